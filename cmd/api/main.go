@@ -1,18 +1,30 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/syukurgit/zta/config"
 	"github.com/syukurgit/zta/internal/domain"
 	"github.com/syukurgit/zta/internal/handler"
+	"github.com/syukurgit/zta/internal/mail"
 	"github.com/syukurgit/zta/internal/middleware"
+	"github.com/syukurgit/zta/internal/oidc"
 	"github.com/syukurgit/zta/internal/repository"
 	"github.com/syukurgit/zta/internal/service"
+	"github.com/syukurgit/zta/internal/storage"
+	"github.com/syukurgit/zta/internal/transcript"
 )
 
 func main() {
 	config.ConnectDB()
+	config.SetupEncryption() // Serializer kolom terenkripsi wajib terdaftar sebelum query pertama
 	// config.MigrateDB() // Uncomment sekali saja saat deployment awal untuk update struktur tabel
 
 	// --- SETUP LAYERS ---
@@ -21,86 +33,304 @@ func main() {
 	auditRepo := repository.NewAuditRepository(config.DB)
 	auditService := service.NewAuditService(auditRepo)
 	auditHandler := handler.NewAuditHandler(auditService)
+	accessLogRepo := repository.NewAccessLogRepository(config.DB)
+
+	// Rule engine anomali: mengamati setiap AuditLog yang ditulis
+	alertRepo := repository.NewAlertRepository(config.DB)
+	anomalyService := service.NewAnomalyService(alertRepo, os.Getenv("ALERT_WEBHOOK_URL"))
+	auditService.OnLog(anomalyService.Evaluate)
+	alertHandler := handler.NewAlertHandler(anomalyService)
+
+	// Arsip & Legal Hold (proses arsip dijalankan lewat cmd/archive)
+	archiveRepo := repository.NewArchiveRepository(config.DB)
+	archiveService := service.NewArchiveService(archiveRepo, auditService, config.Keyring, os.Getenv("ARCHIVE_DIR"))
+	archiveHandler := handler.NewArchiveHandler(archiveService)
 
 	// 2. AUTH LAYER
-	authHandler := &handler.AuthHandler{DB: config.DB}
+	userRepo := repository.NewUserRepository(config.DB)
+	sessionRepo := repository.NewSessionRepository(config.DB)
+	permissionService := service.NewPermissionService(repository.NewPermissionRepository(config.DB), auditService) // Mapping role -> permission (cache)
+	accountService := service.NewAccountService(userRepo, sessionRepo, permissionService, auditService)
+	authHandler := &handler.AuthHandler{DB: config.DB, Accounts: accountService}
+	accountHandler := handler.NewAccountHandler(accountService, userRepo)
+	mailer := mail.FromEnv()
+	adminHandler := handler.NewAdminHandler(service.NewAdminService(userRepo, sessionRepo, auditService, mailer), permissionService)
+	// SSO staff (OIDC); OIDC_ISSUER kosong = hanya login password lokal
+	ssoHandler := handler.NewSSOHandler(nil)
+	if oidcConfig, ok := oidc.ConfigFromEnv(); ok {
+		groupRoles, err := service.ParseGroupRoles(os.Getenv("OIDC_GROUP_ROLES"))
+		if err != nil {
+			log.Fatal("Invalid OIDC_GROUP_ROLES: ", err)
+		}
+		ssoHandler.Service = service.NewSSOService(oidc.NewClient(oidcConfig), repository.NewSSORepository(config.DB),
+			userRepo, sessionRepo, accountService, auditService, groupRoles)
+		ssoHandler.SecureCookie = strings.HasPrefix(oidcConfig.RedirectURL, "https://")
+	}
+	registrationHandler := handler.NewRegistrationHandler(service.NewRegistrationService(userRepo, auditService, mailer))
+
+	// 3. TICKET LAYER (+ SLA)
+	slaRepo := repository.NewSLARepository(config.DB)
+	slaService := service.NewSLAService(slaRepo, auditService)
+	slaHandler := handler.NewSLAHandler(slaService)
+	go slaService.StartMonitor(context.Background(), time.Minute) // Deteksi SLA breach berkala
 
-	// 3. TICKET LAYER
 	ticketRepo := repository.NewTicketRepository(config.DB)
-	ticketService := service.NewTicketService(ticketRepo, auditService)
+	ticketService := service.NewTicketService(ticketRepo, auditService, slaService)
+	ticketService.VerificationCarryOver = os.Getenv("VERIFICATION_CARRY_OVER") == "true"
 	ticketHandler := handler.NewTicketHandler(ticketService)
 
+	// Auto-routing (ROUTING_MODE: MANUAL | ROUND_ROBIN | LEAST_LOADED)
+	agentRepo := repository.NewAgentRepository(config.DB)
+	routingService := service.NewRoutingService(agentRepo, ticketRepo, auditService, os.Getenv("ROUTING_MODE"))
+	go routingService.StartBacklogWorker(context.Background(), time.Minute) // Route backlog antrian (dipicu + berkala)
+	ticketService.Router = routingService
+	routingHandler := handler.NewRoutingHandler(routingService)
+
+	// Batas tiket aktif per agent / tim
+	capacityService := service.NewCapacityService(agentRepo, ticketRepo, auditService)
+	capacityHandler := handler.NewCapacityHandler(capacityService)
+
 	// 4. VERIFICATION LAYER
 	verifRepo := repository.NewVerificationRepository(config.DB)
-	verifService := service.NewVerificationService(verifRepo, auditService)
+	verifService := service.NewVerificationService(verifRepo, auditService, ticketService)
 	verifHandler := handler.NewVerificationHandler(verifService)
 
 	// 5. CHAT LAYER
 	chatRepo := repository.NewChatRepository(config.DB)
-	chatService := service.NewChatService(chatRepo, ticketRepo)
+	chatService := service.NewChatService(chatRepo, ticketRepo, slaService, ticketService, auditService)
 	chatHandler := handler.NewChatHandler(chatService)
 
+	// Event tiket penting -> pesan SYSTEM di chat (link verifikasi / reset langsung ke User)
+	ticketService.OnEvent(chatService.PostSystemEvent)
+	routingService.OnEvent(chatService.PostSystemEvent)
+	verifService.OnEvent(chatService.PostSystemEvent)
+
+	// CSAT: survei satu kali setelah tiket ditutup (didaftarkan setelah pesan SYSTEM agar urutan chat benar)
+	csatService := service.NewCSATService(repository.NewCSATRepository(config.DB), ticketRepo, auditService)
+	ticketService.OnEvent(csatService.HandleTicketEvent)
+	csatService.OnEvent(chatService.PostSystemEvent)
+	auditService.CSATSvc = csatService
+	csatHandler := handler.NewCSATHandler(csatService)
+
+	// Lampiran chat (blob store lokal + stub malware scanner)
+	attachmentRepo := repository.NewAttachmentRepository(config.DB)
+	blobStore := storage.NewLocalBlobStore(os.Getenv("ATTACHMENT_DIR"))
+	attachmentService := service.NewAttachmentService(attachmentRepo, chatService, auditService, blobStore, storage.StubScanner{})
+	if v, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		attachmentService.MaxBytes = v
+	}
+	if v := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); v != "" {
+		attachmentService.AllowedTypes = map[string]bool{}
+		for _, t := range strings.Split(v, ",") {
+			attachmentService.AllowedTypes[strings.TrimSpace(t)] = true
+		}
+	}
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+
+	// Crypto-shredding data tiket (hapus data key per tiket)
+	shredHandler := handler.NewShredHandler(service.NewShredService(config.Keyring, ticketRepo, chatRepo, attachmentRepo, blobStore, auditService))
+
+	// Ekspor transcript tiket bertanda tangan (Ed25519, kunci diturunkan dari TRANSCRIPT_SIGNING_KEY)
+	signingSecret := os.Getenv("TRANSCRIPT_SIGNING_KEY")
+	if signingSecret == "" {
+		signingSecret = os.Getenv("SYSTEM_SECRET_KEY")
+	}
+	transcriptHandler := handler.NewTranscriptHandler(service.NewTranscriptService(ticketRepo, chatRepo, auditRepo, verifRepo,
+		repository.NewTranscriptRepository(config.DB), auditService, transcript.NewSigner(signingSecret)))
+
+	// Catatan internal (CS / Supervisor / Auditor saja)
+	noteRepo := repository.NewNoteRepository(config.DB)
+	noteService := service.NewNoteService(noteRepo, ticketRepo, auditService)
+	noteHandler := handler.NewNoteHandler(noteService)
+
+	// Canned responses & macro (library tim + pribadi)
+	cannedService := service.NewCannedResponseService(repository.NewCannedResponseRepository(config.DB), agentRepo, ticketRepo,
+		chatService, ticketService, verifService, auditService)
+	cannedHandler := handler.NewCannedResponseHandler(cannedService)
+
 	// --- SETUP ROUTER ---
-	r := gin.Default()
+	r := gin.New()
+	r.Use(middleware.RequestID())     // Harus paling awal: korelasi log, audit & response
+	r.Use(middleware.RequestLogger()) // Log server dengan Request ID
+	r.Use(gin.Recovery())
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
 	// Public Route
 	r.POST("/login", authHandler.Login)
+	r.GET("/auth/oidc/login", ssoHandler.Login)        // Redirect ke IdP (authorization code + PKCE)
+	r.POST("/auth/oidc/callback", ssoHandler.Callback) // Tukar code -> token aplikasi
+	r.POST("/register", registrationHandler.Register)
+	r.POST("/register/resend", registrationHandler.ResendConfirmation)
+	r.POST("/register/confirm/:token", registrationHandler.ConfirmEmail)
+	r.POST("/invite/:token", registrationHandler.AcceptInvite) // Staff undangan ADMIN menetapkan password
 
 	// Verification Routes (Public but Secure via Token)
 	r.GET("/verify/:token", verifHandler.GetVerificationPage)
 	r.POST("/verify/:token", verifHandler.SubmitVerification)
 	r.POST("/reset-password", ticketHandler.SubmitUserResetPassword)
+	r.GET("/csat/:token", csatHandler.GetSurvey)     // Link survei sekali pakai (dikirim via chat)
+	r.POST("/csat/:token", csatHandler.SubmitSurvey)
+	r.GET("/attachments/:id", attachmentHandler.DownloadAttachment) // Signed URL berumur pendek
 
 	api := r.Group("/api")
-	api.Use(middleware.AuthMiddleware())
+	api.Use(middleware.AuthMiddleware(sessionRepo, permissionService)) // JWT + sesi server-side (bisa dicabut) + permission
+	api.Use(middleware.AuditAccessLogger(accessLogRepo)) // Audit-of-audit: semua bacaan AUDITOR dicatat
 	{
 		// Endpoint Log Box untuk CS (Real-time monitoring)
-		api.GET("/audit/tickets/:id", auditHandler.GetLogsByTicket)
+		api.GET("/audit/tickets/:id", middleware.RequirePermission(domain.PermTicketLogView), auditHandler.GetLogsByTicket)
+
+		// Akun sendiri (semua role): MFA, step-up, logout & nama tampilan
+		accountGroup := api.Group("/account")
+		{
+			accountGroup.GET("/mfa", accountHandler.GetMFAStatus)
+			accountGroup.POST("/mfa/enroll", accountHandler.EnrollMFA)
+			accountGroup.POST("/mfa/activate", accountHandler.ActivateMFA)
+			accountGroup.POST("/step-up", accountHandler.StepUp)
+			accountGroup.POST("/logout", accountHandler.Logout)
+			accountGroup.PUT("/profile", accountHandler.UpdateProfile)
+		}
 
 		// GROUP: USER
 		userGroup := api.Group("/user")
-		userGroup.Use(middleware.EnforceRole(domain.RoleUser))
+		userGroup.Use(middleware.RequirePermission(domain.PermTicketOwn), middleware.ActAs(domain.RoleUser))
 		{
+			userGroup.GET("/onboarding", verifHandler.GetOnboarding)
+			userGroup.PUT("/onboarding/verification-answers", verifHandler.EnrollVerificationAnswers)
 			userGroup.POST("/tickets", ticketHandler.CreateTicket)
 			userGroup.POST("/tickets/:id/chat", chatHandler.SendChat)
 			userGroup.GET("/tickets/:id/chat", chatHandler.GetHistory)
+			userGroup.PUT("/tickets/:id/chat/:chatId", chatHandler.EditChat)
+			userGroup.DELETE("/tickets/:id/chat/:chatId", chatHandler.RetractChat)
+			userGroup.POST("/tickets/:id/chat/read", chatHandler.MarkRead)
+			userGroup.GET("/tickets/unread", chatHandler.GetUnreadCounts)
+			userGroup.POST("/tickets/:id/chat/attachments", attachmentHandler.UploadAttachment)
+			userGroup.GET("/tickets/:id/chat/attachments/:attachmentId/url", attachmentHandler.GetAttachmentURL)
 			userGroup.POST("/tickets/:id/close", ticketHandler.CloseTicket)
+			userGroup.POST("/tickets/:id/reopen", ticketHandler.ReopenTicket)
 			userGroup.GET("/tickets", ticketHandler.GetUserTickets)
 			userGroup.GET("/tickets/:id", ticketHandler.GetTicketDetail)
 		}
 
 		// GROUP: CS
 		csGroup := api.Group("/cs")
-		csGroup.Use(middleware.EnforceRole(domain.RoleCS))
+		csGroup.Use(middleware.RequirePermission(domain.PermTicketWork), middleware.ActAs(domain.RoleCS))
 		{
+			csGroup.GET("/profile", routingHandler.GetMyProfile)
+			csGroup.PUT("/presence", routingHandler.SetPresence)
 			csGroup.GET("/tickets/open", ticketHandler.GetOpenTickets)
 			csGroup.POST("/tickets/:id/claim", ticketHandler.ClaimTicket)
-			csGroup.POST("/tickets/:id/start-verification", verifHandler.StartVerification)
-			csGroup.POST("/tickets/:id/reset-password", ticketHandler.ResetPasswordAction)
+			csGroup.POST("/tickets/:id/pending-user", ticketHandler.MarkPendingUser)
+			csGroup.POST("/tickets/:id/resume", ticketHandler.ResumeTicket)
+			csGroup.POST("/tickets/:id/transfer", ticketHandler.TransferTicket)
+			csGroup.POST("/tickets/:id/start-verification", middleware.RequirePermission(domain.PermTicketVerify), verifHandler.StartVerification)
+			csGroup.POST("/tickets/:id/reset-password", middleware.RequirePermission(domain.PermTicketResetPassword), ticketHandler.ResetPasswordAction)
 			csGroup.GET("/tickets/history", ticketHandler.GetCSHistory)
 			csGroup.POST("/tickets/:id/chat", chatHandler.SendChat)
 			csGroup.GET("/tickets/:id/chat", chatHandler.GetHistory)
+			csGroup.PUT("/tickets/:id/chat/:chatId", chatHandler.EditChat)
+			csGroup.DELETE("/tickets/:id/chat/:chatId", chatHandler.RetractChat)
+			csGroup.POST("/tickets/:id/chat/read", chatHandler.MarkRead)
+			csGroup.GET("/tickets/unread", chatHandler.GetUnreadCounts)
+			csGroup.POST("/tickets/:id/chat/attachments", attachmentHandler.UploadAttachment)
+			csGroup.GET("/tickets/:id/chat/attachments/:attachmentId/url", attachmentHandler.GetAttachmentURL)
+			csGroup.GET("/tickets/:id/chat/redactions/:redactionId", middleware.RequirePermission(domain.PermChatRevealRedaction), chatHandler.RevealRedaction)
+			csGroup.GET("/tickets/:id/notes", noteHandler.GetNotes)
+			csGroup.POST("/tickets/:id/notes", noteHandler.AddNote)
+			csGroup.PUT("/notes/:id", noteHandler.EditNote)
+			csGroup.GET("/notes/:id/revisions", noteHandler.GetNoteRevisions)
+			csGroup.GET("/mentions", noteHandler.GetMentions)
+			csGroup.GET("/canned-responses", cannedHandler.ListCannedResponses)
+			csGroup.POST("/canned-responses", cannedHandler.CreateCannedResponse)
+			csGroup.PUT("/canned-responses/:id", cannedHandler.UpdateCannedResponse)
+			csGroup.DELETE("/canned-responses/:id", cannedHandler.DeleteCannedResponse)
+			csGroup.GET("/tickets/:id/canned-responses/:responseId/preview", cannedHandler.PreviewCannedResponse)
+			csGroup.POST("/tickets/:id/canned-responses/:responseId/apply", cannedHandler.ApplyCannedResponse)
 			csGroup.POST("/tickets/:id/close", ticketHandler.CloseTicket)
 			csGroup.GET("/tickets/mine", ticketHandler.GetCSActiveTickets)
 			csGroup.GET("/tickets/:id", ticketHandler.GetTicketDetail)
 		}
 
+		// GROUP: SUPERVISOR (Monitoring SLA & kebijakan tim)
+		supervisorGroup := api.Group("/supervisor")
+		supervisorGroup.Use(middleware.RequirePermission(domain.PermTeamSupervise), middleware.ActAs(domain.RoleSupervisor))
+		{
+			supervisorGroup.GET("/sla/breaches", slaHandler.GetBreaches)
+			supervisorGroup.GET("/sla/policies", slaHandler.GetPolicies)
+			supervisorGroup.PUT("/sla/policies", middleware.RequirePermission(domain.PermSLAManage), slaHandler.UpsertPolicy)
+			supervisorGroup.POST("/tickets/:id/close", ticketHandler.CloseTicket) // Menutup tiket LOCKED
+			supervisorGroup.POST("/tickets/:id/reassign", ticketHandler.TransferTicket)
+			supervisorGroup.GET("/tickets/:id/assignments", ticketHandler.GetAssignmentHistory)
+			supervisorGroup.POST("/tickets/:id/dlp-access", middleware.RequirePermission(domain.PermDLPGrant), chatHandler.GrantRevealAccess)
+			supervisorGroup.POST("/tickets/:id/shred", middleware.RequirePermission(domain.PermTicketShred), shredHandler.ShredTicket)
+			supervisorGroup.GET("/tickets/:id/notes", noteHandler.GetNotes)
+			supervisorGroup.POST("/tickets/:id/notes", noteHandler.AddNote)
+			supervisorGroup.PUT("/notes/:id", noteHandler.EditNote)
+			supervisorGroup.GET("/notes/:id/revisions", noteHandler.GetNoteRevisions)
+			supervisorGroup.GET("/mentions", noteHandler.GetMentions)
+			supervisorGroup.GET("/canned-responses", cannedHandler.ListCannedResponses)
+			supervisorGroup.GET("/canned-responses/usage", cannedHandler.GetUsageStats)
+			supervisorGroup.POST("/canned-responses", cannedHandler.CreateCannedResponse)
+			supervisorGroup.PUT("/canned-responses/:id", cannedHandler.UpdateCannedResponse)
+			supervisorGroup.DELETE("/canned-responses/:id", cannedHandler.DeleteCannedResponse)
+			supervisorGroup.GET("/reports/csat", csatHandler.GetCSATReport)
+			supervisorGroup.GET("/agents", routingHandler.GetAgents)
+			supervisorGroup.PUT("/agents/:id/profile", routingHandler.UpdateAgentProfile)
+			supervisorGroup.PUT("/agents/:id/capacity", capacityHandler.SetAgentCapacity)
+			supervisorGroup.GET("/teams", capacityHandler.GetTeams)
+			supervisorGroup.POST("/teams", capacityHandler.CreateTeam)
+			supervisorGroup.PUT("/teams/:id", capacityHandler.UpdateTeam)
+		}
+
 		// GROUP: AUDITOR (Updated with Zero Trust Report Routes)
 		auditorGroup := api.Group("/auditor")
-		auditorGroup.Use(middleware.EnforceRole(domain.RoleAuditor))
+		auditorGroup.Use(middleware.RequirePermission(domain.PermAuditRead), middleware.ActAs(domain.RoleAuditor))
 		{
 			auditorGroup.GET("/logs", auditHandler.GetLogs)                      // Log mentah (Immutable)
 			auditorGroup.GET("/reports", auditHandler.GetAuditReports)           // Daftar laporan per tiket
+			auditorGroup.GET("/reports/analytics", auditHandler.GetAnalyticsReport) // Laporan agregat (JSON / ?format=csv)
 			auditorGroup.GET("/tickets/:id/logs", auditHandler.GetLogsByTicket)  // Timeline detail log per tiket
 			auditorGroup.GET("/tickets/:id/chat", chatHandler.GetHistory)       // Riwayat chat untuk audit
+			auditorGroup.GET("/tickets/:id/chat/:chatId/revisions", chatHandler.GetRevisions) // Versi lama pesan yang diedit / ditarik
+			auditorGroup.GET("/tickets/:id/chat/attachments/:attachmentId/url", attachmentHandler.GetAttachmentURL)
+			auditorGroup.GET("/tickets/:id/notes", noteHandler.GetNotes)        // Catatan internal CS
+			auditorGroup.GET("/tickets/:id/timeline", noteHandler.GetTicketTimeline) // AuditLog + catatan internal
+			auditorGroup.GET("/tickets/:id/transcript", middleware.RequirePermission(domain.PermTranscriptExport), transcriptHandler.ExportTranscript) // Bundle zip HTML + PDF + manifest bertanda tangan
+			auditorGroup.POST("/transcripts/verify", transcriptHandler.VerifyTranscript)
+			auditorGroup.GET("/transcripts/public-key", transcriptHandler.GetSigningKey)
+			auditorGroup.GET("/notes/:id/revisions", noteHandler.GetNoteRevisions)
+			auditorGroup.GET("/alerts", alertHandler.GetAlerts)                 // Alert dari rule engine anomali
+			auditorGroup.GET("/archive/segments", archiveHandler.GetSegments)
+			auditorGroup.GET("/archive/segments/:id", archiveHandler.QuerySegment)
+			auditorGroup.GET("/archive/verify", archiveHandler.VerifyChain)
+			auditorGroup.POST("/tickets/:id/legal-hold", middleware.RequirePermission(domain.PermArchiveLegalHold), archiveHandler.SetLegalHold)
+		}
+
+		// GROUP: ADMIN (Manajemen akun staff, wajib step-up TOTP)
+		adminGroup := api.Group("/admin")
+		adminGroup.Use(middleware.RequirePermission(domain.PermStaffManage), middleware.ActAs(domain.RoleAdmin))
+		adminGroup.Use(middleware.RequireStepUp(service.StepUpMaxAge))
+		{
+			adminGroup.GET("/users", adminHandler.ListStaff)
+			adminGroup.POST("/users", adminHandler.CreateStaff)
+			adminGroup.PUT("/users/:id/role", adminHandler.ChangeRole)
+			adminGroup.PUT("/users/:id/roles", adminHandler.SetRoles)
+			adminGroup.POST("/users/:id/disable", adminHandler.DisableUser)
+			adminGroup.POST("/users/:id/enable", adminHandler.EnableUser)
+			adminGroup.POST("/users/:id/mfa/reset", adminHandler.ResetMFA)
+			adminGroup.GET("/users/:id/sessions", adminHandler.ListSessions)
+			adminGroup.DELETE("/users/:id/sessions", adminHandler.RevokeSessions)
+			adminGroup.GET("/role-requests", adminHandler.ListRoleRequests)
+			adminGroup.POST("/role-requests/:id/approve", adminHandler.ApproveRoleRequest)
+			adminGroup.POST("/role-requests/:id/reject", adminHandler.RejectRoleRequest)
+			adminGroup.GET("/permissions", middleware.RequirePermission(domain.PermRBACManage), adminHandler.GetRolePermissions)
+			adminGroup.PUT("/roles/:role/permissions", middleware.RequirePermission(domain.PermRBACManage), adminHandler.SetRolePermissions)
 		}
 	}
 
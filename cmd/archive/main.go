@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/syukurgit/zta/config"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+// Jalankan berkala (cron): go run ./cmd/archive
+//
+//	-verify       : hanya verifikasi hash chain
+//	-restore=<ID> : kembalikan isi segment ke DB
+func main() {
+	verify := flag.Bool("verify", false, "verify archive hash chain only")
+	restore := flag.Uint("restore", 0, "restore segment ID back into the database")
+	flag.Parse()
+
+	config.ConnectDB()
+	config.SetupEncryption()
+	ctx := context.Background()
+
+	auditService := service.NewAuditService(repository.NewAuditRepository(config.DB))
+	archiveService := service.NewArchiveService(repository.NewArchiveRepository(config.DB), auditService, config.Keyring, os.Getenv("ARCHIVE_DIR"))
+
+	switch {
+	case *verify:
+		if err := archiveService.VerifyChain(ctx); err != nil {
+			log.Fatal("❌ Archive chain invalid: ", err)
+		}
+		fmt.Println("✅ Archive hash chain is intact")
+
+	case *restore > 0:
+		n, err := archiveService.RestoreSegment(ctx, *restore)
+		if err != nil {
+			log.Fatal("❌ Restore failed: ", err)
+		}
+		fmt.Printf("✅ Restored %d rows from segment %d\n", n, *restore)
+
+	default:
+		segments, err := archiveService.RunArchival(ctx)
+		for _, seg := range segments {
+			fmt.Printf("📦 Segment %d (%s): %d rows -> %s\n", seg.ID, seg.Kind, seg.RowCount, seg.FilePath)
+		}
+		if err != nil {
+			log.Fatal("❌ Archival stopped: ", err)
+		}
+		fmt.Printf("✅ Archival completed, %d segment(s) written\n", len(segments))
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/syukurgit/zta/internal/oidc/mockidp"
+)
+
+// IdP tiruan untuk development lokal: go run ./cmd/mockidp
+// Set di .env aplikasi: OIDC_ISSUER=http://localhost:9000, OIDC_CLIENT_ID=zta, OIDC_CLIENT_SECRET=zta-secret
+//
+//	MOCK_IDP_ADDR   : alamat listen (default :9000)
+//	MOCK_IDP_ISSUER : issuer yang diiklankan (default http://localhost:9000)
+func main() {
+	addr := envOr("MOCK_IDP_ADDR", ":9000")
+	issuer := envOr("MOCK_IDP_ISSUER", "http://localhost:9000")
+
+	idp, err := mockidp.New(issuer, envOr("OIDC_CLIENT_ID", "zta"), envOr("OIDC_CLIENT_SECRET", "zta-secret"),
+		mockidp.User{Subject: "sso-cs-001", Email: "sso.cs@company.com", EmailVerified: true, Groups: []string{"cs-agents"}},
+		mockidp.User{Subject: "sso-lead-001", Email: "sso.lead@company.com", EmailVerified: true, Groups: []string{"cs-leads", "cs-agents"}, AMR: []string{"pwd", "mfa"}},
+		mockidp.User{Subject: "sso-auditor-001", Email: "sso.auditor@company.com", EmailVerified: true, Groups: []string{"auditors"}, AMR: []string{"pwd", "mfa"}},
+		mockidp.User{Subject: "sso-guest-001", Email: "guest@partner.com", EmailVerified: true, Groups: []string{"contractors"}},
+	)
+	if err != nil {
+		log.Fatal("❌ Failed to create mock IdP: ", err)
+	}
+
+	fmt.Printf("🔐 Mock IdP running at %s (issuer %s)\n", addr, issuer)
+	log.Fatal(idp.ListenAndServe(addr))
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -13,6 +13,7 @@ import (
 func main() {
 	// 1. Connect DB
 	config.ConnectDB()
+	config.SetupEncryption()
 
 	// 2. Seed Users
 	seedUsers(config.DB)
@@ -20,6 +21,18 @@ func main() {
 	// 3. Seed Questions
 	seedQuestions(config.DB)
 
+	// 4. Seed Retention Policies
+	seedRetentionPolicies(config.DB)
+
+	// 5. Seed SLA Policies
+	seedSLAPolicies(config.DB)
+
+	// 6. Seed Agent Profiles (routing)
+	seedAgentProfiles(config.DB)
+
+	// 7. Seed Role -> Permission mapping
+	seedRolePermissions(config.DB)
+
 	fmt.Println("🌱 Database seeding completed successfully!")
 }
 
@@ -40,17 +53,37 @@ func seedUsers(db *gorm.DB) {
 			Role:         "CS",
 			RiskScore:    0,
 		},
+		{
+			Email:        "supervisor@company.com",
+			PasswordHash: hashedPassword,
+			Role:         "SUPERVISOR",
+			RiskScore:    0,
+		},
 		{
 			Email:        "auditor@company.com",
 			PasswordHash: hashedPassword,
 			Role:         "AUDITOR",
 			RiskScore:    0,
 		},
+		{
+			Email:        "admin@company.com",
+			PasswordHash: hashedPassword,
+			Role:         "ADMIN",
+			RiskScore:    0,
+		},
+		{
+			// Team lead: role utama SUPERVISOR + role tambahan CS
+			Email:        "lead@company.com",
+			PasswordHash: hashedPassword,
+			Role:         "SUPERVISOR",
+			RiskScore:    0,
+			ExtraRoles:   []domain.UserRole{{Role: "CS"}},
+		},
 	}
 
 	for _, u := range users {
 		// FirstOrCreate mencegah duplikasi data jika script dijalankan 2x
-		if err := db.Where("email = ?", u.Email).FirstOrCreate(&u).Error; err != nil {
+		if err := db.Where("email_index = ?", utils.BlindIndex(u.Email)).FirstOrCreate(&u).Error; err != nil {
 			log.Printf("Failed to seed user %s: %v", u.Email, err)
 		} else {
 			fmt.Printf("✅ User seeded: %s\n", u.Email)
@@ -87,6 +120,78 @@ func seedQuestions(db *gorm.DB) {
 	}
 }
 
+func seedRetentionPolicies(db *gorm.DB) {
+	// Default retensi: log umum 1 tahun, aksi sensitif 2 tahun, chat tiket CLOSED 180 hari
+	policies := []domain.RetentionPolicy{
+		{Kind: "AUDIT_LOG", Action: "*", RetentionDays: 365},
+		{Kind: "AUDIT_LOG", Action: "GENERATE_RESET_LINK", RetentionDays: 730},
+		{Kind: "AUDIT_LOG", Action: "SET_NEW_PASSWORD", RetentionDays: 730},
+		{Kind: "CHAT", Action: "*", RetentionDays: 180},
+	}
+
+	for _, p := range policies {
+		if err := db.Where("kind = ? AND action = ?", p.Kind, p.Action).FirstOrCreate(&p).Error; err != nil {
+			log.Printf("Failed to seed retention policy: %v", err)
+		} else {
+			fmt.Printf("✅ Retention policy seeded: %s/%s\n", p.Kind, p.Action)
+		}
+	}
+}
+
+func seedSLAPolicies(db *gorm.DB) {
+	// Target dalam menit. Priority "*" berlaku untuk semua prioritas kategori tsb.
+	policies := []domain.SLAPolicy{
+		{Category: "GENERAL", Priority: "*", FirstResponseMinutes: 60, ResolutionMinutes: 24 * 60},
+		{Category: "GENERAL", Priority: "URGENT", FirstResponseMinutes: 15, ResolutionMinutes: 4 * 60},
+		{Category: "ACCOUNT", Priority: "*", FirstResponseMinutes: 30, ResolutionMinutes: 8 * 60},
+		{Category: "ACCOUNT", Priority: "URGENT", FirstResponseMinutes: 10, ResolutionMinutes: 2 * 60},
+		{Category: "PAYMENT", Priority: "*", FirstResponseMinutes: 30, ResolutionMinutes: 12 * 60},
+		{Category: "TECHNICAL", Priority: "*", FirstResponseMinutes: 120, ResolutionMinutes: 48 * 60},
+	}
+
+	for _, p := range policies {
+		if err := db.Where("category = ? AND priority = ?", p.Category, p.Priority).FirstOrCreate(&p).Error; err != nil {
+			log.Printf("Failed to seed SLA policy: %v", err)
+		} else {
+			fmt.Printf("✅ SLA policy seeded: %s/%s\n", p.Category, p.Priority)
+		}
+	}
+}
+
+func seedAgentProfiles(db *gorm.DB) {
+	var cs domain.User
+	if err := db.Where("email_index = ?", utils.BlindIndex("cs@company.com")).First(&cs).Error; err != nil {
+		log.Printf("Failed to find CS for agent profile: %v", err)
+		return
+	}
+
+	// Tim default: batas 1 tiket aktif per agent (agent bisa override lewat Capacity)
+	team := domain.Team{Name: "General Support", MaxActiveTickets: 1}
+	if err := db.Where("name = ?", team.Name).FirstOrCreate(&team).Error; err != nil {
+		log.Printf("Failed to seed team: %v", err)
+		return
+	}
+
+	profile := domain.AgentProfile{UserID: cs.ID, Skills: "*", Languages: "id,en", TeamID: &team.ID, Presence: domain.PresenceOffline}
+	if err := db.Omit("User").Where("user_id = ?", cs.ID).FirstOrCreate(&profile).Error; err != nil {
+		log.Printf("Failed to seed agent profile: %v", err)
+	} else {
+		fmt.Printf("✅ Agent profile seeded: %s\n", cs.Email)
+	}
+}
+
+func seedRolePermissions(db *gorm.DB) {
+	for role, perms := range domain.DefaultRolePermissions {
+		for _, perm := range perms {
+			row := domain.RolePermission{Role: role, Permission: perm}
+			if err := db.Where("role = ? AND permission = ?", role, perm).FirstOrCreate(&row).Error; err != nil {
+				log.Printf("Failed to seed role permission %s/%s: %v", role, perm, err)
+			}
+		}
+		fmt.Printf("✅ Role permissions seeded: %s\n", role)
+	}
+}
+
 // Helper kecil untuk seeder ini saja
 func hashAnswer(ans string) string {
 	h, _ := utils.HashPassword(ans)
@@ -57,17 +57,62 @@ func MigrateDB() {
 		&domain.User{},
 		&domain.Ticket{},
 		&domain.TicketAssignment{},
+		&domain.TicketAssignmentHistory{},
+		&domain.Chat{},
 		&domain.VerificationSession{},
 		&domain.VerificationQuestion{},
 		&domain.TemporaryPrivilege{},
 		&domain.AuditLog{},
 		&domain.VerificationAttempt{},
 		&domain.VerificationSession{}, // Tabel anak
+		&domain.AuditAccessLog{},
+		&domain.Alert{},
+		&domain.RetentionPolicy{},
+		&domain.ArchiveSegment{},
+		&domain.SLAPolicy{},
+		&domain.Team{},
+		&domain.AgentProfile{},
+		&domain.Attachment{},
+		&domain.ChatRedaction{},
+		&domain.ChatRevision{},
+		&domain.ChatReceipt{},
+		&domain.TicketNote{},
+		&domain.TicketNoteRevision{},
+		&domain.TicketNoteMention{},
+		&domain.DataKey{},
+		&domain.TranscriptExport{},
+		&domain.CannedResponse{},
+		&domain.CannedResponseUsage{},
+		&domain.CSATSurvey{},
+		&domain.EmailVerificationToken{},
+		&domain.UserVerificationAnswer{},
+		&domain.UserSession{},
+		&domain.UserMFA{},
+		&domain.RolePermission{},
+		&domain.UserRole{},
+		&domain.UserIdentity{},
+		&domain.OIDCLoginState{},
+		&domain.RoleGrantRequest{},
 	)
 
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// FK audit_logs -> tickets dari migrasi lama menolak event tanpa tiket (ticket_id = 0)
+	if DB.Migrator().HasConstraint(&domain.AuditLog{}, "fk_audit_logs_ticket") {
+		if err := DB.Migrator().DropConstraint(&domain.AuditLog{}, "fk_audit_logs_ticket"); err != nil {
+			log.Fatal("Failed to drop audit log foreign key:", err)
+		}
+	}
+
+	// Pesan lama belum punya updated_at (cursor sinkronisasi chat)
+	if err := DB.Exec("UPDATE chats SET updated_at = COALESCE(edited_at, retracted_at, created_at) WHERE updated_at IS NULL").Error; err != nil {
+		log.Fatal("Failed to backfill chat updated_at:", err)
+	}
+
+	// Data lama sebelum enkripsi at-rest
+	BackfillEncryption()
+
 	fmt.Println("✅ Database Migration Completed Successfully!")
 }
\ No newline at end of file
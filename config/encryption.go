@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/envelope"
+	"github.com/syukurgit/zta/internal/kms"
+	"gorm.io/gorm"
+)
+
+var Keyring *envelope.Keyring
+
+// SetupEncryption menyiapkan KMS + keyring dan mendaftarkan serializer GORM terenkripsi.
+// Harus dipanggil setelah ConnectDB dan sebelum MigrateDB / query pertama.
+func SetupEncryption() {
+	if DB == nil {
+		log.Fatal("Database connection is not initialized")
+	}
+
+	var provider kms.KeyProvider
+	switch os.Getenv("KMS_PROVIDER") {
+	case "", "local":
+		keyFile := os.Getenv("KMS_KEY_FILE")
+		if keyFile == "" {
+			keyFile = "./keys/master.keys"
+		}
+		local, err := kms.NewLocalFileKeyProvider(keyFile)
+		if err != nil {
+			log.Fatal("Failed to load KMS key file:", err)
+		}
+		provider = local
+	default:
+		log.Fatal("Unknown KMS_PROVIDER: ", os.Getenv("KMS_PROVIDER"))
+	}
+
+	Keyring = envelope.NewKeyring(DB, provider)
+	envelope.Register(Keyring)
+	fmt.Println("🔐 Encryption at rest enabled (envelope AES-GCM)")
+}
+
+// BackfillEncryption mengenkripsi data lama (plaintext): email user (+ blind index), chat & catatan tiket
+// beserta revisinya, dan hash jawaban verifikasi
+func BackfillEncryption() {
+	var users []domain.User
+	if err := DB.Where("email_index IS NULL OR email_index = ''").Find(&users).Error; err != nil {
+		log.Fatal("Failed to load users for encryption backfill:", err)
+	}
+	for i := range users {
+		if err := DB.Save(&users[i]).Error; err != nil {
+			log.Fatal("Failed to encrypt user email:", err)
+		}
+	}
+	if len(users) > 0 {
+		fmt.Printf("🔐 Encrypted %d legacy user email(s)\n", len(users))
+	}
+
+	// Revisi catatan lama belum punya ticket_id (scope data key)
+	if err := DB.Exec("UPDATE ticket_note_revisions r JOIN ticket_notes n ON n.id = r.note_id " +
+		"SET r.ticket_id = n.ticket_id WHERE r.ticket_id = 0").Error; err != nil {
+		log.Fatal("Failed to backfill note revision ticket IDs:", err)
+	}
+
+	counts := map[string]int{
+		"chat":                 backfillColumn[domain.Chat]("message", true),
+		"chat revision":        backfillColumn[domain.ChatRevision]("message", true),
+		"ticket note":          backfillColumn[domain.TicketNote]("body", true),
+		"note revision":        backfillColumn[domain.TicketNoteRevision]("body", true),
+		"question answer key":  backfillColumn[domain.VerificationQuestion]("answer_hash", false),
+		"personal answer hash": backfillColumn[domain.UserVerificationAnswer]("answer_hash", false),
+	}
+	for name, n := range counts {
+		if n > 0 {
+			fmt.Printf("🔐 Encrypted %d legacy %s row(s)\n", n, name)
+		}
+	}
+}
+
+// backfillColumn menulis ulang baris yang kolomnya masih plaintext (tanpa prefix envelope) lewat serializer.
+// Model ber-scope tiket butuh ticket_id; baris tanpa tiket dilewati.
+func backfillColumn[T any](column string, ticketScoped bool) int {
+	query := DB.Where(column+" NOT LIKE ? AND "+column+" <> ''", envelope.ValuePrefix+"%")
+	if ticketScoped {
+		query = query.Where("ticket_id <> 0")
+	}
+
+	var rows []T
+	total := 0
+	err := query.FindInBatches(&rows, 200, func(tx *gorm.DB, batch int) error {
+		for i := range rows {
+			if err := DB.Model(&rows[i]).Select(column).Updates(&rows[i]).Error; err != nil {
+				return err
+			}
+		}
+		total += len(rows)
+		return nil
+	}).Error
+	if err != nil {
+		log.Fatalf("Failed to encrypt legacy %s: %v", column, err)
+	}
+	return total
+}
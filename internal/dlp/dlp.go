@@ -0,0 +1,166 @@
+// Package dlp mendeteksi & menyamarkan data sensitif (PII, secret, link reset) di teks chat.
+package dlp
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Jenis temuan DLP
+const (
+	KindNIK       = "NIK"
+	KindCard      = "CARD"
+	KindPhone     = "PHONE"
+	KindEmail     = "EMAIL"
+	KindResetURL  = "RESET_URL"
+	KindVerifyURL = "VERIFY_URL"
+	KindSurveyURL = "SURVEY_URL"
+	KindPassword  = "PASSWORD"
+)
+
+// Match: Satu temuan. Original TIDAK boleh disimpan plaintext oleh pemanggil.
+type Match struct {
+	Kind     string
+	Start    int
+	End      int
+	Original string
+	Masked   string
+}
+
+// Detector: Pola + validasi tambahan (mis. Luhn) + cara menyamarkan
+type Detector struct {
+	Kind     string
+	Pattern  *regexp.Regexp
+	Group    int // Sub-match yang disamarkan (0 = seluruh match)
+	Validate func(string) bool
+	Mask     func(string) string
+}
+
+// DefaultDetectors: Urutan penting; temuan yang tumpang tindih dengan temuan sebelumnya diabaikan.
+// CARD sebelum NIK: 16 digit yang lolos Luhn dianggap nomor kartu, sisanya baru dicek sebagai NIK.
+var DefaultDetectors = []Detector{
+	{Kind: KindResetURL, Pattern: regexp.MustCompile(`(?i)\bhttps?://[^\s]+/reset-password/[A-Za-z0-9_-]+`), Mask: fixedMask("[reset link disembunyikan]")},
+	{Kind: KindVerifyURL, Pattern: regexp.MustCompile(`(?i)\bhttps?://[^\s]+/verify/[A-Za-z0-9_-]+`), Mask: fixedMask("[link verifikasi disembunyikan]")},
+	{Kind: KindSurveyURL, Pattern: regexp.MustCompile(`(?i)\bhttps?://[^\s]+/csat/[A-Za-z0-9_-]+`), Mask: fixedMask("[link survei disembunyikan]")},
+	{Kind: KindPassword, Pattern: regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|kata ?sandi|sandi)\s*[:=]\s*(\S+)`), Group: 1, Mask: fixedMask("********")},
+	{Kind: KindEmail, Pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`), Mask: maskEmail},
+	{Kind: KindCard, Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), Validate: validLuhn, Mask: maskLast4},
+	{Kind: KindNIK, Pattern: regexp.MustCompile(`\b\d{16}\b`), Validate: validNIK, Mask: maskLast4},
+	{Kind: KindPhone, Pattern: regexp.MustCompile(`(?:\+62|\b62|\b0)[ -]?8[1-9](?:[ -]?\d){6,10}\b`), Mask: maskLast4},
+}
+
+// Redactor menjalankan pipeline detektor
+type Redactor struct {
+	Detectors []Detector
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{Detectors: DefaultDetectors}
+}
+
+// Redact mengembalikan teks yang sudah disamarkan beserta daftar temuan (urut posisi)
+func (r *Redactor) Redact(text string) (string, []Match) {
+	var matches []Match
+	for _, d := range r.Detectors {
+		for _, loc := range d.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[2*d.Group], loc[2*d.Group+1]
+			if start < 0 || overlaps(matches, start, end) {
+				continue
+			}
+			original := text[start:end]
+			if d.Validate != nil && !d.Validate(original) {
+				continue
+			}
+			matches = append(matches, Match{Kind: d.Kind, Start: start, End: end, Original: original, Masked: d.Mask(original)})
+		}
+	}
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString(m.Masked)
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String(), matches
+}
+
+func overlaps(matches []Match, start, end int) bool {
+	for _, m := range matches {
+		if start < m.End && m.Start < end {
+			return true
+		}
+	}
+	return false
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// validNIK: Kode provinsi 11-94, tanggal lahir 01-31 (perempuan +40), bulan 01-12
+func validNIK(s string) bool {
+	province := atoi2(s[0:2])
+	day := atoi2(s[6:8])
+	month := atoi2(s[8:10])
+	if day > 40 {
+		day -= 40
+	}
+	return province >= 11 && province <= 94 && day >= 1 && day <= 31 && month >= 1 && month <= 12
+}
+
+func atoi2(s string) int {
+	return int(s[0]-'0')*10 + int(s[1]-'0')
+}
+
+// validLuhn: Checksum nomor kartu
+func validLuhn(s string) bool {
+	digits := digitsOnly(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func fixedMask(mask string) func(string) string {
+	return func(string) string { return mask }
+}
+
+func maskLast4(s string) string {
+	digits := digitsOnly(s)
+	if len(digits) <= 4 {
+		return strings.Repeat("*", len(digits))
+	}
+	return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+}
+
+func maskEmail(s string) string {
+	at := strings.LastIndex(s, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return s[:1] + "***" + s[at:]
+}
@@ -0,0 +1,168 @@
+package dlp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/syukurgit/zta/internal/dlp"
+)
+
+// finding: Bagian Match yang dicek (posisi diuji terpisah lewat teks hasil)
+type finding struct {
+	Kind     string
+	Original string
+	Masked   string
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		want     string
+		findings []finding
+	}{
+		{
+			name: "no sensitive data",
+			text: "Halo, akun saya tidak bisa login sejak kemarin",
+			want: "Halo, akun saya tidak bisa login sejak kemarin",
+		},
+
+		// NIK: provinsi 11-94, tanggal 01-31 (perempuan +40), bulan 01-12, dan bukan Luhn-valid
+		{
+			name:     "valid NIK",
+			text:     "NIK saya 3171010101900006 ya",
+			want:     "NIK saya ************0006 ya",
+			findings: []finding{{dlp.KindNIK, "3171010101900006", "************0006"}},
+		},
+		{
+			name:     "NIK with female birth date",
+			text:     "3171014501900002",
+			want:     "************0002",
+			findings: []finding{{dlp.KindNIK, "3171014501900002", "************0002"}},
+		},
+		{
+			name: "NIK with invalid province",
+			text: "0971010101900005",
+			want: "0971010101900005",
+		},
+		{
+			name: "NIK with invalid month",
+			text: "3171010113900005",
+			want: "3171010113900005",
+		},
+
+		// CARD: 13-19 digit (boleh dipisah spasi / strip) yang lolos Luhn
+		{
+			name:     "Luhn-valid card with separators",
+			text:     "kartu 4111 1111 1111 1111 exp 12/27",
+			want:     "kartu ************1111 exp 12/27",
+			findings: []finding{{dlp.KindCard, "4111 1111 1111 1111", "************1111"}},
+		},
+		{
+			name:     "Luhn-valid 16 digits that also look like a NIK are a card",
+			text:     "4111111111111111",
+			want:     "************1111",
+			findings: []finding{{dlp.KindCard, "4111111111111111", "************1111"}},
+		},
+		{
+			name: "card failing Luhn is left alone",
+			text: "4111-1111-1111-1112",
+			want: "4111-1111-1111-1112",
+		},
+
+		// PHONE: +62 / 62 / 0 diikuti 8[1-9]
+		{
+			name:     "phone with leading zero",
+			text:     "hubungi 0812-3456-7890",
+			want:     "hubungi ********7890",
+			findings: []finding{{dlp.KindPhone, "0812-3456-7890", "********7890"}},
+		},
+		{
+			name:     "phone with country code",
+			text:     "WA +62 812 3456 789",
+			want:     "WA ********6789",
+			findings: []finding{{dlp.KindPhone, "+62 812 3456 789", "********6789"}},
+		},
+		{
+			name: "landline is not a mobile number",
+			text: "kantor 021-5551234",
+			want: "kantor 021-5551234",
+		},
+
+		// Tumpang tindih: detektor yang lebih awal menang, temuan berikutnya di rentang yang sama diabaikan
+		{
+			name:     "password value wins over card",
+			text:     "password: 4111111111111111",
+			want:     "password: ********",
+			findings: []finding{{dlp.KindPassword, "4111111111111111", "********"}},
+		},
+		{
+			name:     "digits inside email are not a phone",
+			text:     "email 081234567890@example.com",
+			want:     "email 0***@example.com",
+			findings: []finding{{dlp.KindEmail, "081234567890@example.com", "0***@example.com"}},
+		},
+		{
+			name:     "reset link token is not scanned again",
+			text:     "buka http://localhost:3000/reset-password/4111111111111111",
+			want:     "buka [reset link disembunyikan]",
+			findings: []finding{{dlp.KindResetURL, "http://localhost:3000/reset-password/4111111111111111", "[reset link disembunyikan]"}},
+		},
+
+		// Beberapa temuan: dikembalikan urut posisi dalam teks
+		{
+			name: "multiple findings in text order",
+			text: "HP 081234567890, NIK 3171010101900006, email budi@example.com",
+			want: "HP ********7890, NIK ************0006, email b***@example.com",
+			findings: []finding{
+				{dlp.KindPhone, "081234567890", "********7890"},
+				{dlp.KindNIK, "3171010101900006", "************0006"},
+				{dlp.KindEmail, "budi@example.com", "b***@example.com"},
+			},
+		},
+	}
+
+	redactor := dlp.NewRedactor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, matches := redactor.Redact(tt.text)
+			if got != tt.want {
+				t.Errorf("Redact(%q) text = %q, want %q", tt.text, got, tt.want)
+			}
+
+			var findings []finding
+			for _, m := range matches {
+				findings = append(findings, finding{m.Kind, m.Original, m.Masked})
+				if tt.text[m.Start:m.End] != m.Original {
+					t.Errorf("match %s: text[%d:%d] = %q, want Original %q", m.Kind, m.Start, m.End, tt.text[m.Start:m.End], m.Original)
+				}
+			}
+			if !reflect.DeepEqual(findings, tt.findings) {
+				t.Errorf("Redact(%q) findings = %+v, want %+v", tt.text, findings, tt.findings)
+			}
+		})
+	}
+}
+
+func TestRedactMasks(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"last four digits of card kept, separators dropped", "4111-1111-1111-1111", "************1111"},
+		{"first letter and domain of email kept", "a.b+tag@mail.co.id", "a***@mail.co.id"},
+		{"verification link replaced", "http://localhost:3000/verify/5f1c-ab", "[link verifikasi disembunyikan]"},
+		{"survey link replaced", "https://zta.example/csat/tok_123", "[link survei disembunyikan]"},
+		{"only the password value is masked", "kata sandi = Rahasia123!", "kata sandi = ********"},
+	}
+
+	redactor := dlp.NewRedactor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := redactor.Redact(tt.text); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Scope canned response
+const (
+	CannedScopeTeam     = "TEAM"
+	CannedScopePersonal = "PERSONAL"
+)
+
+// Langkah macro yang didukung
+const (
+	MacroSendMessage       = "SEND_MESSAGE"
+	MacroStartVerification = "START_VERIFICATION"
+	MacroSetStatus         = "SET_STATUS"
+)
+
+// MaxMacroActions: Batas langkah per macro
+const MaxMacroActions = 5
+
+// macroStatuses: Status yang boleh diset lewat macro (tetap divalidasi state machine saat dijalankan)
+var macroStatuses = map[string]bool{TicketInProgress: true, TicketPendingUser: true, TicketClosed: true}
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z_.]+)\s*\}\}`)
+
+// DefaultUserName: Pengganti {{user.name}} jika user belum mengisi nama tampilan
+const DefaultUserName = "Pelanggan"
+
+// Placeholders yang didukung: {{ticket.id}}, {{ticket.subject}}, {{ticket.category}}, {{ticket.priority}}, {{user.name}}
+// (Ticket.User harus sudah di-preload)
+var placeholders = map[string]func(t *Ticket) string{
+	"ticket.id":       func(t *Ticket) string { return strconv.FormatUint(uint64(t.ID), 10) },
+	"ticket.subject":  func(t *Ticket) string { return t.Subject },
+	"ticket.category": func(t *Ticket) string { return t.Category },
+	"ticket.priority": func(t *Ticket) string { return t.Priority },
+	"user.name": func(t *Ticket) string {
+		if t.User.DisplayName != "" {
+			return t.User.DisplayName
+		}
+		return DefaultUserName
+	},
+}
+
+// Validate memeriksa placeholder & langkah macro sebelum disimpan
+func (r *CannedResponse) Validate() error {
+	if strings.TrimSpace(r.Title) == "" || strings.TrimSpace(r.Body) == "" {
+		return errors.New("title and body are required")
+	}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(r.Body, -1) {
+		if _, ok := placeholders[match[1]]; !ok {
+			return fmt.Errorf("unknown placeholder {{%s}}", match[1])
+		}
+	}
+	if len(r.Actions) > MaxMacroActions {
+		return fmt.Errorf("a macro can have at most %d actions", MaxMacroActions)
+	}
+	for _, action := range r.Actions {
+		switch action.Type {
+		case MacroSendMessage, MacroStartVerification:
+		case MacroSetStatus:
+			if !macroStatuses[action.Status] {
+				return fmt.Errorf("macro cannot set status %q", action.Status)
+			}
+		default:
+			return fmt.Errorf("unknown macro action %q", action.Type)
+		}
+	}
+	return nil
+}
+
+// Render mengisi placeholder dengan data tiket
+func (r *CannedResponse) Render(ticket *Ticket) string {
+	return placeholderPattern.ReplaceAllStringFunc(r.Body, func(token string) string {
+		key := placeholderPattern.FindStringSubmatch(token)[1]
+		if fill, ok := placeholders[key]; ok {
+			return fill(ticket)
+		}
+		return token
+	})
+}
+
+// MacroSteps: Langkah yang dijalankan; tanpa Actions = kirim pesan saja
+func (r *CannedResponse) MacroSteps() []MacroAction {
+	if len(r.Actions) == 0 {
+		return []MacroAction{{Type: MacroSendMessage}}
+	}
+	return r.Actions
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "errors"
+
+// DefaultActiveTicketLimit: Batas tiket aktif jika agent & timnya tidak mengatur kapasitas
+const DefaultActiveTicketLimit = 1
+
+var ErrActiveLimitReached = errors.New("active ticket limit reached")
+
+// EffectiveCapacity: Kapasitas agent > batas tim > default global.
+// Team harus sudah di-preload agar batas tim ikut terhitung.
+func (p *AgentProfile) EffectiveCapacity() int {
+	if p.Capacity > 0 {
+		return p.Capacity
+	}
+	if p.Team != nil && p.Team.MaxActiveTickets > 0 {
+		return p.Team.MaxActiveTickets
+	}
+	return DefaultActiveTicketLimit
+}
@@ -0,0 +1,14 @@
+package domain
+
+import (
+	"github.com/syukurgit/zta/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// BeforeSave menjaga blind index email selalu sinkron (kolom Email sendiri terenkripsi, tidak bisa di-query)
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.Email != "" {
+		u.EmailIndex = utils.BlindIndex(u.Email)
+	}
+	return nil
+}
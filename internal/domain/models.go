@@ -8,18 +8,57 @@ import (
 
 
 const (
-	RoleUser    = "USER"
-	RoleCS      = "CS"
-	RoleAuditor = "AUDITOR"
+	RoleUser       = "USER"
+	RoleCS         = "CS"
+	RoleAuditor    = "AUDITOR"
+	RoleSupervisor = "SUPERVISOR"
+	RoleAdmin      = "ADMIN"  // Manajemen akun staff (bukan akses data tiket)
+	RoleSystem     = "SYSTEM" // Aktor otomatis (SLA monitor, scheduler), bukan akun login
 )
+
+const (
+	PresenceAvailable = "AVAILABLE"
+	PresenceAway      = "AWAY"
+	PresenceOffline   = "OFFLINE"
+)
+
+const (
+	PriorityLow    = "LOW"
+	PriorityMedium = "MEDIUM"
+	PriorityHigh   = "HIGH"
+	PriorityUrgent = "URGENT"
+)
+
+// Status akun User
+const (
+	UserActive              = "ACTIVE"
+	UserPendingVerification = "PENDING_VERIFICATION"
+	UserDisabled            = "DISABLED" // Dinonaktifkan ADMIN: login ditolak, sesi dicabut
+)
+
+// Sumber kredensial User
+const (
+	AuthLocal = "LOCAL" // Email + password lokal
+	AuthOIDC  = "OIDC"  // SSO korporat (OpenID Connect): password lokal tidak berlaku
+)
+
+// StaffRoles: Role yang akunnya dikelola ADMIN (USER mendaftar sendiri)
+var StaffRoles = []string{RoleCS, RoleSupervisor, RoleAuditor, RoleAdmin}
+
 // 1. User: Aktor dalam sistem (User Biasa, CS, Auditor)
 type User struct {
 	ID           uint   `gorm:"primaryKey"`
     // PERUBAHAN DI SINI: Tambahkan type:varchar(255)
-	Email        string `gorm:"type:varchar(255);uniqueIndex;not null"` 
+	Email        string `gorm:"type:varchar(512);not null;serializer:encrypted"` // Terenkripsi (envelope), lookup via EmailIndex
+	EmailIndex   string `gorm:"type:char(64);uniqueIndex" json:"-"`                // Blind index HMAC dari email
+	DisplayName  string `gorm:"type:varchar(512);not null;default:'';serializer:encrypted"` // Nama panggilan pilihan user (opsional), untuk {{user.name}}
 	PasswordHash string `gorm:"not null"` 
-	Role         string `gorm:"type:enum('USER','CS','AUDITOR');not null"` 
+	Role         string `gorm:"type:enum('USER','CS','AUDITOR','SUPERVISOR','ADMIN');not null"` 
 	RiskScore    int    `gorm:"default:0"` 
+	Status       string `gorm:"type:enum('ACTIVE','PENDING_VERIFICATION','DISABLED');default:'ACTIVE'"` // Registrasi mandiri: aktif setelah email dikonfirmasi
+	EmailVerifiedAt *time.Time
+	AuthProvider string `gorm:"type:varchar(20);not null;default:'LOCAL'" json:"-"` // LOCAL | OIDC (lihat sso.go)
+	ExtraRoles   []UserRole `gorm:"foreignKey:UserID" json:"-"` // Role tambahan (lihat permissions.go)
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -29,7 +68,22 @@ type Ticket struct {
 	ID        uint   `gorm:"primaryKey"`
 	UserID    uint   `gorm:"not null"`
 	Subject   string `gorm:"type:varchar(255);not null"`
-	Status    string `gorm:"type:enum('OPEN','IN_PROGRESS','CLOSED','LOCKED');default:'OPEN'"`
+	Status    string `gorm:"type:enum('OPEN','IN_PROGRESS','PENDING_USER','REOPENED','CLOSED','LOCKED');default:'OPEN'"` // Lihat ticket_state.go
+	LegalHold bool   `gorm:"default:false"` // Jika true: log & chat tiket ini tidak boleh diarsip/purge
+	Priority  string `gorm:"type:enum('LOW','MEDIUM','HIGH','URGENT');default:'MEDIUM'"`
+	Category  string `gorm:"type:varchar(50);default:'GENERAL'"`
+	Language  string `gorm:"type:varchar(10);default:'id'"` // Dipakai routing berbasis bahasa
+
+	// SLA: Deadline dihitung dari SLAPolicy saat tiket dibuat
+	FirstResponseDueAt    *time.Time `gorm:"index"`
+	ResolutionDueAt       *time.Time `gorm:"index"`
+	FirstRespondedAt      *time.Time
+	SLAPausedAt           *time.Time `gorm:"column:sla_paused_at"` // Terisi selama menunggu balasan user
+	FirstResponseBreached bool       `gorm:"default:false"`
+	ResolutionBreached    bool       `gorm:"default:false"`
+
+	ClosedAt *time.Time // Dipakai guard REOPEN (lihat ReopenWindow)
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	
@@ -48,6 +102,43 @@ type TicketAssignment struct {
 	CS     User   `gorm:"foreignKey:CSID"`
 }
 
+// TicketAssignmentHistory: Riwayat siapa saja yang pernah memegang tiket (claim, transfer, reassign)
+type TicketAssignmentHistory struct {
+	ID             uint      `gorm:"primaryKey"`
+	TicketID       uint      `gorm:"index;not null"`
+	FromCSID       uint      // 0 untuk claim pertama
+	ToCSID         uint      `gorm:"not null"`
+	Kind           string    `gorm:"type:enum('CLAIM','TRANSFER','REASSIGN','AUTO_ROUTE');not null"`
+	AssignedBy     uint      `gorm:"not null"`
+	AssignedByRole string    `gorm:"type:varchar(20);not null"`
+	Note           string    `gorm:"type:text"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+}
+
+// AgentProfile: Skill, bahasa, kapasitas & presence CS untuk auto-routing
+type AgentProfile struct {
+	UserID         uint       `gorm:"primaryKey"`
+	Skills         string     `gorm:"type:varchar(255)"`  // Kategori tiket, dipisah koma ("*" = semua)
+	Languages      string     `gorm:"type:varchar(100)"`  // Kode bahasa, dipisah koma (mis. "id,en")
+	Capacity       int        `gorm:"default:0"`          // Maks tiket aktif (0 = ikut batas tim)
+	TeamID         *uint      `gorm:"index"`
+	Presence       string     `gorm:"type:enum('AVAILABLE','AWAY','OFFLINE');default:'OFFLINE'"`
+	LastAssignedAt *time.Time // Dipakai round-robin
+	UpdatedAt      time.Time
+
+	User User  `gorm:"foreignKey:UserID"`
+	Team *Team `gorm:"foreignKey:TeamID"`
+}
+
+// Team: Kelompok agent dengan batas tiket aktif bersama (mis. tim chat-heavy vs tim senior)
+type Team struct {
+	ID               uint      `gorm:"primaryKey"`
+	Name             string    `gorm:"type:varchar(100);uniqueIndex"`
+	MaxActiveTickets int       `gorm:"default:1"` // Batas default per agent anggota tim
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
 // 4. VerificationSession: Sesi verifikasi yang dikendalikan sistem
 // internal/domain/models.go
 
@@ -55,6 +146,7 @@ type VerificationSession struct {
     ID           string    `gorm:"primaryKey;type:varchar(64)"` // UUID
     TicketID     uint      `gorm:"not null"`
     UserID       uint      `gorm:"not null"`
+    CSID         uint      `gorm:"not null;default:0"` // CS yang memulai sesi; privilege hanya diberikan jika tiket masih dipegangnya
     Status       string    `gorm:"type:enum('PENDING','PASSED','FAILED','EXPIRED');default:'PENDING'"`
     AttemptCount int       `gorm:"default:0"` // Kolom yang baru ditambahkan
     ExpiresAt    time.Time `gorm:"not null"`
@@ -69,7 +161,7 @@ type VerificationQuestion struct {
 	ID           uint   `gorm:"primaryKey"`
 	Category     string `gorm:"type:enum('STATIC','HISTORY','USAGE');not null"`
 	QuestionText string `gorm:"type:text;not null"`
-	AnswerHash   string `gorm:"not null"` // Jawaban disimpan sebagai hash, bukan plaintext
+	AnswerHash   string `gorm:"type:varchar(512);not null;serializer:encrypted"` // Hash jawaban, terenkripsi (envelope)
 }
 
 // 6. TemporaryPrivilege: INTI dari Just-In-Time (JIT) Access
@@ -82,6 +174,7 @@ type TemporaryPrivilege struct {
 	GrantedAt time.Time 
 	ExpiresAt time.Time `gorm:"not null"` // Privilege mati otomatis setelah waktu ini
 	IsUsed    bool      `gorm:"default:false"` // One-time use only
+	UsedAt    *time.Time // Kapan privilege dipakai (untuk hitung latency grant -> use)
 }
 
 // 7. AuditLog: Log Immutable untuk Auditor
@@ -90,16 +183,17 @@ type TemporaryPrivilege struct {
 
 type AuditLog struct {
 	ID        uint      `gorm:"primaryKey"`
-	TicketID  uint      `gorm:"index;not null"` // Link ke Tiket
+	TicketID  uint      `gorm:"index;not null"` // Link ke Tiket; 0 = event akun / sistem tanpa tiket (AuditService.LogEvent)
 	ActorHash string    `gorm:"not null"`       // ID CS yang disamarkan
 	ActorRole string    `gorm:"not null"`
 	Action    string    `gorm:"not null"`
 	Result    string    `gorm:"not null"`       // SUCCESS / DENIED
 	Context   string    `gorm:"type:text"`      // Detail aktivitas
+	RequestID string    `gorm:"type:varchar(128);index"` // Korelasi ke HTTP request (X-Request-ID)
 	Timestamp time.Time `gorm:"autoCreateTime"`
 	
-	// Relation untuk mempermudah pengambilan data
-	Ticket Ticket `gorm:"foreignKey:TicketID"`
+	// Relation untuk mempermudah pengambilan data. Tanpa FK constraint: event tanpa tiket memakai ticket_id = 0.
+	Ticket Ticket `gorm:"foreignKey:TicketID;constraint:-"`
 }
 
 type VerificationAttempt struct {
@@ -119,9 +213,427 @@ type Chat struct {
 	ID        uint      `gorm:"primaryKey"`
 	TicketID  uint      `gorm:"not null;index"` // Relasi ke Tiket
 	SenderID  uint      `gorm:"not null"`       // ID User atau ID CS
-	SenderRole string   `gorm:"type:enum('USER','CS');not null"` // Siapa yang kirim?
-	Message   string    `gorm:"type:text;not null"`
+	SenderRole string   `gorm:"type:enum('USER','CS','SYSTEM');not null"` // Siapa yang kirim? (SYSTEM = notifikasi otomatis)
+	Message   string    `gorm:"type:text;not null;serializer:encrypted_ticket"` // Terenkripsi dengan data key per tiket
 	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"type:datetime(6);index"` // Berubah saat dibuat / edit / retract: cursor sinkronisasi inkremental
+	Version     int        `gorm:"not null;default:1"` // Naik setiap edit / retract
+	EditedAt    *time.Time
+	RetractedAt *time.Time // Pesan ditarik: Message dikosongkan, isi lama ada di ChatRevision
+
+	Attachments []Attachment    `gorm:"foreignKey:ChatID" json:",omitempty"`
+	Redactions  []ChatRedaction `gorm:"foreignKey:ChatID" json:",omitempty"`
+	Receipts    []ChatReceipt   `gorm:"foreignKey:ChatID" json:",omitempty"`
+}
+
+// ChatRevision: Versi lama pesan chat sebelum diedit / ditarik (append-only, hanya untuk Auditor)
+type ChatRevision struct {
+	ID        uint      `gorm:"primaryKey"`
+	ChatID    uint      `gorm:"not null;index"`
+	TicketID  uint      `gorm:"not null;index"`
+	Version   int       `gorm:"not null"` // Versi dari Message ini
+	Message   string    `gorm:"type:text;not null;serializer:encrypted_ticket"`
+	Action    string    `gorm:"type:enum('EDIT','RETRACT');not null"` // Aksi yang menggantikan versi ini
+	ActorID   uint      `gorm:"not null"`
+	CreatedAt time.Time
+
+	// Lampiran & data DLP milik versi ini (dipindah dari pesan saat diedit / ditarik)
+	Attachments []Attachment    `gorm:"foreignKey:RevisionID" json:",omitempty"`
+	Redactions  []ChatRedaction `gorm:"foreignKey:RevisionID" json:",omitempty"`
+}
+
+// ChatReceipt: Status terkirim / dibaca per peserta (User pemilik / CS)
+type ChatReceipt struct {
+	ChatID      uint       `gorm:"primaryKey;autoIncrement:false"`
+	UserID      uint       `gorm:"primaryKey;autoIncrement:false"`
+	DeliveredAt *time.Time
+	ReadAt      *time.Time
+}
+
+// UnreadCount: Jumlah pesan belum dibaca per tiket (DTO)
+type UnreadCount struct {
+	TicketID uint  `json:"ticket_id"`
+	Unread   int64 `json:"unread"`
+}
+
+// ChatRedaction: Data sensitif yang disamarkan DLP dari pesan chat.
+// Nilai asli hanya disimpan terenkripsi dan dibuka lewat privilege JIT VIEW_REDACTED.
+type ChatRedaction struct {
+	ID         uint      `gorm:"primaryKey"`
+	ChatID     uint      `gorm:"not null;index"`
+	TicketID   uint      `gorm:"not null;index"`
+	Kind       string    `gorm:"type:varchar(20);not null"` // NIK | CARD | PHONE | EMAIL | RESET_URL | VERIFY_URL | SURVEY_URL | PASSWORD
+	Masked     string    `gorm:"type:varchar(255);not null"`
+	Ciphertext string    `gorm:"type:text;not null" json:"-"`
+	RevisionID *uint     `gorm:"index"` // Terisi jika milik versi lama (ChatRevision): tidak tampil / tidak bisa di-reveal User & CS
+	CreatedAt  time.Time
+}
+
+// Attachment: Lampiran chat. Isi file disimpan di BlobStore dengan key = SHA256 (dedup per konten).
+type Attachment struct {
+	ID           uint      `gorm:"primaryKey"`
+	ChatID       uint      `gorm:"not null;index"`
+	TicketID     uint      `gorm:"not null;index"`
+	UploaderID   uint      `gorm:"not null"`
+	UploaderRole string    `gorm:"type:varchar(20);not null"`
+	FileName     string    `gorm:"type:varchar(255);not null"`
+	MimeType     string    `gorm:"type:varchar(100);not null"` // Hasil deteksi konten, bukan header client
+	Size         int64     `gorm:"not null"`
+	SHA256       string    `gorm:"type:char(64);index;not null"`
+	BlobKey      string    `gorm:"type:varchar(255);not null" json:"-"`
+	RevisionID   *uint     `gorm:"index"` // Terisi jika pesan ditarik: lampiran milik ChatRevision, hanya Auditor yang bisa membuka
+	CreatedAt    time.Time
+}
+
+// TicketNote: Catatan internal tiket. TIDAK PERNAH dikirim ke User (hanya CS, Supervisor, Auditor).
+type TicketNote struct {
+	ID         uint      `gorm:"primaryKey"`
+	TicketID   uint      `gorm:"not null;index"`
+	AuthorID   uint      `gorm:"not null"`
+	AuthorRole string    `gorm:"type:enum('CS','SUPERVISOR');not null"`
+	Body       string    `gorm:"type:text;not null;serializer:encrypted_ticket"` // Terenkripsi dengan data key per tiket
+	Revision   int       `gorm:"not null;default:1"` // Naik setiap kali diedit
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Mentions []TicketNoteMention `gorm:"foreignKey:NoteID"`
+}
+
+// TicketNoteRevision: Isi lama catatan sebelum diedit (riwayat edit)
+type TicketNoteRevision struct {
+	ID        uint      `gorm:"primaryKey"`
+	NoteID    uint      `gorm:"not null;index"`
+	TicketID  uint      `gorm:"not null;default:0;index"` // Scope data key (ikut ter-shred bersama tiket)
+	Revision  int       `gorm:"not null"` // Nomor revisi dari Body ini
+	Body      string    `gorm:"type:text;not null;serializer:encrypted_ticket"`
+	EditedBy  uint      `gorm:"not null"` // Aktor yang menggantikan revisi ini
+	CreatedAt time.Time // Waktu edit
+}
+
+// TicketNoteMention: Agent (CS / Supervisor) yang disebut di catatan
+type TicketNoteMention struct {
+	ID          uint      `gorm:"primaryKey"`
+	NoteID      uint      `gorm:"not null;index"`
+	MentionedID uint      `gorm:"not null;index"`
+	CreatedAt   time.Time
+}
+
+// TimelineEntry: Satu baris timeline tiket untuk Auditor (AuditLog + catatan internal, DTO)
+type TimelineEntry struct {
+	Type      string              `json:"type"` // AUDIT | NOTE | NOTE_EDIT
+	Timestamp time.Time           `json:"timestamp"`
+	AuditLog  *AuditLog           `json:"audit_log,omitempty"`
+	Note      *TicketNote         `json:"note,omitempty"`
+	Revision  *TicketNoteRevision `json:"revision,omitempty"`
+}
+
+// AuditAccessLog: Jejak setiap bacaan yang dilakukan Auditor (audit-of-audit).
+// Disimpan di tabel terpisah dan sengaja tidak punya endpoint baca/ubah untuk Auditor.
+type AuditAccessLog struct {
+	ID           uint      `gorm:"primaryKey"`
+	ActorID      uint      `gorm:"index;not null"`
+	ActorRole    string    `gorm:"type:varchar(20);not null"`
+	Method       string    `gorm:"type:varchar(10);not null"`
+	Path         string    `gorm:"type:varchar(255);not null"`
+	Filters      string    `gorm:"type:text"` // Query string yang dipakai auditor
+	TicketIDs    string    `gorm:"type:text"` // Tiket yang disentuh (dipisah koma)
+	RowsReturned int       `gorm:"default:0"`
+	StatusCode   int       `gorm:"not null"`
+	ClientIP     string    `gorm:"type:varchar(64)"`
+	RequestID    string    `gorm:"type:varchar(128);index"`
+	AccessedAt   time.Time `gorm:"autoCreateTime"`
 }
 
+// AuditAnalyticsReport: Laporan agregat Auditor per rentang waktu (DTO, bukan tabel)
+type AuditAnalyticsReport struct {
+	From                             time.Time              `json:"from"`
+	To                               time.Time              `json:"to"`
+	DeniedByActor                    []ActorDeniedCount     `json:"denied_by_actor"`
+	Verification                     VerificationStats      `json:"verification"`
+	PrivilegeLatency                 PrivilegeLatencyStats  `json:"privilege_latency"`
+	UnusedPrivileges                 int64                  `json:"unused_privileges"`
+	ExpiredPrivileges                int64                  `json:"expired_privileges"`
+	TicketsClosedWithoutVerification int64                  `json:"tickets_closed_without_verification"`
+	PolicyViolations                 []PolicyViolationCount `json:"policy_violations"`
+	CSAT                             CSATReport             `json:"csat"`
+}
 
+// ActorDeniedCount: Jumlah aksi DENIED per pseudonym CS
+type ActorDeniedCount struct {
+	ActorHash string `json:"actor_hash"`
+	Action    string `json:"action"`
+	Count     int64  `json:"count"`
+}
+
+// VerificationStats: Rasio lulus/gagal sesi verifikasi
+type VerificationStats struct {
+	Total    int64   `json:"total"`
+	Passed   int64   `json:"passed"`
+	Failed   int64   `json:"failed"`
+	Expired  int64   `json:"expired"`
+	Pending  int64   `json:"pending"`
+	PassRate float64 `json:"pass_rate"`
+	FailRate float64 `json:"fail_rate"`
+}
+
+// PrivilegeLatencyStats: Jeda waktu antara privilege diberikan dan dipakai (detik)
+type PrivilegeLatencyStats struct {
+	Samples    int     `json:"samples"`
+	AvgSeconds float64 `json:"avg_seconds"`
+	MinSeconds float64 `json:"min_seconds"`
+	MaxSeconds float64 `json:"max_seconds"`
+}
+
+// PolicyViolationCount: Jumlah pelanggaran kebijakan (Action + Result DENIED)
+type PolicyViolationCount struct {
+	Action string `json:"action"`
+	Result string `json:"result"`
+	Count  int64  `json:"count"`
+}
+
+// Alert: Temuan rule engine anomali atas stream AuditLog
+type Alert struct {
+	ID         uint      `gorm:"primaryKey"`
+	Rule       string    `gorm:"type:varchar(64);index;not null"`
+	Severity   string    `gorm:"type:enum('LOW','MEDIUM','HIGH');not null"`
+	TicketID   uint      `gorm:"index"`
+	ActorHash  string    `gorm:"type:varchar(255);index"`
+	AuditLogID uint      `gorm:"not null"` // Event AuditLog yang memicu alert
+	Message    string    `gorm:"type:text;not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// RetentionPolicy: Berapa lama data disimpan di DB sebelum diarsip ke segment file.
+// Kind AUDIT_LOG dicocokkan per Action ("*" = default), Kind CHAT berlaku untuk tiket CLOSED.
+type RetentionPolicy struct {
+	ID            uint   `gorm:"primaryKey"`
+	Kind          string `gorm:"type:enum('AUDIT_LOG','CHAT');uniqueIndex:idx_retention_kind_action;not null"`
+	Action        string `gorm:"type:varchar(64);uniqueIndex:idx_retention_kind_action;not null"`
+	RetentionDays int    `gorm:"not null"`
+	UpdatedAt     time.Time
+}
+
+// ArchiveSegment: Metadata file arsip (gzip JSON lines) yang saling terhubung lewat hash chain
+type ArchiveSegment struct {
+	ID         uint      `gorm:"primaryKey"`
+	Kind       string    `gorm:"type:enum('AUDIT_LOG','CHAT');not null"`
+	FilePath   string    `gorm:"type:varchar(512);not null"`
+	RowCount   int       `gorm:"not null"`
+	FirstRowID uint      `gorm:"not null"`
+	LastRowID  uint      `gorm:"not null"`
+	PrevHash   string    `gorm:"type:varchar(64);not null"`
+	Hash       string    `gorm:"type:varchar(64);uniqueIndex;not null"` // sha256(PrevHash + sha256(payload))
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// SLAPolicy: Target waktu respon & penyelesaian per kategori (Priority "*" = semua prioritas)
+type SLAPolicy struct {
+	ID                   uint   `gorm:"primaryKey"`
+	Category             string `gorm:"type:varchar(50);uniqueIndex:idx_sla_category_priority;not null"`
+	Priority             string `gorm:"type:varchar(10);uniqueIndex:idx_sla_category_priority;not null"`
+	FirstResponseMinutes int    `gorm:"not null"`
+	ResolutionMinutes    int    `gorm:"not null"`
+	UpdatedAt            time.Time
+}
+
+// DataKey: Data key envelope encryption, disimpan terbungkus (wrapped) oleh KEK dari KMS.
+// Scope TICKET = satu key per tiket (crypto-shredding), GLOBAL = data lintas tiket (mis. email user).
+type DataKey struct {
+	ID         uint       `gorm:"primaryKey"`
+	Scope      string     `gorm:"type:enum('GLOBAL','TICKET');uniqueIndex:idx_data_key_scope;not null"`
+	ScopeID    uint       `gorm:"uniqueIndex:idx_data_key_scope;not null"`
+	KEKID      string     `gorm:"column:kek_id;type:varchar(64)"`
+	WrappedKey []byte     `gorm:"type:varbinary(256)"` // Dikosongkan saat shredding
+	CreatedAt  time.Time
+	ShreddedAt *time.Time
+}
+
+// TranscriptExport: Catatan setiap ekspor transcript tiket, untuk membuktikan keaslian bundle di kemudian hari
+type TranscriptExport struct {
+	ID             uint      `gorm:"primaryKey"`
+	ExportID       string    `gorm:"type:varchar(36);uniqueIndex;not null"` // UUID, sama dengan manifest.export_id
+	TicketID       uint      `gorm:"index;not null"`
+	ManifestSHA256 string    `gorm:"type:char(64);not null"`
+	Signature      string    `gorm:"type:text;not null"` // Ed25519 base64 atas manifest.json
+	KeyID          string    `gorm:"type:varchar(32);not null"`
+	ExportedBy     string    `gorm:"not null"` // Pseudonym auditor
+	CreatedAt      time.Time
+}
+
+// CannedResponse: Jawaban siap pakai CS dengan placeholder (lihat canned_response.go).
+// Scope TEAM dikelola Supervisor untuk satu tim, PERSONAL milik satu CS.
+// Actions kosong = hanya mengirim Body; selain itu dijalankan berurutan sebagai macro.
+type CannedResponse struct {
+	ID         uint          `gorm:"primaryKey"`
+	Scope      string        `gorm:"type:enum('TEAM','PERSONAL');not null;index"`
+	TeamID     *uint         `gorm:"index"` // Scope TEAM
+	OwnerID    *uint         `gorm:"index"` // Scope PERSONAL
+	Shortcut   string        `gorm:"type:varchar(50);index"` // Mis. "/reset"
+	Title      string        `gorm:"type:varchar(150);not null"`
+	Body       string        `gorm:"type:text;not null"`
+	Actions    []MacroAction `gorm:"type:text;serializer:json"`
+	UsageCount int64         `gorm:"default:0"`
+	LastUsedAt *time.Time
+	CreatedBy  uint `gorm:"not null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// MacroAction: Satu langkah macro
+type MacroAction struct {
+	Type   string `json:"type"`             // SEND_MESSAGE | START_VERIFICATION | SET_STATUS
+	Status string `json:"status,omitempty"` // Target status untuk SET_STATUS
+}
+
+// CannedResponseUsage: Jejak pemakaian canned response per tiket (untuk laporan)
+type CannedResponseUsage struct {
+	ID         uint `gorm:"primaryKey"`
+	ResponseID uint `gorm:"not null;index"`
+	TicketID   uint `gorm:"not null;index"`
+	CSID       uint `gorm:"not null;index"`
+	CreatedAt  time.Time
+}
+
+// CannedResponseStats: Ringkasan pemakaian per canned response (DTO)
+type CannedResponseStats struct {
+	ResponseID uint       `json:"response_id"`
+	Title      string     `json:"title"`
+	Scope      string     `json:"scope"`
+	TeamID     *uint      `json:"team_id,omitempty"`
+	Uses       int64      `json:"uses"`
+	Agents     int64      `json:"agents"` // Jumlah CS berbeda yang memakai
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// Status CSATSurvey
+const (
+	CSATPending   = "PENDING"
+	CSATSubmitted = "SUBMITTED"
+	CSATExpired   = "EXPIRED"
+)
+
+// CSATSurvey: Survei kepuasan satu kali per penutupan tiket. Token = link rahasia milik User.
+// Penilaian diatribusikan ke pseudonym CS (bukan ID) yang menangani tiket saat ditutup.
+type CSATSurvey struct {
+	ID          uint      `gorm:"primaryKey"`
+	TicketID    uint      `gorm:"not null;index"`
+	UserID      uint      `gorm:"not null;index"`
+	Token       string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	CSHash      string    `gorm:"type:varchar(64);index"`
+	Category    string    `gorm:"type:varchar(50);index"` // Kategori tiket saat ditutup
+	Status      string    `gorm:"type:enum('PENDING','SUBMITTED','EXPIRED');default:'PENDING';index"`
+	Rating      *int      // 1-5
+	Comment     string    `gorm:"type:text;serializer:encrypted_ticket"` // Ikut terhapus saat tiket di-shred
+	ExpiresAt   time.Time `gorm:"not null"`
+	SubmittedAt *time.Time
+	CreatedAt   time.Time `gorm:"index"`
+}
+
+// CSATReport: Ringkasan kepuasan User untuk laporan Auditor & Supervisor (DTO)
+type CSATReport struct {
+	Requested     int64            `json:"requested"` // Survei dikirim dalam rentang waktu
+	Responses     int64            `json:"responses"`
+	ResponseRate  float64          `json:"response_rate"`
+	AvgRating     float64          `json:"avg_rating"`
+	SatisfiedRate float64          `json:"satisfied_rate"` // Rating 4-5
+	Distribution  map[int]int64    `json:"distribution"`   // Rating -> jumlah
+	ByAgent       []CSATGroupStats `json:"by_agent"`       // Key = pseudonym CS
+	ByCategory    []CSATGroupStats `json:"by_category"`
+}
+
+// CSATGroupStats: Kepuasan per pseudonym CS / kategori tiket
+type CSATGroupStats struct {
+	Key           string  `json:"key" gorm:"column:group_key"`
+	Responses     int64   `json:"responses"`
+	AvgRating     float64 `json:"avg_rating"`
+	Satisfied     int64   `json:"satisfied"`
+	SatisfiedRate float64 `json:"satisfied_rate" gorm:"-"`
+}
+
+// Jenis EmailVerificationToken
+const (
+	TokenConfirmEmail = "CONFIRM_EMAIL" // Registrasi mandiri: aktivasi tanpa mengubah password
+	TokenStaffInvite  = "STAFF_INVITE"  // Undangan akun staff: penerima menetapkan password sendiri
+)
+
+// EmailVerificationToken: Token sekali pakai yang dikirim lewat email (konfirmasi registrasi / undangan staff)
+type EmailVerificationToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Token     string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	Purpose   string    `gorm:"type:varchar(20);not null;default:'CONFIRM_EMAIL'"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Status RoleGrantRequest
+const (
+	RoleGrantPending  = "PENDING"
+	RoleGrantApproved = "APPROVED"
+	RoleGrantRejected = "REJECTED"
+)
+
+// ApprovalRoles: Pemberian role ini (akun baru maupun perubahan role) butuh persetujuan ADMIN kedua
+var ApprovalRoles = []string{RoleAuditor}
+
+// RoleGrantRequest: Pemberian role yang menunggu persetujuan ADMIN lain (bukan pemohon, bukan target)
+type RoleGrantRequest struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Roles       string     `gorm:"type:varchar(255);not null" json:"roles"` // Dipisah koma, elemen pertama = role utama
+	NewAccount  bool       `json:"new_account"`                             // Akun baru: undangan dikirim setelah disetujui
+	Status      string     `gorm:"type:varchar(20);not null;default:'PENDING';index" json:"status"`
+	RequestedBy uint       `gorm:"not null" json:"requested_by"`
+	DecidedBy   *uint      `json:"decided_by,omitempty"`
+	Reason      string     `gorm:"type:varchar(255)" json:"reason,omitempty"` // Alasan penolakan
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// UserVerificationAnswer: Jawaban verifikasi pribadi User (di-enroll saat onboarding), disimpan sebagai hash
+type UserVerificationAnswer struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;uniqueIndex:idx_user_question"`
+	QuestionID uint   `gorm:"not null;uniqueIndex:idx_user_question"`
+	AnswerHash string `gorm:"type:varchar(512);not null;serializer:encrypted" json:"-"` // Hash bcrypt, terenkripsi (envelope)
+	CreatedAt  time.Time
+}
+
+
+// UserSession: Sesi login. JWT membawa ID sesi sehingga sesi bisa dicabut sebelum token kedaluwarsa.
+type UserSession struct {
+	ID         string     `gorm:"primaryKey;type:varchar(64)"` // UUID (claim "sid")
+	UserID     uint       `gorm:"not null;index"`
+	Role       string     `gorm:"type:varchar(20);not null"` // Role utama
+	Roles      string     `gorm:"type:varchar(255)"`         // Semua role saat login, dipisah koma (lihat RoleList)
+	IPAddress  string     `gorm:"type:varchar(64)"`
+	UserAgent  string     `gorm:"type:varchar(255)"`
+	MFAUsed    bool       `gorm:"default:false"` // Login memakai kode TOTP
+	StepUpAt   *time.Time // Re-autentikasi TOTP terakhir (untuk aksi sensitif)
+	LastSeenAt time.Time
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// UserMFA: Secret TOTP (RFC 6238) milik user. ActivatedAt nil = enrollment belum dikonfirmasi.
+type UserMFA struct {
+	UserID      uint   `gorm:"primaryKey;autoIncrement:false"`
+	Secret      string `gorm:"type:varchar(512);not null;serializer:encrypted" json:"-"`
+	LastStep    int64  `json:"-"` // Time-step kode terakhir yang dipakai (anti replay)
+	ActivatedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// StaffAccount: Tampilan akun untuk ADMIN (tanpa hash password / risk data)
+type StaffAccount struct {
+	ID           uint      `json:"id"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	Roles        []string  `json:"roles"`
+	Status       string    `json:"status"`
+	AuthProvider string    `json:"auth_provider"`
+	MFAEnabled   bool      `json:"mfa_enabled"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Batas ukuran halaman untuk semua endpoint listing
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort field")
+)
+
+// ListQuery: Parameter pagination & filter bersama untuk listing tiket dan chat.
+// Pakai Cursor (dari NextCursor response sebelumnya) ATAU Page (1-based); Cursor diutamakan.
+// Cursor = keyset (nilai sort + ID item terakhir), sehingga item yang keluar dari listing di antara
+// dua request (mis. tiket diklaim dari antrian) tidak menggeser halaman berikutnya.
+type ListQuery struct {
+	Limit  int
+	Page   int
+	Cursor string
+	Sort   string // Nama field yang diizinkan per listing (kosong = urutan default)
+	Desc   bool
+	Status []string
+	From   *time.Time // created_at >= From
+	To     *time.Time // created_at < To
+	Search string     // Pencarian teks (subject tiket / isi pesan chat)
+}
+
+// Offset: Offset baris untuk pagination per nomor halaman (tidak dipakai jika Cursor diisi)
+func (q ListQuery) Offset() int {
+	if q.Cursor == "" && q.Page > 1 {
+		return (q.Page - 1) * q.Limit
+	}
+	return 0
+}
+
+// Page: Satu halaman hasil listing
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"` // Kosong jika sudah halaman terakhir
+}
+
+// NewPage menyusun halaman; nextCursor kosong = halaman terakhir
+func NewPage[T any](items []T, total int64, limit int, nextCursor string) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &Page[T]{Items: items, Total: total, Limit: limit, NextCursor: nextCursor}
+}
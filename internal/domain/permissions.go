@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Permission bernama yang dideklarasikan route (middleware.RequirePermission).
+// Mapping role -> permission dikelola di tabel role_permissions (lihat DefaultRolePermissions).
+const (
+	PermTicketOwn           = "ticket:own"            // USER: tiket & chat milik sendiri
+	PermTicketWork          = "ticket:work"           // CS workspace: klaim, chat, catatan, macro
+	PermTicketVerify        = "ticket:verify"         // CS: memicu verifikasi identitas
+	PermTicketResetPassword = "ticket:reset_password" // CS: aksi reset password (tetap butuh privilege JIT)
+	PermChatRevealRedaction = "chat:reveal_redaction" // CS: membuka nilai asli DLP (tetap butuh grant)
+	PermTicketLogView       = "ticket:log_view"       // Log box audit per tiket
+	PermTeamSupervise       = "team:supervise"        // Supervisor: monitoring SLA, agent, tim, CSAT
+	PermSLAManage           = "sla:manage"            // Mengubah policy SLA
+	PermDLPGrant            = "dlp:grant"             // Memberi akses reveal DLP ke CS
+	PermTicketShred         = "ticket:shred"          // Crypto-shredding data tiket
+	PermAuditRead           = "audit:read"            // Auditor: log, laporan, timeline (read-only)
+	PermTranscriptExport    = "transcript:export"     // Ekspor transcript bertanda tangan
+	PermArchiveLegalHold    = "archive:legal_hold"    // Pasang / lepas legal hold
+	PermStaffManage         = "staff:manage"          // ADMIN: akun staff, sesi, reset MFA
+	PermRBACManage          = "rbac:manage"           // ADMIN: mapping role -> permission
+)
+
+// Permissions: Semua permission yang dikenal (mapping hanya boleh berisi nilai dari sini)
+var Permissions = []string{
+	PermTicketOwn, PermTicketWork, PermTicketVerify, PermTicketResetPassword, PermChatRevealRedaction,
+	PermTicketLogView, PermTeamSupervise, PermSLAManage, PermDLPGrant, PermTicketShred,
+	PermAuditRead, PermTranscriptExport, PermArchiveLegalHold, PermStaffManage, PermRBACManage,
+}
+
+// DefaultRolePermissions: Isi awal role_permissions (seed) & fallback jika tabel masih kosong
+var DefaultRolePermissions = map[string][]string{
+	RoleUser:       {PermTicketOwn},
+	RoleCS:         {PermTicketWork, PermTicketVerify, PermTicketResetPassword, PermChatRevealRedaction, PermTicketLogView},
+	RoleSupervisor: {PermTeamSupervise, PermSLAManage, PermDLPGrant, PermTicketShred, PermTicketLogView},
+	RoleAuditor:    {PermAuditRead, PermTranscriptExport, PermArchiveLegalHold, PermTicketLogView},
+	RoleAdmin:      {PermStaffManage, PermRBACManage},
+}
+
+// UserPermissions: Satu-satunya permission yang boleh dipetakan ke role USER (akun publik tidak pernah
+// mendapat permission staff lewat mapping)
+var UserPermissions = []string{PermTicketOwn}
+
+// IncompatiblePermissions: Pasangan permission yang tidak boleh berakhir di satu role / satu akun
+// (separation of duties): pengelola akun & RBAC tidak boleh sekaligus membaca audit atas aksinya sendiri.
+var IncompatiblePermissions = [][2]string{{PermStaffManage, PermAuditRead}, {PermRBACManage, PermAuditRead}}
+
+// LoginRoles: Role yang bisa dipegang akun (SYSTEM bukan akun login)
+var LoginRoles = []string{RoleUser, RoleCS, RoleSupervisor, RoleAuditor, RoleAdmin}
+
+// IncompatibleRoles: Pasangan role yang tidak boleh dipegang satu akun (separation of duties):
+// ADMIN yang juga AUDITOR bisa mengaudit aksinya sendiri.
+var IncompatibleRoles = [][2]string{{RoleAdmin, RoleAuditor}}
+
+// RolePermission: Satu baris mapping role -> permission
+type RolePermission struct {
+	ID         uint      `gorm:"primaryKey" json:"-"`
+	Role       string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_role_permission" json:"role"`
+	Permission string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_role_permission" json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UserRole: Role tambahan di luar User.Role (role utama), mis. team lead = SUPERVISOR + CS
+type UserRole struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_role" json:"-"`
+	Role      string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_user_role" json:"role"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// AllRoles: Role utama + role tambahan (ExtraRoles harus sudah di-preload)
+func (u *User) AllRoles() []string {
+	roles := []string{u.Role}
+	for _, r := range u.ExtraRoles {
+		if !slices.Contains(roles, r.Role) {
+			roles = append(roles, r.Role)
+		}
+	}
+	return roles
+}
+
+// HasRole: true jika role utama atau salah satu role tambahan cocok (ExtraRoles harus sudah di-preload)
+func (u *User) HasRole(role string) bool {
+	return slices.Contains(u.AllRoles(), role)
+}
+
+// RoleList: Role yang berlaku pada sesi (disimpan dipisah koma saat login)
+func (s *UserSession) RoleList() []string {
+	if s.Roles == "" {
+		return []string{s.Role}
+	}
+	return strings.Split(s.Roles, ",")
+}
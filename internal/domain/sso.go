@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// UserIdentity: Tautan akun lokal ke identitas IdP (issuer + subject). Subject stabil walaupun email di IdP berubah.
+type UserIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"-"`
+	UserID      uint       `gorm:"not null;index" json:"-"`
+	Issuer      string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_identity" json:"issuer"`
+	Subject     string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_identity" json:"subject"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// OIDCLoginState: Login SSO yang sedang berjalan (dibuat saat redirect ke IdP, dihapus saat callback).
+// Nonce & code_verifier tidak pernah dikirim ke browser (hanya state).
+type OIDCLoginState struct {
+	State        string    `gorm:"primaryKey;type:varchar(64)"`
+	Nonce        string    `gorm:"type:varchar(64);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status tiket. Semua perubahan status WAJIB lewat Ticket.CanTransition.
+const (
+	TicketOpen        = "OPEN"         // Baru dibuat, belum di-claim
+	TicketInProgress  = "IN_PROGRESS"  // Ditangani CS
+	TicketPendingUser = "PENDING_USER" // Menunggu balasan user (SLA dijeda)
+	TicketReopened    = "REOPENED"     // Dibuka lagi oleh user setelah CLOSED
+	TicketClosed      = "CLOSED"       // Selesai, akses dicabut
+	TicketLocked      = "LOCKED"       // Dikunci sistem (mis. verifikasi FAILED)
+)
+
+// ActiveTicketStatuses: Status yang dihitung sebagai beban kerja CS
+var ActiveTicketStatuses = []string{TicketInProgress, TicketPendingUser, TicketReopened}
+
+// ReopenWindow: Batas waktu user boleh membuka lagi tiket yang sudah CLOSED
+const ReopenWindow = 7 * 24 * time.Hour
+
+var ErrIllegalTransition = errors.New("illegal ticket transition")
+
+// ticketTransitions: from -> to -> role yang diizinkan
+var ticketTransitions = map[string]map[string][]string{
+	TicketOpen: {
+		TicketInProgress: {RoleCS},
+	},
+	TicketInProgress: {
+		TicketPendingUser: {RoleCS},
+		TicketClosed:      {RoleUser, RoleCS, RoleSupervisor},
+		TicketLocked:      {RoleSystem},
+	},
+	TicketPendingUser: {
+		TicketInProgress: {RoleUser, RoleCS},
+		TicketClosed:     {RoleUser, RoleCS, RoleSupervisor},
+		TicketLocked:     {RoleSystem},
+	},
+	TicketReopened: {
+		TicketInProgress: {RoleCS},
+		TicketClosed:     {RoleUser, RoleCS, RoleSupervisor},
+		TicketLocked:     {RoleSystem},
+	},
+	TicketClosed: {
+		TicketReopened: {RoleUser},
+	},
+	TicketLocked: {
+		TicketClosed: {RoleSupervisor},
+	},
+}
+
+// TransitionContext: Informasi aktor untuk guard condition
+type TransitionContext struct {
+	ActorID      uint
+	Role         string
+	AssignedCSID uint // 0 jika tiket belum di-assign
+	Now          time.Time
+}
+
+// CanTransition memeriksa apakah perpindahan status diizinkan (tabel transisi + role + guard)
+func (t *Ticket) CanTransition(to string, tc TransitionContext) error {
+	if t.Status == to {
+		return fmt.Errorf("%w: ticket is already %s", ErrIllegalTransition, to)
+	}
+
+	roles, ok := ticketTransitions[t.Status][to]
+	if !ok {
+		return fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, t.Status, to)
+	}
+	if !contains(roles, tc.Role) {
+		return fmt.Errorf("%w: role %s cannot move ticket %s -> %s", ErrIllegalTransition, tc.Role, t.Status, to)
+	}
+
+	// Guard per role
+	switch tc.Role {
+	case RoleUser:
+		if t.UserID != tc.ActorID {
+			return errors.New("unauthorized: you don't own this ticket")
+		}
+	case RoleCS:
+		// Claim (OPEN) dicek oleh repository; selain itu hanya CS yang memegang tiket
+		if t.Status != TicketOpen && tc.AssignedCSID != tc.ActorID {
+			return errors.New("unauthorized: ticket is handled by another CS")
+		}
+	}
+
+	// Guard per transisi
+	if t.Status == TicketClosed && to == TicketReopened {
+		if t.ClosedAt == nil || tc.Now.Sub(*t.ClosedAt) > ReopenWindow {
+			return errors.New("reopen window has passed, please create a new ticket")
+		}
+	}
+
+	return nil
+}
+
+// IsActive: Tiket sedang dikerjakan CS
+func (t *Ticket) IsActive() bool {
+	return contains(ActiveTicketStatuses, t.Status)
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
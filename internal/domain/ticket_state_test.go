@@ -0,0 +1,123 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+)
+
+const (
+	ownerID    = 10
+	strangerID = 11
+	csID       = 20
+	otherCSID  = 21
+	superID    = 30
+)
+
+var now = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+func ago(d time.Duration) *time.Time {
+	t := now.Add(-d)
+	return &t
+}
+
+func TestCanTransition(t *testing.T) {
+	closedRecently := ago(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		status   string
+		closedAt *time.Time
+		to       string
+		actorID  uint
+		role     string
+		assigned uint
+		wantErr  bool
+		illegal  bool // Ditolak tabel transisi / role (ErrIllegalTransition), bukan guard
+	}{
+		// Alur normal
+		{name: "CS claims open ticket", status: domain.TicketOpen, to: domain.TicketInProgress, actorID: csID, role: domain.RoleCS},
+		{name: "assigned CS waits for user", status: domain.TicketInProgress, to: domain.TicketPendingUser, actorID: csID, role: domain.RoleCS, assigned: csID},
+		{name: "owner replies while pending", status: domain.TicketPendingUser, to: domain.TicketInProgress, actorID: ownerID, role: domain.RoleUser, assigned: csID},
+		{name: "assigned CS resumes pending ticket", status: domain.TicketPendingUser, to: domain.TicketInProgress, actorID: csID, role: domain.RoleCS, assigned: csID},
+		{name: "assigned CS closes", status: domain.TicketInProgress, to: domain.TicketClosed, actorID: csID, role: domain.RoleCS, assigned: csID},
+		{name: "owner closes", status: domain.TicketInProgress, to: domain.TicketClosed, actorID: ownerID, role: domain.RoleUser, assigned: csID},
+		{name: "supervisor closes pending ticket", status: domain.TicketPendingUser, to: domain.TicketClosed, actorID: superID, role: domain.RoleSupervisor, assigned: csID},
+		{name: "assigned CS picks up reopened ticket", status: domain.TicketReopened, to: domain.TicketInProgress, actorID: csID, role: domain.RoleCS, assigned: csID},
+		{name: "owner closes reopened ticket", status: domain.TicketReopened, to: domain.TicketClosed, actorID: ownerID, role: domain.RoleUser, assigned: csID},
+
+		// Ditolak tabel transisi / role
+		{name: "same status", status: domain.TicketInProgress, to: domain.TicketInProgress, actorID: csID, role: domain.RoleCS, assigned: csID, wantErr: true, illegal: true},
+		{name: "open ticket cannot be closed", status: domain.TicketOpen, to: domain.TicketClosed, actorID: ownerID, role: domain.RoleUser, wantErr: true, illegal: true},
+		{name: "user cannot claim", status: domain.TicketOpen, to: domain.TicketInProgress, actorID: ownerID, role: domain.RoleUser, wantErr: true, illegal: true},
+		{name: "user cannot set pending", status: domain.TicketInProgress, to: domain.TicketPendingUser, actorID: ownerID, role: domain.RoleUser, assigned: csID, wantErr: true, illegal: true},
+		{name: "supervisor cannot reopen", status: domain.TicketClosed, closedAt: closedRecently, to: domain.TicketReopened, actorID: superID, role: domain.RoleSupervisor, wantErr: true, illegal: true},
+		{name: "closed ticket cannot go back to in progress", status: domain.TicketClosed, closedAt: closedRecently, to: domain.TicketInProgress, actorID: csID, role: domain.RoleCS, assigned: csID, wantErr: true, illegal: true},
+		{name: "unknown target status", status: domain.TicketInProgress, to: "ARCHIVED", actorID: csID, role: domain.RoleCS, assigned: csID, wantErr: true, illegal: true},
+		{name: "auditor cannot change status", status: domain.TicketInProgress, to: domain.TicketClosed, actorID: superID, role: domain.RoleAuditor, assigned: csID, wantErr: true, illegal: true},
+
+		// Guard aktor
+		{name: "another user cannot close", status: domain.TicketInProgress, to: domain.TicketClosed, actorID: strangerID, role: domain.RoleUser, assigned: csID, wantErr: true},
+		{name: "unassigned CS cannot close", status: domain.TicketInProgress, to: domain.TicketClosed, actorID: otherCSID, role: domain.RoleCS, assigned: csID, wantErr: true},
+		{name: "unassigned CS cannot set pending", status: domain.TicketInProgress, to: domain.TicketPendingUser, actorID: otherCSID, role: domain.RoleCS, assigned: csID, wantErr: true},
+
+		// Reopen window (7 hari sejak CLOSED)
+		{name: "owner reopens within window", status: domain.TicketClosed, closedAt: closedRecently, to: domain.TicketReopened, actorID: ownerID, role: domain.RoleUser},
+		{name: "owner reopens exactly at window end", status: domain.TicketClosed, closedAt: ago(domain.ReopenWindow), to: domain.TicketReopened, actorID: ownerID, role: domain.RoleUser},
+		{name: "owner reopens after window", status: domain.TicketClosed, closedAt: ago(domain.ReopenWindow + time.Second), to: domain.TicketReopened, actorID: ownerID, role: domain.RoleUser, wantErr: true},
+		{name: "closed ticket without ClosedAt cannot reopen", status: domain.TicketClosed, to: domain.TicketReopened, actorID: ownerID, role: domain.RoleUser, wantErr: true},
+		{name: "another user cannot reopen", status: domain.TicketClosed, closedAt: closedRecently, to: domain.TicketReopened, actorID: strangerID, role: domain.RoleUser, wantErr: true},
+
+		// Auto-lock oleh sistem (verifikasi FAILED) dan penanganannya
+		{name: "system locks in progress ticket", status: domain.TicketInProgress, to: domain.TicketLocked, role: domain.RoleSystem, assigned: csID},
+		{name: "system locks pending ticket", status: domain.TicketPendingUser, to: domain.TicketLocked, role: domain.RoleSystem, assigned: csID},
+		{name: "system locks reopened ticket", status: domain.TicketReopened, to: domain.TicketLocked, role: domain.RoleSystem, assigned: csID},
+		{name: "CS cannot lock", status: domain.TicketInProgress, to: domain.TicketLocked, actorID: csID, role: domain.RoleCS, assigned: csID, wantErr: true, illegal: true},
+		{name: "system cannot lock open ticket", status: domain.TicketOpen, to: domain.TicketLocked, role: domain.RoleSystem, wantErr: true, illegal: true},
+		{name: "supervisor closes locked ticket", status: domain.TicketLocked, to: domain.TicketClosed, actorID: superID, role: domain.RoleSupervisor, assigned: csID},
+		{name: "CS cannot close locked ticket", status: domain.TicketLocked, to: domain.TicketClosed, actorID: csID, role: domain.RoleCS, assigned: csID, wantErr: true, illegal: true},
+		{name: "user cannot close locked ticket", status: domain.TicketLocked, to: domain.TicketClosed, actorID: ownerID, role: domain.RoleUser, assigned: csID, wantErr: true, illegal: true},
+		{name: "locked ticket cannot be resumed", status: domain.TicketLocked, to: domain.TicketInProgress, actorID: csID, role: domain.RoleCS, assigned: csID, wantErr: true, illegal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := &domain.Ticket{UserID: ownerID, Status: tt.status, ClosedAt: tt.closedAt}
+			err := ticket.CanTransition(tt.to, domain.TransitionContext{
+				ActorID:      tt.actorID,
+				Role:         tt.role,
+				AssignedCSID: tt.assigned,
+				Now:          now,
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CanTransition(%s -> %s, %s) error = %v, wantErr %t", tt.status, tt.to, tt.role, err, tt.wantErr)
+			}
+			if err != nil && errors.Is(err, domain.ErrIllegalTransition) != tt.illegal {
+				t.Errorf("CanTransition(%s -> %s, %s) error = %v, want ErrIllegalTransition %t", tt.status, tt.to, tt.role, err, tt.illegal)
+			}
+		})
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{domain.TicketOpen, false},
+		{domain.TicketInProgress, true},
+		{domain.TicketPendingUser, true},
+		{domain.TicketReopened, true},
+		{domain.TicketClosed, false},
+		{domain.TicketLocked, false},
+	}
+
+	for _, tt := range tests {
+		if got := (&domain.Ticket{Status: tt.status}).IsActive(); got != tt.want {
+			t.Errorf("IsActive(%s) = %t, want %t", tt.status, got, tt.want)
+		}
+	}
+}
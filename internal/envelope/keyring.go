@@ -0,0 +1,195 @@
+// Package envelope: Enkripsi kolom DB dengan data key (DEK) per scope yang dibungkus KEK dari KMS.
+// Menghapus DEK sebuah tiket (crypto-shredding) membuat semua datanya tidak bisa dibaca lagi.
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/kms"
+	"github.com/syukurgit/zta/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ScopeGlobal = "GLOBAL"
+	ScopeTicket = "TICKET"
+
+	// Format nilai di DB: enc:v1:<g|t<ticketID>>:<base64url(nonce|ciphertext)>
+	ValuePrefix = "enc:v1:"
+
+	// ShreddedPlaceholder: Pengganti isi yang data key-nya sudah dihapus
+	ShreddedPlaceholder = "[data erased]"
+)
+
+var ErrKeyShredded = errors.New("data key has been shredded")
+
+// Keyring mengelola DEK: dibuat saat pertama dipakai, disimpan terbungkus, di-cache setelah dibuka
+type Keyring struct {
+	DB       *gorm.DB
+	Provider kms.KeyProvider
+
+	mu    sync.RWMutex
+	cache map[string][]byte
+}
+
+func NewKeyring(db *gorm.DB, provider kms.KeyProvider) *Keyring {
+	return &Keyring{DB: db, Provider: provider, cache: make(map[string][]byte)}
+}
+
+// Encrypt mengenkripsi plaintext dengan DEK scope tsb (DEK dibuat jika belum ada)
+func (k *Keyring) Encrypt(ctx context.Context, scope string, scopeID uint, plaintext string) (string, error) {
+	dek, err := k.dataKey(ctx, scope, scopeID, true)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := utils.SealGCM(dek, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return ValuePrefix + scopeRef(scope, scopeID) + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt membuka nilai terenkripsi. Nilai tanpa prefix (data lama sebelum enkripsi) dikembalikan apa adanya.
+func (k *Keyring) Decrypt(ctx context.Context, value string) (string, error) {
+	if !strings.HasPrefix(value, ValuePrefix) {
+		return value, nil
+	}
+
+	ref, encoded, ok := strings.Cut(strings.TrimPrefix(value, ValuePrefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	scope, scopeID, err := parseScopeRef(ref)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	dek, err := k.dataKey(ctx, scope, scopeID, false)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := utils.OpenGCM(dek, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Shred menghapus DEK secara permanen. Penanda tetap disimpan agar DEK baru tidak dibuat ulang untuk scope ini.
+func (k *Keyring) Shred(ctx context.Context, scope string, scopeID uint) error {
+	now := time.Now()
+	err := k.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}, {Name: "scope_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"wrapped_key": nil,
+			"shredded_at": gorm.Expr("COALESCE(shredded_at, ?)", now),
+		}),
+	}).Create(&domain.DataKey{Scope: scope, ScopeID: scopeID, ShreddedAt: &now}).Error
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	delete(k.cache, scopeRef(scope, scopeID))
+	k.mu.Unlock()
+	return nil
+}
+
+// dataKey mengambil DEK dari cache / DB; create = buat DEK baru jika belum ada
+func (k *Keyring) dataKey(ctx context.Context, scope string, scopeID uint, create bool) ([]byte, error) {
+	ref := scopeRef(scope, scopeID)
+	k.mu.RLock()
+	dek, ok := k.cache[ref]
+	k.mu.RUnlock()
+	if ok {
+		return dek, nil
+	}
+
+	// 1. Ambil DEK terbungkus
+	record, err := k.loadKey(ctx, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Belum ada -> buat (aman untuk request paralel: unique index + DO NOTHING, lalu baca ulang)
+	if record == nil {
+		if !create {
+			return nil, fmt.Errorf("data key %s not found", ref)
+		}
+		if err := k.createKey(ctx, scope, scopeID); err != nil {
+			return nil, err
+		}
+		if record, err = k.loadKey(ctx, scope, scopeID); err != nil || record == nil {
+			return nil, fmt.Errorf("data key %s could not be created", ref)
+		}
+	}
+	if record.ShreddedAt != nil {
+		return nil, ErrKeyShredded
+	}
+
+	// 3. Buka dengan KEK
+	dek, err = k.Provider.Unwrap(ctx, record.KEKID, record.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.cache[ref] = dek
+	k.mu.Unlock()
+	return dek, nil
+}
+
+func (k *Keyring) loadKey(ctx context.Context, scope string, scopeID uint) (*domain.DataKey, error) {
+	var records []domain.DataKey
+	if err := k.DB.WithContext(ctx).Where("scope = ? AND scope_id = ?", scope, scopeID).Limit(1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+func (k *Keyring) createKey(ctx context.Context, scope string, scopeID uint) error {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return err
+	}
+	kekID, wrapped, err := k.Provider.Wrap(ctx, dek)
+	if err != nil {
+		return err
+	}
+	return k.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&domain.DataKey{Scope: scope, ScopeID: scopeID, KEKID: kekID, WrappedKey: wrapped}).Error
+}
+
+func scopeRef(scope string, scopeID uint) string {
+	if scope == ScopeTicket {
+		return "t" + strconv.FormatUint(uint64(scopeID), 10)
+	}
+	return "g"
+}
+
+func parseScopeRef(ref string) (string, uint, error) {
+	if ref == "g" {
+		return ScopeGlobal, 0, nil
+	}
+	if id, err := strconv.ParseUint(strings.TrimPrefix(ref, "t"), 10, 64); err == nil && strings.HasPrefix(ref, "t") {
+		return ScopeTicket, uint(id), nil
+	}
+	return "", 0, fmt.Errorf("unknown key scope %q", ref)
+}
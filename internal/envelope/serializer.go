@@ -0,0 +1,72 @@
+package envelope
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// Serializer GORM: tag `serializer:encrypted` (DEK global) / `serializer:encrypted_ticket` (DEK per tiket,
+// diambil dari field TicketID pada model yang sama)
+type Serializer struct {
+	Keyring *Keyring
+	Scope   string
+}
+
+// Register mendaftarkan kedua serializer. Wajib dipanggil sebelum model pertama di-parse (sebelum migrate / query).
+func Register(keyring *Keyring) {
+	schema.RegisterSerializer("encrypted", Serializer{Keyring: keyring, Scope: ScopeGlobal})
+	schema.RegisterSerializer("encrypted_ticket", Serializer{Keyring: keyring, Scope: ScopeTicket})
+}
+
+// Scan: DB -> plaintext. Data yang sudah di-shred diganti ShreddedPlaceholder agar listing tetap jalan.
+func (s Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var raw string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("encrypted field %s: unsupported db type %T", field.Name, dbValue)
+	}
+
+	plaintext, err := s.Keyring.Decrypt(ctx, raw)
+	if errors.Is(err, ErrKeyShredded) {
+		plaintext, err = ShreddedPlaceholder, nil
+	}
+	if err != nil {
+		return fmt.Errorf("encrypted field %s: %w", field.Name, err)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value: plaintext -> DB. String kosong disimpan kosong (mis. pesan yang ditarik).
+func (s Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s must be a string", field.Name)
+	}
+	if plaintext == "" {
+		return "", nil
+	}
+
+	var scopeID uint
+	if s.Scope == ScopeTicket {
+		ticketField := field.Schema.LookUpField("TicketID")
+		if ticketField == nil {
+			return nil, fmt.Errorf("encrypted field %s: model has no TicketID", field.Name)
+		}
+		value, zero := ticketField.ValueOf(ctx, dst)
+		if zero {
+			return nil, fmt.Errorf("encrypted field %s: TicketID is required", field.Name)
+		}
+		scopeID = value.(uint)
+	}
+	return s.Keyring.Encrypt(ctx, s.Scope, scopeID, plaintext)
+}
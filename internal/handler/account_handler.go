@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+// AccountHandler: Sesi, MFA & step-up milik akun yang sedang login (semua role)
+type AccountHandler struct {
+	Service *service.AccountService
+	Users   *repository.UserRepository
+}
+
+func NewAccountHandler(s *service.AccountService, users *repository.UserRepository) *AccountHandler {
+	return &AccountHandler{Service: s, Users: users}
+}
+
+// GetMFAStatus - GET /api/account/mfa
+func (h *AccountHandler) GetMFAStatus(c *gin.Context) {
+	enabled, err := h.Service.GetMFAStatus(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil status MFA")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mfa_enabled": enabled})
+}
+
+// EnrollMFA - POST /api/account/mfa/enroll
+func (h *AccountHandler) EnrollMFA(c *gin.Context) {
+	user, err := h.Users.GetByID(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	enrollment, err := h.Service.EnrollMFA(c.Request.Context(), user)
+	if errors.Is(err, service.ErrMFAEnrolled) {
+		respondError(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal membuat secret MFA")
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+type otpInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ActivateMFA - POST /api/account/mfa/activate
+func (h *AccountHandler) ActivateMFA(c *gin.Context) {
+	var input otpInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := h.Service.ActivateMFA(c.Request.Context(), c.GetUint("user_id"), c.GetString("role"), input.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "MFA enabled"})
+}
+
+// StepUp - POST /api/account/step-up
+func (h *AccountHandler) StepUp(c *gin.Context) {
+	var input otpInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validUntil, err := h.Service.StepUp(c.Request.Context(), c.GetUint("user_id"), c.GetString("role"), c.GetString("session_id"), input.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Step-up authentication successful", "valid_until": validUntil})
+}
+
+// Logout - POST /api/account/logout
+func (h *AccountHandler) Logout(c *gin.Context) {
+	if err := h.Service.Logout(c.Request.Context(), c.GetString("session_id")); err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal logout")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFAInvalid):
+		respondError(c, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrMFANotEnrolled), errors.Is(err, service.ErrMFAEnrolled):
+		respondError(c, http.StatusConflict, err.Error())
+	default:
+		respondError(c, http.StatusInternalServerError, "Gagal memverifikasi kode MFA")
+	}
+}
+
+// UpdateProfile - PUT /api/account/profile
+func (h *AccountHandler) UpdateProfile(c *gin.Context) {
+	var input struct {
+		DisplayName string `json:"display_name"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name, err := h.Service.SetDisplayName(c.Request.Context(), c.GetUint("user_id"), c.GetString("role"), input.DisplayName)
+	if errors.Is(err, service.ErrDisplayName) {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal menyimpan profil")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"display_name": name})
+}
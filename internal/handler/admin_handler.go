@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type AdminHandler struct {
+	Service     *service.AdminService
+	Permissions *service.PermissionService
+}
+
+func NewAdminHandler(s *service.AdminService, permissions *service.PermissionService) *AdminHandler {
+	return &AdminHandler{Service: s, Permissions: permissions}
+}
+
+// ListStaff (ADMIN Only) - GET /api/admin/users?role=CS&email=...
+func (h *AdminHandler) ListStaff(c *gin.Context) {
+	q, err := parseListQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.Service.ListStaff(c.Request.Context(), strings.ToUpper(c.Query("role")), c.Query("email"), q)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Gagal mengambil daftar akun staff")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateStaff (ADMIN Only) - POST /api/admin/users
+// Tanpa password: staff baru menerima link undangan dan menetapkan password sendiri.
+func (h *AdminHandler) CreateStaff(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+		Role  string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, req, err := h.Service.CreateStaff(c.Request.Context(), c.GetUint("user_id"), input.Email, strings.ToUpper(input.Role))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req != nil {
+		c.JSON(http.StatusAccepted, gin.H{"account": account, "role_request": req,
+			"message": "Account created. The invitation is sent after another admin approves the role."})
+		return
+	}
+	c.JSON(http.StatusCreated, account)
+}
+
+// ChangeRole (ADMIN Only) - PUT /api/admin/users/:id/role (satu role; role tambahan dihapus)
+func (h *AdminHandler) ChangeRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.setRoles(c, []string{input.Role})
+}
+
+// SetRoles (ADMIN Only) - PUT /api/admin/users/:id/roles (roles[0] = role utama)
+func (h *AdminHandler) SetRoles(c *gin.Context) {
+	var input struct {
+		Roles []string `json:"roles" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.setRoles(c, input.Roles)
+}
+
+func (h *AdminHandler) setRoles(c *gin.Context, roles []string) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	account, req, err := h.Service.SetRoles(c.Request.Context(), c.GetUint("user_id"), uint(userID), roles)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	if req != nil {
+		c.JSON(http.StatusAccepted, gin.H{"account": account, "role_request": req,
+			"message": "Role change requires approval from another admin."})
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// ListRoleRequests (ADMIN Only) - GET /api/admin/role-requests?status=PENDING
+func (h *AdminHandler) ListRoleRequests(c *gin.Context) {
+	reqs, err := h.Service.ListRoleRequests(c.Request.Context(), strings.ToUpper(c.Query("status")))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil permintaan role")
+		return
+	}
+	c.JSON(http.StatusOK, reqs)
+}
+
+// ApproveRoleRequest (ADMIN Only) - POST /api/admin/role-requests/:id/approve
+func (h *AdminHandler) ApproveRoleRequest(c *gin.Context) {
+	requestID, _ := strconv.Atoi(c.Param("id"))
+
+	account, err := h.Service.ApproveRoleRequest(c.Request.Context(), c.GetUint("user_id"), uint(requestID))
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// RejectRoleRequest (ADMIN Only) - POST /api/admin/role-requests/:id/reject
+func (h *AdminHandler) RejectRoleRequest(c *gin.Context) {
+	requestID, _ := strconv.Atoi(c.Param("id"))
+
+	var input adminReasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.Service.RejectRoleRequest(c.Request.Context(), c.GetUint("user_id"), uint(requestID), input.Reason); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role request rejected"})
+}
+
+type adminReasonInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// DisableUser (ADMIN Only) - POST /api/admin/users/:id/disable
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+// EnableUser (ADMIN Only) - POST /api/admin/users/:id/enable
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h *AdminHandler) setDisabled(c *gin.Context, disabled bool) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	var input adminReasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.Service.SetDisabled(c.Request.Context(), c.GetUint("user_id"), uint(userID), disabled, input.Reason)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// ResetMFA (ADMIN Only) - POST /api/admin/users/:id/mfa/reset
+func (h *AdminHandler) ResetMFA(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	var input adminReasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.Service.ResetMFA(c.Request.Context(), c.GetUint("user_id"), uint(userID), input.Reason); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "MFA reset. The user must enroll again at next login."})
+}
+
+// ListSessions (ADMIN Only) - GET /api/admin/users/:id/sessions
+func (h *AdminHandler) ListSessions(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	sessions, err := h.Service.ListSessions(c.Request.Context(), c.GetUint("user_id"), uint(userID))
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSessions (ADMIN Only) - DELETE /api/admin/users/:id/sessions
+func (h *AdminHandler) RevokeSessions(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	revoked, err := h.Service.RevokeSessions(c.Request.Context(), c.GetUint("user_id"), uint(userID))
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// GetRolePermissions (ADMIN Only) - GET /api/admin/permissions
+func (h *AdminHandler) GetRolePermissions(c *gin.Context) {
+	mapping, err := h.Permissions.Mapping(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil mapping permission")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"permissions": domain.Permissions, "roles": mapping})
+}
+
+// SetRolePermissions (ADMIN Only) - PUT /api/admin/roles/:role/permissions
+func (h *AdminHandler) SetRolePermissions(c *gin.Context) {
+	var input struct {
+		Permissions []string `json:"permissions"` // Kosong = role tanpa permission
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	perms, err := h.Permissions.SetRolePermissions(c.Request.Context(), c.GetUint("user_id"), c.GetStringSlice("roles"),
+		strings.ToUpper(c.Param("role")), input.Permissions)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"role": strings.ToUpper(c.Param("role")), "permissions": perms})
+}
+
+func respondAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSelfAdminister), errors.Is(err, service.ErrOwnRoleMapping), errors.Is(err, service.ErrSelfApproval):
+		respondError(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrNotStaff), errors.Is(err, service.ErrNoRoles),
+		errors.Is(err, service.ErrIncompatible), errors.Is(err, service.ErrUnknownRole), errors.Is(err, service.ErrUnknownPermission),
+		errors.Is(err, service.ErrPermissionConflict):
+		respondError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrRequestDecided):
+		respondError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrRequestNotFound):
+		respondError(c, http.StatusNotFound, err.Error())
+	default:
+		respondError(c, http.StatusInternalServerError, "Gagal memproses aksi admin")
+	}
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/middleware"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type AlertHandler struct {
+	Service *service.AnomalyService
+}
+
+func NewAlertHandler(s *service.AnomalyService) *AlertHandler {
+	return &AlertHandler{Service: s}
+}
+
+// GetAlerts (AUDITOR Only) - GET /api/auditor/alerts?severity=HIGH
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	alerts, err := h.Service.GetAlerts(c.Request.Context(), c.Query("severity"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil alert")
+		return
+	}
+
+	ticketIDs := make([]uint, 0, len(alerts))
+	for _, a := range alerts {
+		ticketIDs = append(ticketIDs, a.TicketID)
+	}
+	middleware.RecordAccess(c, len(alerts), ticketIDs...)
+
+	c.JSON(http.StatusOK, alerts)
+}
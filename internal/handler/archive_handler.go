@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/middleware"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type ArchiveHandler struct {
+	Service *service.ArchiveService
+}
+
+func NewArchiveHandler(s *service.ArchiveService) *ArchiveHandler {
+	return &ArchiveHandler{Service: s}
+}
+
+// GetSegments (AUDITOR Only) - GET /api/auditor/archive/segments
+func (h *ArchiveHandler) GetSegments(c *gin.Context) {
+	segments, err := h.Service.GetSegments(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil daftar arsip")
+		return
+	}
+	middleware.RecordAccess(c, len(segments))
+	c.JSON(http.StatusOK, segments)
+}
+
+// VerifyChain (AUDITOR Only) - GET /api/auditor/archive/verify
+func (h *ArchiveHandler) VerifyChain(c *gin.Context) {
+	if err := h.Service.VerifyChain(c.Request.Context()); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"valid": false, "error": err.Error(), "request_id": c.GetString("request_id")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
+
+// QuerySegment (AUDITOR Only) - GET /api/auditor/archive/segments/:id?ticket_id=&action=
+func (h *ArchiveHandler) QuerySegment(c *gin.Context) {
+	segmentID, _ := strconv.Atoi(c.Param("id"))
+	ticketID, _ := strconv.Atoi(c.Query("ticket_id"))
+
+	result, err := h.Service.QuerySegment(c.Request.Context(), uint(segmentID), uint(ticketID), c.Query("action"))
+	if err != nil {
+		respondError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	// Param :id di route ini adalah segment, jadi tiket dicatat manual
+	ticketIDs := make([]uint, 0, len(result.AuditLogs)+len(result.Chats))
+	for _, l := range result.AuditLogs {
+		ticketIDs = append(ticketIDs, l.TicketID)
+	}
+	for _, ch := range result.Chats {
+		ticketIDs = append(ticketIDs, ch.TicketID)
+	}
+	middleware.RecordAccess(c, len(ticketIDs), ticketIDs...)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SetLegalHold (AUDITOR Only) - POST /api/auditor/tickets/:id/legal-hold
+func (h *ArchiveHandler) SetLegalHold(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		Hold   *bool  `json:"hold" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, "hold and reason are required")
+		return
+	}
+
+	err := h.Service.SetLegalHold(c.Request.Context(), uint(ticketID), c.GetUint("user_id"), c.GetString("role"), *input.Hold, input.Reason)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengubah legal hold")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket_id": ticketID, "legal_hold": *input.Hold})
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/middleware"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type AttachmentHandler struct {
+	Service *service.AttachmentService
+}
+
+func NewAttachmentHandler(s *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{Service: s}
+}
+
+// UploadAttachment (USER & CS) - POST /tickets/:id/chat/attachments (multipart: file, message)
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "File is required")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Failed to read file")
+		return
+	}
+	defer file.Close()
+
+	chat, err := h.Service.Upload(c.Request.Context(), uint(ticketID), c.GetUint("user_id"), c.GetString("role"),
+		fileHeader.Filename, file, c.PostForm("message"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, chat)
+}
+
+// GetAttachmentURL (USER, CS & AUDITOR) - GET /tickets/:id/chat/attachments/:attachmentId/url
+func (h *AttachmentHandler) GetAttachmentURL(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+	attachmentID, _ := strconv.Atoi(c.Param("attachmentId"))
+
+	url, expiresAt, err := h.Service.SignedURL(c.Request.Context(), uint(ticketID), uint(attachmentID), c.GetUint("user_id"), c.GetString("role"))
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	middleware.RecordAccess(c, 1, uint(ticketID))
+	c.JSON(http.StatusOK, gin.H{"url": url, "expires_at": expiresAt})
+}
+
+// DownloadAttachment (Public, via signed URL) - GET /attachments/:id?uid=&role=&exp=&sig=
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachmentID, _ := strconv.Atoi(c.Param("id"))
+	uid, _ := strconv.Atoi(c.Query("uid"))
+	exp, _ := strconv.ParseInt(c.Query("exp"), 10, 64)
+
+	attachment, content, err := h.Service.Download(c.Request.Context(), uint(attachmentID), uint(uid), c.Query("role"), c.Query("sig"), exp)
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Type", attachment.MimeType)
+	c.Header("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, content)
+}
@@ -3,9 +3,12 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+
 	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/middleware"
 	"github.com/syukurgit/zta/internal/service"
 )
 
@@ -19,21 +22,35 @@ func NewAuditHandler(s *service.AuditService) *AuditHandler {
 
 // GetLogs: Mengambil semua log mentah (untuk dashboard lama)
 func (h *AuditHandler) GetLogs(c *gin.Context) {
-	logs, err := h.Service.GetAuditTrail()
+	logs, err := h.Service.GetAuditTrail(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch logs"})
+		respondError(c, http.StatusInternalServerError, "Failed to fetch logs")
 		return
 	}
+
+	ticketIDs := make([]uint, 0, len(logs))
+	for _, l := range logs {
+		ticketIDs = append(ticketIDs, l.TicketID)
+	}
+	middleware.RecordAccess(c, len(logs), ticketIDs...)
+
 	c.JSON(http.StatusOK, logs)
 }
 
 // GetAuditReports: Daftar laporan per tiket (Fungsi yang tadi undefined)
 func (h *AuditHandler) GetAuditReports(c *gin.Context) {
-	reports, err := h.Service.Repo.GetAuditReports() // Panggil langsung via repo atau service
+	reports, err := h.Service.Repo.GetAuditReports(c.Request.Context()) // Panggil langsung via repo atau service
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil laporan audit"})
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil laporan audit")
 		return
 	}
+
+	ticketIDs := make([]uint, 0, len(reports))
+	for _, t := range reports {
+		ticketIDs = append(ticketIDs, t.ID)
+	}
+	middleware.RecordAccess(c, len(reports), ticketIDs...)
+
 	c.JSON(http.StatusOK, reports)
 }
 
@@ -42,10 +59,43 @@ func (h *AuditHandler) GetLogsByTicket(c *gin.Context) {
 	ticketIDStr := c.Param("id")
 	ticketID, _ := strconv.Atoi(ticketIDStr)
 
-	logs, err := h.Service.Repo.GetLogsByTicket(uint(ticketID))
+	logs, err := h.Service.Repo.GetLogsByTicket(c.Request.Context(), uint(ticketID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil timeline log"})
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil timeline log")
 		return
 	}
+
+	middleware.RecordAccess(c, len(logs), uint(ticketID))
 	c.JSON(http.StatusOK, logs)
-}
\ No newline at end of file
+}
+
+// GetAnalyticsReport: Laporan agregat per rentang waktu (?from=&to= RFC3339, ?format=csv untuk export)
+func (h *AuditHandler) GetAnalyticsReport(c *gin.Context) {
+	// Default: 7 hari terakhir
+	from, to, err := parseReportWindow(c, 7)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.Service.BuildAnalyticsReport(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal menyusun laporan analitik")
+		return
+	}
+	middleware.RecordAccess(c, 1)
+
+	if c.Query("format") == "csv" {
+		data, err := h.Service.ExportAnalyticsCSV(report)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Gagal export laporan")
+			return
+		}
+		filename := fmt.Sprintf("audit-report-%s-%s.csv", from.UTC().Format("20060102"), to.UTC().Format("20060102"))
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "text/csv", data)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
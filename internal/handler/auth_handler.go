@@ -1,24 +1,26 @@
 package handler
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/service"
 	"github.com/syukurgit/zta/pkg/utils"
 	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Accounts *service.AccountService
 }
 
 // Input struct untuk validasi JSON
 type LoginInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	OTP      string `json:"otp"` // Wajib jika MFA (TOTP) akun sudah aktif
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -26,42 +28,70 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// 1. Bind JSON dulu
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// 2. Cari user
+	// 2. Cari user (email terenkripsi -> lookup lewat blind index)
 	var user domain.User
-	if err := h.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+	if err := h.DB.WithContext(c.Request.Context()).Preload("ExtraRoles").Where("email_index = ?", utils.BlindIndex(input.Email)).First(&user).Error; err != nil {
+		respondError(c, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
-	// ===== DEBUG LOG (SEMENTARA) =====
-	fmt.Println("====== LOGIN DEBUG ======")
-	fmt.Println("EMAIL:", input.Email)
-	fmt.Println("INPUT PASSWORD:", input.Password)
-	fmt.Println("DB HASH:", user.PasswordHash)
-	fmt.Println("PASSWORD MATCH:", utils.CheckPasswordHash(input.Password, user.PasswordHash))
-	fmt.Println("=========================")
-	// =================================
-
-	// 3. Cek password
+	// 3. Cek password (akun SSO tidak punya password lokal -> login hanya lewat /auth/oidc/login)
+	if user.AuthProvider == domain.AuthOIDC {
+		respondError(c, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
 	if !utils.CheckPasswordHash(input.Password, user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		respondError(c, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+
+	// 3b. Akun registrasi mandiri wajib konfirmasi email dulu
+	if user.Status == domain.UserPendingVerification {
+		respondError(c, http.StatusForbidden, "Email address has not been confirmed")
 		return
 	}
 
-	// 4. Generate token
-	token, err := utils.GenerateToken(user.ID, user.Role, 1*time.Hour)
+	// 3c. Akun dinonaktifkan ADMIN
+	if user.Status == domain.UserDisabled {
+		respondError(c, http.StatusForbidden, "Account is disabled")
+		return
+	}
+
+	// 4. MFA (jika aktif) + buat sesi & token
+	session, err := h.Accounts.StartSession(c.Request.Context(), &user, input.OTP, c.ClientIP(), c.Request.UserAgent())
+	if errors.Is(err, service.ErrMFARequired) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "mfa_required": true, "request_id": c.GetString("request_id")})
+		return
+	}
+	if errors.Is(err, service.ErrMFAInvalid) {
+		respondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respondError(c, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	// 5. Response
+	// 5. Onboarding: User tanpa jawaban verifikasi pribadi diminta enroll
+	onboarding := []string{}
+	if user.Role == domain.RoleUser {
+		var enrolled int64
+		h.DB.WithContext(c.Request.Context()).Model(&domain.UserVerificationAnswer{}).Where("user_id = ?", user.ID).Count(&enrolled)
+		if enrolled == 0 {
+			onboarding = append(onboarding, onboardingVerificationAnswers)
+		}
+	}
+
+	// 6. Response
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"role":  user.Role,
+		"token":       session.Token,
+		"role":        user.Role,
+		"roles":       session.Roles,
+		"permissions": session.Permissions,
+		"onboarding":  onboarding,
 	})
 }
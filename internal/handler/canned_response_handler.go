@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type CannedResponseHandler struct {
+	Service *service.CannedResponseService
+}
+
+func NewCannedResponseHandler(s *service.CannedResponseService) *CannedResponseHandler {
+	return &CannedResponseHandler{Service: s}
+}
+
+type cannedResponseInput struct {
+	TeamID   *uint                `json:"team_id"` // Wajib untuk Supervisor (library tim), diabaikan untuk CS
+	Shortcut string               `json:"shortcut"`
+	Title    string               `json:"title" binding:"required"`
+	Body     string               `json:"body" binding:"required"`
+	Actions  []domain.MacroAction `json:"actions"`
+}
+
+// ListCannedResponses - GET /api/cs/canned-responses?q= (pribadi + tim) | /api/supervisor/canned-responses?team_id=
+func (h *CannedResponseHandler) ListCannedResponses(c *gin.Context) {
+	var (
+		responses []domain.CannedResponse
+		err       error
+	)
+	if c.GetString("role") == domain.RoleSupervisor {
+		var teamID *uint
+		if v := c.Query("team_id"); v != "" {
+			id, convErr := strconv.Atoi(v)
+			if convErr != nil {
+				respondError(c, http.StatusBadRequest, "invalid 'team_id'")
+				return
+			}
+			tid := uint(id)
+			teamID = &tid
+		}
+		responses, err = h.Service.ListTeamResponses(c.Request.Context(), teamID)
+	} else {
+		responses, err = h.Service.ListForAgent(c.Request.Context(), c.GetUint("user_id"), c.Query("q"))
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil canned responses")
+		return
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateCannedResponse - POST /api/{cs|supervisor}/canned-responses
+func (h *CannedResponseHandler) CreateCannedResponse(c *gin.Context) {
+	h.save(c, 0, http.StatusCreated)
+}
+
+// UpdateCannedResponse - PUT /api/{cs|supervisor}/canned-responses/:id
+func (h *CannedResponseHandler) UpdateCannedResponse(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	h.save(c, uint(id), http.StatusOK)
+}
+
+func (h *CannedResponseHandler) save(c *gin.Context, id uint, status int) {
+	var input cannedResponseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.Service.Save(c.Request.Context(), id, domain.CannedResponse{
+		TeamID:   input.TeamID,
+		Shortcut: input.Shortcut,
+		Title:    input.Title,
+		Body:     input.Body,
+		Actions:  input.Actions,
+	}, c.GetUint("user_id"), c.GetString("role"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(status, response)
+}
+
+// DeleteCannedResponse - DELETE /api/{cs|supervisor}/canned-responses/:id
+func (h *CannedResponseHandler) DeleteCannedResponse(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.Service.Delete(c.Request.Context(), uint(id), c.GetUint("user_id"), c.GetString("role")); err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Canned response deleted"})
+}
+
+// PreviewCannedResponse (CS Only) - GET /api/cs/tickets/:id/canned-responses/:responseId/preview
+func (h *CannedResponseHandler) PreviewCannedResponse(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+	responseID, _ := strconv.Atoi(c.Param("responseId"))
+
+	text, err := h.Service.Preview(c.Request.Context(), uint(ticketID), uint(responseID), c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": text})
+}
+
+// ApplyCannedResponse (CS Only) - POST /api/cs/tickets/:id/canned-responses/:responseId/apply
+func (h *CannedResponseHandler) ApplyCannedResponse(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+	responseID, _ := strconv.Atoi(c.Param("responseId"))
+
+	result, err := h.Service.Apply(c.Request.Context(), uint(ticketID), uint(responseID), c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	// Macro berhenti di tengah -> 207 agar client tahu sebagian langkah sudah dijalankan
+	status := http.StatusOK
+	if !result.Steps[len(result.Steps)-1].OK {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, result)
+}
+
+// GetUsageStats (SUPERVISOR Only) - GET /api/supervisor/canned-responses/usage?from=&to=
+func (h *CannedResponseHandler) GetUsageStats(c *gin.Context) {
+	from, to, err := parseReportWindow(c, 30)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	stats, err := h.Service.GetUsageStats(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil statistik pemakaian")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "responses": stats})
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type CapacityHandler struct {
+	Service *service.CapacityService
+}
+
+func NewCapacityHandler(s *service.CapacityService) *CapacityHandler {
+	return &CapacityHandler{Service: s}
+}
+
+// GetTeams (SUPERVISOR Only) - GET /api/supervisor/teams
+func (h *CapacityHandler) GetTeams(c *gin.Context) {
+	teams, err := h.Service.GetTeams(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil daftar tim")
+		return
+	}
+	c.JSON(http.StatusOK, teams)
+}
+
+type teamInput struct {
+	Name             string `json:"name" binding:"required"`
+	MaxActiveTickets int    `json:"max_active_tickets" binding:"required"` // Batas default per agent anggota
+}
+
+// CreateTeam (SUPERVISOR Only) - POST /api/supervisor/teams
+func (h *CapacityHandler) CreateTeam(c *gin.Context) {
+	h.saveTeam(c, 0, http.StatusCreated)
+}
+
+// UpdateTeam (SUPERVISOR Only) - PUT /api/supervisor/teams/:id
+func (h *CapacityHandler) UpdateTeam(c *gin.Context) {
+	teamID, _ := strconv.Atoi(c.Param("id"))
+	h.saveTeam(c, uint(teamID), http.StatusOK)
+}
+
+func (h *CapacityHandler) saveTeam(c *gin.Context, teamID uint, status int) {
+	var input teamInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	team, err := h.Service.SaveTeam(c.Request.Context(), teamID, input.Name, input.MaxActiveTickets, c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(status, team)
+}
+
+// SetAgentCapacity (SUPERVISOR Only) - PUT /api/supervisor/agents/:id/capacity
+func (h *CapacityHandler) SetAgentCapacity(c *gin.Context) {
+	csID, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		Capacity int   `json:"capacity"` // 0 = ikut batas tim
+		TeamID   *uint `json:"team_id"`  // null = tanpa tim
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	profile, err := h.Service.SetAgentCapacity(c.Request.Context(), uint(csID), input.Capacity, input.TeamID, c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profile": profile, "active_limit": profile.EffectiveCapacity()})
+}
@@ -3,8 +3,12 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/middleware"
+	"github.com/syukurgit/zta/internal/repository"
 	"github.com/syukurgit/zta/internal/service"
 )
 
@@ -29,13 +33,13 @@ func (h *ChatHandler) SendChat(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Message is required"})
+		respondError(c, http.StatusBadRequest, "Message is required")
 		return
 	}
 
-	chat, err := h.Service.SendMessage(uint(ticketID), senderID, role, input.Message)
+	chat, err := h.Service.SendMessage(c.Request.Context(), uint(ticketID), senderID, role, input.Message)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		respondError(c, http.StatusForbidden, err.Error())
 		return
 	}
 
@@ -50,11 +54,209 @@ func (h *ChatHandler) GetHistory(c *gin.Context) {
 	requestorID := c.GetUint("user_id")
 	role := c.GetString("role")
 
-	chats, err := h.Service.GetHistory(uint(ticketID), requestorID, role)
+	// Sinkronisasi inkremental: ?after=<id>&limit=&wait=<detik> (atau ?cursor=<next_cursor> untuk ikut menerima edit / retract)
+	_, hasAfter := c.GetQuery("after")
+	_, hasCursor := c.GetQuery("cursor")
+	if hasAfter || hasCursor {
+		h.syncHistory(c, uint(ticketID), requestorID, role)
+		return
+	}
+
+	q, err := parseListQuery(c)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	chats, err := h.Service.GetHistory(c.Request.Context(), uint(ticketID), requestorID, role, q)
+	if err != nil {
+		respondListError(c, err, http.StatusForbidden, err.Error())
+		return
+	}
+
+	middleware.RecordAccess(c, len(chats.Items), uint(ticketID))
 	c.JSON(http.StatusOK, chats)
-}
\ No newline at end of file
+}
+
+// RevealRedaction (CS Only) - GET /api/cs/tickets/:id/chat/redactions/:redactionId
+func (h *ChatHandler) RevealRedaction(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+	redactionID, _ := strconv.Atoi(c.Param("redactionId"))
+
+	original, err := h.Service.RevealRedaction(c.Request.Context(), uint(ticketID), uint(redactionID), c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"id": redactionID, "value": original})
+}
+
+// GrantRevealAccess (SUPERVISOR Only) - POST /api/supervisor/tickets/:id/dlp-access
+func (h *ChatHandler) GrantRevealAccess(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		CSID    uint   `json:"cs_id" binding:"required"`
+		Minutes int    `json:"minutes" binding:"required"`
+		Reason  string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	privilege, err := h.Service.GrantRevealAccess(c.Request.Context(), uint(ticketID), input.CSID, c.GetUint("user_id"),
+		time.Duration(input.Minutes)*time.Minute, input.Reason)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"action": privilege.Action, "cs_id": privilege.CSID, "expires_at": privilege.ExpiresAt})
+}
+
+// EditChat (User & CS) - PUT /api/{user|cs}/tickets/:id/chat/:chatId
+func (h *ChatHandler) EditChat(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+	chatID, _ := strconv.Atoi(c.Param("chatId"))
+
+	var input struct {
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, "Message is required")
+		return
+	}
+
+	chat, err := h.Service.EditMessage(c.Request.Context(), uint(ticketID), uint(chatID), c.GetUint("user_id"), c.GetString("role"), input.Message)
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, chat)
+}
+
+// RetractChat (User & CS) - DELETE /api/{user|cs}/tickets/:id/chat/:chatId
+func (h *ChatHandler) RetractChat(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+	chatID, _ := strconv.Atoi(c.Param("chatId"))
+
+	chat, err := h.Service.RetractMessage(c.Request.Context(), uint(ticketID), uint(chatID), c.GetUint("user_id"), c.GetString("role"))
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, chat)
+}
+
+// MarkRead (User & CS) - POST /api/{user|cs}/tickets/:id/chat/read
+func (h *ChatHandler) MarkRead(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		UpToID uint `json:"up_to_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, "up_to_id is required")
+		return
+	}
+
+	marked, err := h.Service.MarkRead(c.Request.Context(), uint(ticketID), c.GetUint("user_id"), c.GetString("role"), input.UpToID)
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
+
+// GetUnreadCounts (User & CS) - GET /api/{user|cs}/tickets/unread
+func (h *ChatHandler) GetUnreadCounts(c *gin.Context) {
+	counts, err := h.Service.GetUnreadCounts(c.Request.Context(), c.GetUint("user_id"), c.GetString("role"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to count unread messages")
+		return
+	}
+	c.JSON(http.StatusOK, counts)
+}
+
+// GetRevisions (AUDITOR Only) - GET /api/auditor/tickets/:id/chat/:chatId/revisions
+func (h *ChatHandler) GetRevisions(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+	chatID, _ := strconv.Atoi(c.Param("chatId"))
+
+	revisions, err := h.Service.GetRevisions(c.Request.Context(), uint(ticketID), uint(chatID))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	middleware.RecordAccess(c, len(revisions), uint(ticketID))
+	c.JSON(http.StatusOK, revisions)
+}
+
+// syncHistory: Pesan setelah ID tertentu (?after=) atau setelah cursor updated_at (?cursor=, termasuk pesan yang diedit / ditarik);
+// wait > 0 menahan request (long-poll) sampai ada pesan baru / perubahan
+func (h *ChatHandler) syncHistory(c *gin.Context, ticketID, requestorID uint, role string) {
+	var since repository.ChatSince
+	var err error
+	if value, ok := c.GetQuery("cursor"); ok {
+		if _, both := c.GetQuery("after"); both {
+			respondError(c, http.StatusBadRequest, "use either 'after' or 'cursor'")
+			return
+		}
+		cursor, err := repository.ParseChatCursor(value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "invalid 'cursor'")
+			return
+		}
+		since.Cursor = &cursor
+	} else {
+		afterID, err := strconv.ParseUint(c.Query("after"), 10, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "invalid 'after'")
+			return
+		}
+		since.AfterID = uint(afterID)
+	}
+
+	limit := domain.DefaultPageLimit
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			respondError(c, http.StatusBadRequest, "invalid 'limit'")
+			return
+		}
+		limit = min(limit, domain.MaxPageLimit)
+	}
+	wait := 0
+	if v := c.Query("wait"); v != "" {
+		if wait, err = strconv.Atoi(v); err != nil || wait < 0 {
+			respondError(c, http.StatusBadRequest, "invalid 'wait'")
+			return
+		}
+	}
+
+	chats, err := h.Service.SyncHistory(c.Request.Context(), ticketID, requestorID, role, since, limit, time.Duration(wait)*time.Second)
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	middleware.RecordAccess(c, len(chats), ticketID)
+
+	// next_cursor dipakai sebagai ?cursor= pada request berikutnya
+	if since.Cursor != nil {
+		next := *since.Cursor
+		if len(chats) > 0 {
+			next = repository.ChatCursorOf(&chats[len(chats)-1])
+		}
+		c.JSON(http.StatusOK, gin.H{"items": chats, "next_cursor": next.String(), "has_more": len(chats) == limit})
+		return
+	}
+
+	// next_after dipakai sebagai ?after= pada request berikutnya
+	nextAfter := since.AfterID
+	if len(chats) > 0 {
+		nextAfter = chats[len(chats)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"items": chats, "next_after": nextAfter, "has_more": len(chats) == limit})
+}
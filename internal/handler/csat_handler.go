@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type CSATHandler struct {
+	Service *service.CSATService
+}
+
+func NewCSATHandler(s *service.CSATService) *CSATHandler {
+	return &CSATHandler{Service: s}
+}
+
+// GetSurvey (Public) - GET /csat/:token
+func (h *CSATHandler) GetSurvey(c *gin.Context) {
+	survey, err := h.Service.GetSurvey(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		respondError(c, csatErrorStatus(err), err.Error())
+		return
+	}
+
+	// Tidak ada data CS / User yang dikirim: cukup konteks tiket
+	c.JSON(http.StatusOK, gin.H{
+		"ticket_id":  survey.TicketID,
+		"category":   survey.Category,
+		"expires_at": survey.ExpiresAt,
+		"scale":      []int{1, 2, 3, 4, 5},
+	})
+}
+
+// SubmitSurvey (Public) - POST /csat/:token
+func (h *CSATHandler) SubmitSurvey(c *gin.Context) {
+	var input struct {
+		Rating  int    `json:"rating" binding:"required"`
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid input format")
+		return
+	}
+
+	if err := h.Service.SubmitRating(c.Request.Context(), c.Param("token"), input.Rating, input.Comment); err != nil {
+		respondError(c, csatErrorStatus(err), err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Thank you for your feedback"})
+}
+
+// GetCSATReport (SUPERVISOR Only) - GET /api/supervisor/reports/csat?from=&to=
+func (h *CSATHandler) GetCSATReport(c *gin.Context) {
+	// Default: 30 hari terakhir
+	from, to, err := parseReportWindow(c, 30)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.Service.BuildReport(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal menyusun laporan CSAT")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "csat": report})
+}
+
+func csatErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCSATNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCSATClosed):
+		return http.StatusGone
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/domain"
+)
+
+// parseListQuery membaca parameter listing bersama:
+// ?limit=&page= | ?cursor=, ?sort=&order=asc|desc, ?status=A,B, ?from=&to= (RFC3339), ?q=
+func parseListQuery(c *gin.Context) (domain.ListQuery, error) {
+	q := domain.ListQuery{
+		Limit:  domain.DefaultPageLimit,
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+		Search: strings.TrimSpace(c.Query("q")),
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return q, errors.New("invalid 'limit'")
+		}
+		q.Limit = min(limit, domain.MaxPageLimit)
+	}
+	if v := c.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return q, errors.New("invalid 'page'")
+		}
+		q.Page = page
+	}
+
+	switch strings.ToLower(c.Query("order")) {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, errors.New("invalid 'order', use asc or desc")
+	}
+
+	if v := c.Query("status"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			if status = strings.ToUpper(strings.TrimSpace(status)); status != "" {
+				q.Status = append(q.Status, status)
+			}
+		}
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if v := c.Query(param.name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, errors.New("invalid '" + param.name + "' format, use RFC3339")
+			}
+			*param.target = &parsed
+		}
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return q, errors.New("'from' must be before 'to'")
+	}
+	return q, nil
+}
+
+// respondListError: Error parameter listing (cursor/sort) -> 400, selain itu -> status fallback
+func respondListError(c *gin.Context, err error, status int, message string) {
+	if errors.Is(err, domain.ErrInvalidCursor) || errors.Is(err, domain.ErrInvalidSort) {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondError(c, status, message)
+}
+
+// parseReportWindow membaca ?from=&to= (RFC3339) untuk laporan agregat; default defaultDays hari terakhir
+func parseReportWindow(c *gin.Context, defaultDays int) (time.Time, time.Time, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -defaultDays)
+
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, errors.New("Invalid 'from' format, use RFC3339")
+		}
+		from = parsed
+	}
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, errors.New("Invalid 'to' format, use RFC3339")
+		}
+		to = parsed
+	}
+	if !from.Before(to) {
+		return from, to, errors.New("'from' must be before 'to'")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/middleware"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type NoteHandler struct {
+	Service *service.NoteService
+}
+
+func NewNoteHandler(s *service.NoteService) *NoteHandler {
+	return &NoteHandler{Service: s}
+}
+
+type noteInput struct {
+	Body       string `json:"body" binding:"required"`
+	MentionIDs []uint `json:"mention_ids"` // ID CS / Supervisor yang disebut
+}
+
+// AddNote (CS & SUPERVISOR) - POST /tickets/:id/notes
+func (h *NoteHandler) AddNote(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	var input noteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	note, err := h.Service.AddNote(c.Request.Context(), uint(ticketID), c.GetUint("user_id"), c.GetString("role"), input.Body, input.MentionIDs)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, note)
+}
+
+// EditNote (CS & SUPERVISOR, penulis saja) - PUT /notes/:id
+func (h *NoteHandler) EditNote(c *gin.Context) {
+	noteID, _ := strconv.Atoi(c.Param("id"))
+
+	var input noteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	note, err := h.Service.EditNote(c.Request.Context(), uint(noteID), c.GetUint("user_id"), c.GetString("role"), input.Body, input.MentionIDs)
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, note)
+}
+
+// GetNotes (CS, SUPERVISOR & AUDITOR) - GET /tickets/:id/notes
+func (h *NoteHandler) GetNotes(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	notes, err := h.Service.GetNotes(c.Request.Context(), uint(ticketID), c.GetUint("user_id"), c.GetString("role"))
+	if err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	middleware.RecordAccess(c, len(notes), uint(ticketID))
+	c.JSON(http.StatusOK, notes)
+}
+
+// GetNoteRevisions (CS, SUPERVISOR & AUDITOR) - GET /notes/:id/revisions
+func (h *NoteHandler) GetNoteRevisions(c *gin.Context) {
+	noteID, _ := strconv.Atoi(c.Param("id"))
+
+	revisions, err := h.Service.GetNoteRevisions(c.Request.Context(), uint(noteID), c.GetUint("user_id"), c.GetString("role"))
+	if errors.Is(err, service.ErrNoteAccessDenied) {
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	middleware.RecordAccess(c, len(revisions))
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetMentions (CS & SUPERVISOR) - GET /mentions (+ parameter listing)
+func (h *NoteHandler) GetMentions(c *gin.Context) {
+	q, err := parseListQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	notes, err := h.Service.GetMentions(c.Request.Context(), c.GetUint("user_id"), q)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Gagal mengambil mention")
+		return
+	}
+	c.JSON(http.StatusOK, notes)
+}
+
+// GetTicketTimeline (AUDITOR Only) - GET /api/auditor/tickets/:id/timeline
+func (h *NoteHandler) GetTicketTimeline(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	timeline, err := h.Service.GetTicketTimeline(c.Request.Context(), uint(ticketID))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil timeline tiket")
+		return
+	}
+
+	middleware.RecordAccess(c, len(timeline), uint(ticketID))
+	c.JSON(http.StatusOK, timeline)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type RegistrationHandler struct {
+	Service *service.RegistrationService
+}
+
+func NewRegistrationHandler(s *service.RegistrationService) *RegistrationHandler {
+	return &RegistrationHandler{Service: s}
+}
+
+// Response sama untuk email baru & yang sudah terdaftar (anti enumerasi akun)
+const registrationAccepted = "If the address can be registered, a confirmation link has been sent to it."
+
+// Register (Public) - POST /register
+func (h *RegistrationHandler) Register(c *gin.Context) {
+	var input struct {
+		Email       string `json:"email" binding:"required,email"`
+		Password    string `json:"password" binding:"required"`
+		DisplayName string `json:"display_name"` // Opsional, untuk sapaan {{user.name}}
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.Service.Register(c.Request.Context(), input.Email, input.Password, input.DisplayName); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": registrationAccepted})
+}
+
+// ResendConfirmation (Public) - POST /register/resend
+func (h *RegistrationHandler) ResendConfirmation(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.Service.ResendConfirmation(c.Request.Context(), input.Email); err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengirim ulang link konfirmasi")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": registrationAccepted})
+}
+
+// ConfirmEmail (Public) - POST /register/confirm/:token
+func (h *RegistrationHandler) ConfirmEmail(c *gin.Context) {
+	if _, err := h.Service.ConfirmEmail(c.Request.Context(), c.Param("token")); err != nil {
+		if errors.Is(err, repository.ErrTokenInvalid) {
+			respondError(c, http.StatusGone, err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "Gagal mengkonfirmasi email")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Email confirmed. You can now log in.",
+		"onboarding": []string{onboardingVerificationAnswers},
+	})
+}
+
+// AcceptInvite (Public) - POST /invite/:token {password}: Staff baru menetapkan password dari link undangan
+func (h *RegistrationHandler) AcceptInvite(c *gin.Context) {
+	var input struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := h.Service.AcceptInvite(c.Request.Context(), c.Param("token"), input.Password); err != nil {
+		if errors.Is(err, repository.ErrTokenInvalid) {
+			respondError(c, http.StatusGone, err.Error())
+			return
+		}
+		respondError(c, http.StatusBadRequest, err.Error()) // Kebijakan password
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password set. You can now log in."})
+}
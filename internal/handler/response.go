@@ -0,0 +1,9 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+// respondError: Format response error standar untuk semua handler.
+// request_id disertakan agar error di client bisa dilacak ke log server & AuditLog.
+func respondError(c *gin.Context, status int, message string) {
+	c.JSON(status, gin.H{"error": message, "request_id": c.GetString("request_id")})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type RoutingHandler struct {
+	Service *service.RoutingService
+}
+
+func NewRoutingHandler(s *service.RoutingService) *RoutingHandler {
+	return &RoutingHandler{Service: s}
+}
+
+// GetMyProfile (CS Only) - GET /api/cs/profile
+func (h *RoutingHandler) GetMyProfile(c *gin.Context) {
+	profile, err := h.Service.GetProfile(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil profil agent")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profile": profile, "active_limit": profile.EffectiveCapacity(), "routing_mode": h.Service.Mode})
+}
+
+// SetPresence (CS Only) - PUT /api/cs/presence
+func (h *RoutingHandler) SetPresence(c *gin.Context) {
+	var input struct {
+		Presence string `json:"presence" binding:"required"` // AVAILABLE | AWAY | OFFLINE
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	profile, err := h.Service.SetPresence(c.Request.Context(), c.GetUint("user_id"), input.Presence)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// GetAgents (SUPERVISOR Only) - GET /api/supervisor/agents
+func (h *RoutingHandler) GetAgents(c *gin.Context) {
+	profiles, err := h.Service.GetProfiles(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil daftar agent")
+		return
+	}
+	c.JSON(http.StatusOK, profiles)
+}
+
+// UpdateAgentProfile (SUPERVISOR Only) - PUT /api/supervisor/agents/:id/profile
+func (h *RoutingHandler) UpdateAgentProfile(c *gin.Context) {
+	csID, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		Skills    []string `json:"skills" binding:"required"`    // Kategori tiket, "*" = semua
+		Languages []string `json:"languages" binding:"required"` // Kode bahasa, "*" = semua
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	profile, err := h.Service.UpdateProfile(c.Request.Context(), uint(csID), input.Skills, input.Languages, c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type ShredHandler struct {
+	Service *service.ShredService
+}
+
+func NewShredHandler(s *service.ShredService) *ShredHandler {
+	return &ShredHandler{Service: s}
+}
+
+// ShredTicket (SUPERVISOR Only) - POST /api/supervisor/tickets/:id/shred
+func (h *ShredHandler) ShredTicket(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, "Reason is required")
+		return
+	}
+
+	if err := h.Service.ShredTicket(c.Request.Context(), uint(ticketID), c.GetUint("user_id"), input.Reason); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket data has been crypto-shredded"})
+}
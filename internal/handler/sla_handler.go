@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+type SLAHandler struct {
+	Service *service.SLAService
+}
+
+func NewSLAHandler(s *service.SLAService) *SLAHandler {
+	return &SLAHandler{Service: s}
+}
+
+// GetBreaches (SUPERVISOR Only) - GET /api/supervisor/sla/breaches?include_closed=true (+ parameter listing)
+func (h *SLAHandler) GetBreaches(c *gin.Context) {
+	q, err := parseListQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tickets, err := h.Service.GetBreachedTickets(c.Request.Context(), c.Query("include_closed") == "true", q)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Gagal mengambil data pelanggaran SLA")
+		return
+	}
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetPolicies (SUPERVISOR Only) - GET /api/supervisor/sla/policies
+func (h *SLAHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.Service.GetPolicies(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil policy SLA")
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+// UpsertPolicy (SUPERVISOR Only) - PUT /api/supervisor/sla/policies
+func (h *SLAHandler) UpsertPolicy(c *gin.Context) {
+	var input struct {
+		Category             string `json:"category" binding:"required"`
+		Priority             string `json:"priority" binding:"required"` // "*" = semua prioritas
+		FirstResponseMinutes int    `json:"first_response_minutes" binding:"required"`
+		ResolutionMinutes    int    `json:"resolution_minutes" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	policy := &domain.SLAPolicy{
+		Category:             input.Category,
+		Priority:             input.Priority,
+		FirstResponseMinutes: input.FirstResponseMinutes,
+		ResolutionMinutes:    input.ResolutionMinutes,
+	}
+	if err := h.Service.UpsertPolicy(c.Request.Context(), policy, c.GetUint("user_id")); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+// ssoStateCookie: Mengikat state login ke browser yang memulai login (cegah login CSRF: callback dengan
+// code + state milik penyerang di browser korban)
+const ssoStateCookie = "zta_sso_state"
+
+// SSOHandler: Login staff lewat OpenID Connect. Service nil = SSO tidak dikonfigurasi (OIDC_ISSUER kosong).
+type SSOHandler struct {
+	Service      *service.SSOService
+	SecureCookie bool // true jika diakses lewat HTTPS (OIDC_REDIRECT_URL https://)
+}
+
+func NewSSOHandler(s *service.SSOService) *SSOHandler {
+	return &SSOHandler{Service: s}
+}
+
+// Login (Public) - GET /auth/oidc/login?login_hint=... -> redirect 302 ke IdP
+func (h *SSOHandler) Login(c *gin.Context) {
+	if h.Service == nil {
+		respondError(c, http.StatusNotFound, "SSO is not configured")
+		return
+	}
+
+	redirectURL, state, err := h.Service.Begin(c.Request.Context(), c.Query("login_hint"))
+	if err != nil {
+		respondError(c, http.StatusBadGateway, "Identity provider is unavailable")
+		return
+	}
+	h.setStateCookie(c, state, int(service.SSOStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// Callback (Public) - POST /auth/oidc/callback {code, state}
+// Frontend di OIDC_REDIRECT_URL meneruskan parameter code & state dari IdP ke endpoint ini.
+func (h *SSOHandler) Callback(c *gin.Context) {
+	if h.Service == nil {
+		respondError(c, http.StatusNotFound, "SSO is not configured")
+		return
+	}
+
+	var input struct {
+		Code  string `json:"code" binding:"required"`
+		State string `json:"state" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// State harus sama dengan cookie dari Login di browser yang sama; cookie sekali pakai
+	cookie, err := c.Cookie(ssoStateCookie)
+	h.setStateCookie(c, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(input.State)) != 1 {
+		respondError(c, http.StatusUnauthorized, service.ErrSSOFailed.Error())
+		return
+	}
+
+	login, err := h.Service.Complete(c.Request.Context(), input.Code, input.State, c.ClientIP(), c.Request.UserAgent())
+	switch {
+	case errors.Is(err, service.ErrSSODenied):
+		respondError(c, http.StatusForbidden, err.Error())
+		return
+	case errors.Is(err, service.ErrSSOFailed):
+		respondError(c, http.StatusUnauthorized, service.ErrSSOFailed.Error())
+		return
+	case err != nil:
+		respondError(c, http.StatusInternalServerError, "Failed to complete SSO login")
+		return
+	}
+
+	// Response sama dengan POST /login (akun SSO selalu staff -> tanpa onboarding)
+	c.JSON(http.StatusOK, gin.H{
+		"token":       login.Session.Token,
+		"role":        login.User.Role,
+		"roles":       login.Session.Roles,
+		"permissions": login.Session.Permissions,
+		"onboarding":  []string{},
+	})
+}
+
+func (h *SSOHandler) setStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoStateCookie, state, maxAge, "/auth/oidc", "", h.SecureCookie, true)
+}
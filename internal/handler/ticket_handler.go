@@ -22,17 +22,20 @@ func (h *TicketHandler) CreateTicket(c *gin.Context) {
 	userID := c.GetUint("user_id")
 
 	var input struct {
-		Subject string `json:"subject" binding:"required"`
+		Subject  string `json:"subject" binding:"required"`
+		Category string `json:"category"` // Default: GENERAL
+		Priority string `json:"priority"` // LOW | MEDIUM | HIGH | URGENT (default: MEDIUM)
+		Language string `json:"language"` // Kode bahasa untuk routing (default: id)
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	ticket, err := h.Service.CreateTicket(userID, input.Subject)
+	ticket, err := h.Service.CreateTicket(c.Request.Context(), userID, input.Subject, input.Category, input.Priority, input.Language)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -41,9 +44,15 @@ func (h *TicketHandler) CreateTicket(c *gin.Context) {
 
 // GetOpenTickets (CS Only)
 func (h *TicketHandler) GetOpenTickets(c *gin.Context) {
-	tickets, err := h.Service.GetOpenQueue()
+	q, err := parseListQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tickets"})
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tickets, err := h.Service.GetOpenQueue(c.Request.Context(), q)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Failed to fetch tickets")
 		return
 	}
 	c.JSON(http.StatusOK, tickets)
@@ -55,10 +64,10 @@ func (h *TicketHandler) ClaimTicket(c *gin.Context) {
 	ticketIDStr := c.Param("id")
 	ticketID, _ := strconv.Atoi(ticketIDStr)
 
-	err := h.Service.ClaimTicket(csID, uint(ticketID))
+	err := h.Service.ClaimTicket(c.Request.Context(), csID, uint(ticketID))
 	if err != nil {
 		// Bisa jadi error karena tiket sudah diambil orang lain
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		respondError(c, http.StatusConflict, err.Error())
 		return
 	}
 
@@ -66,24 +75,97 @@ func (h *TicketHandler) ClaimTicket(c *gin.Context) {
 }
 
 
+// MarkPendingUser (CS Only) - POST /api/cs/tickets/:id/pending-user
+// Status -> PENDING_USER, SLA penyelesaian dijeda sampai user membalas chat.
+func (h *TicketHandler) MarkPendingUser(c *gin.Context) {
+	csID := c.GetUint("user_id")
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.Service.MarkPendingUser(c.Request.Context(), uint(ticketID), csID); err != nil {
+		respondError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket is now pending user reply. SLA paused."})
+}
+
+// ResumeTicket (CS Only) - POST /api/cs/tickets/:id/resume
+func (h *TicketHandler) ResumeTicket(c *gin.Context) {
+	csID := c.GetUint("user_id")
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.Service.ResumeTicket(c.Request.Context(), uint(ticketID), csID); err != nil {
+		respondError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket is back IN_PROGRESS."})
+}
+
+// ReopenTicket (USER Only) - POST /api/user/tickets/:id/reopen
+func (h *TicketHandler) ReopenTicket(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.Service.ReopenTicket(c.Request.Context(), uint(ticketID), userID); err != nil {
+		respondError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket reopened. Your previous agent will continue."})
+}
+
+// TransferTicket (CS: hand-off, SUPERVISOR: reassign)
+// POST /api/cs/tickets/:id/transfer & POST /api/supervisor/tickets/:id/reassign
+func (h *TicketHandler) TransferTicket(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	var input struct {
+		ToCSID uint   `json:"to_cs_id" binding:"required"`
+		Note   string `json:"note" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, "to_cs_id and note are required")
+		return
+	}
+
+	err := h.Service.TransferTicket(c.Request.Context(), uint(ticketID), input.ToCSID, c.GetUint("user_id"), c.GetString("role"), input.Note)
+	if err != nil {
+		respondError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket transferred. Previous CS access revoked."})
+}
+
+// GetAssignmentHistory (SUPERVISOR Only) - GET /api/supervisor/tickets/:id/assignments
+func (h *TicketHandler) GetAssignmentHistory(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	history, err := h.Service.GetAssignmentHistory(c.Request.Context(), uint(ticketID))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil riwayat assignment")
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
 // ResetPasswordAction (CS Only)
 func (h *TicketHandler) ResetPasswordAction(c *gin.Context) {
     csID := c.GetUint("user_id")
     ticketIDStr := c.Param("id")
     ticketID, _ := strconv.Atoi(ticketIDStr)
 
-    // Panggil service yang baru kita buat
-    newPass, err := h.Service.ExecuteResetPassword(csID, uint(ticketID))
-    if err != nil {
-        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    // Link reset dikirim langsung ke User sebagai pesan SYSTEM (CS tidak pernah melihat link)
+    if _, err := h.Service.ExecuteResetPassword(c.Request.Context(), csID, uint(ticketID)); err != nil {
+        respondError(c, http.StatusForbidden, err.Error())
         return
     }
 
     c.JSON(http.StatusOK, gin.H{
         "status": "SUCCESS",
         "message": "Temporary access granted and used successfully.",
-        "new_user_password": newPass,
-        "info": "Share this password securely with the user via phone.",
+        "info": "The reset link has been sent to the user via chat.",
     })
 }
 
@@ -100,9 +182,9 @@ func (h *TicketHandler) CloseTicket(c *gin.Context) {
 	requestorID := c.GetUint("user_id")
 	role := c.GetString("role")
 
-	err := h.Service.CloseTicket(uint(ticketID), requestorID, role)
+	err := h.Service.CloseTicket(c.Request.Context(), uint(ticketID), requestorID, role)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -115,10 +197,16 @@ func (h *TicketHandler) CloseTicket(c *gin.Context) {
 func (h *TicketHandler) GetUserTickets(c *gin.Context) {
     userID := c.GetUint("user_id") // Dari Middleware JWT
 
+    q, err := parseListQuery(c)
+    if err != nil {
+        respondError(c, http.StatusBadRequest, err.Error())
+        return
+    }
+
     // Panggil Service (Nanti kita buat di bawah)
-    tickets, err := h.Service.GetUserTickets(userID)
+    tickets, err := h.Service.GetUserTickets(c.Request.Context(), userID, q)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data tiket"})
+        respondListError(c, err, http.StatusInternalServerError, "Gagal mengambil data tiket")
         return
     }
 
@@ -132,9 +220,15 @@ func (h *TicketHandler) GetCSActiveTickets(c *gin.Context) {
     csID := c.GetUint("user_id")
 
     // Ambil tiket yang statusnya IN_PROGRESS dan di-handle oleh CS ini
-    tickets, err := h.Service.GetCSActiveTickets(csID)
+    q, err := parseListQuery(c)
+    if err != nil {
+        respondError(c, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    tickets, err := h.Service.GetCSActiveTickets(c.Request.Context(), csID, q)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil tiket aktif"})
+        respondListError(c, err, http.StatusInternalServerError, "Gagal mengambil tiket aktif")
         return
     }
 
@@ -149,9 +243,9 @@ func (h *TicketHandler) GetTicketDetail(c *gin.Context) {
     ticketID, _ := strconv.Atoi(ticketIDStr)
 
     // Logika Service GetByID biasa
-    ticket, err := h.Service.Repo.GetByID(uint(ticketID)) 
+    ticket, err := h.Service.Repo.GetByID(c.Request.Context(), uint(ticketID)) 
     if err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+        respondError(c, http.StatusNotFound, "Ticket not found")
         return
     }
     
@@ -167,9 +261,15 @@ func (h *TicketHandler) GetTicketDetail(c *gin.Context) {
 func (h *TicketHandler) GetCSHistory(c *gin.Context) {
     csID := c.GetUint("user_id")
 
-    tickets, err := h.Service.GetCSHistory(csID)
+    q, err := parseListQuery(c)
+    if err != nil {
+        respondError(c, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    tickets, err := h.Service.GetCSHistory(c.Request.Context(), csID, q)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil riwayat tiket"})
+        respondListError(c, err, http.StatusInternalServerError, "Gagal mengambil riwayat tiket")
         return
     }
 
@@ -187,13 +287,13 @@ type ResetPasswordRequest struct {
 func (h *TicketHandler) SubmitUserResetPassword(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	err := h.Service.ProcessUserResetPassword(req.Token, req.NewPassword)
+	err := h.Service.ProcessUserResetPassword(c.Request.Context(), req.Token, req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		respondError(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
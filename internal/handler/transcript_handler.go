@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/middleware"
+	"github.com/syukurgit/zta/internal/service"
+)
+
+// maxBundleSize: Batas ukuran bundle yang diunggah untuk verifikasi
+const maxBundleSize = 64 << 20
+
+type TranscriptHandler struct {
+	Service *service.TranscriptService
+}
+
+func NewTranscriptHandler(s *service.TranscriptService) *TranscriptHandler {
+	return &TranscriptHandler{Service: s}
+}
+
+// ExportTranscript (AUDITOR Only) - GET /api/auditor/tickets/:id/transcript -> zip (HTML + PDF + manifest bertanda tangan)
+func (h *TranscriptHandler) ExportTranscript(c *gin.Context) {
+	ticketID, _ := strconv.Atoi(c.Param("id"))
+
+	result, err := h.Service.Export(c.Request.Context(), uint(ticketID), c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	middleware.RecordAccess(c, 1, uint(ticketID))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ticket-%d-transcript-%s.zip"`, ticketID, result.Manifest.ExportID))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", result.Zip)
+}
+
+// VerifyTranscript (AUDITOR Only) - POST /api/auditor/transcripts/verify (multipart: bundle)
+func (h *TranscriptHandler) VerifyTranscript(c *gin.Context) {
+	fileHeader, err := c.FormFile("bundle")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Bundle file is required")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Failed to read bundle")
+		return
+	}
+	defer file.Close()
+
+	bundle, err := io.ReadAll(io.LimitReader(file, maxBundleSize+1))
+	if err != nil || len(bundle) > maxBundleSize {
+		respondError(c, http.StatusBadRequest, "Bundle is too large or unreadable")
+		return
+	}
+
+	result := h.Service.Verify(c.Request.Context(), bundle, c.GetUint("user_id"))
+	status := http.StatusOK
+	if !result.Valid {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, result)
+}
+
+// GetSigningKey (AUDITOR Only) - GET /api/auditor/transcripts/public-key, untuk verifikasi offline
+func (h *TranscriptHandler) GetSigningKey(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm":  "Ed25519",
+		"key_id":     h.Service.Signer.KeyID(),
+		"public_key": base64.StdEncoding.EncodeToString(h.Service.Signer.PublicKey()),
+	})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -22,15 +23,15 @@ func (h *VerificationHandler) StartVerification(c *gin.Context) {
 	ticketID, _ := strconv.Atoi(ticketIDStr)
 	csID := c.GetUint("user_id")
 
-	verificationURL, err := h.Service.StartVerification(uint(ticketID), csID)
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	// Link verifikasi dikirim langsung ke User sebagai pesan SYSTEM, tidak lewat CS
+	if _, err := h.Service.StartVerification(c.Request.Context(), uint(ticketID), csID); err != nil {
+		respondError(c, http.StatusForbidden, err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": "PENDING",
-		"verification_url": verificationURL,
+		"status":  "PENDING",
+		"message": "Verification link has been sent to the user via chat.",
 	})
 }
 
@@ -40,9 +41,9 @@ func (h *VerificationHandler) StartVerification(c *gin.Context) {
 func (h *VerificationHandler) GetVerificationPage(c *gin.Context) {
 	sessionID := c.Param("token")
 	
-	questions, err := h.Service.GetVerificationQuestions(sessionID)
+	questions, err := h.Service.GetVerificationQuestions(c.Request.Context(), sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -66,12 +67,13 @@ func (h *VerificationHandler) SubmitVerification(c *gin.Context) {
 	sessionID := c.Param("token")
 
 	var input struct {
-		Answers map[string]string `json:"answers" binding:"required"`
+		Answers         map[string]string `json:"answers" binding:"required"`
+		CurrentPassword string            `json:"current_password"` // Wajib jika jawaban sudah pernah di-enroll
 	}
 
 	// 1. Bind JSON
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input format"})
+		respondError(c, http.StatusBadRequest, "Invalid input format")
 		return
 	}
 
@@ -81,18 +83,20 @@ func (h *VerificationHandler) SubmitVerification(c *gin.Context) {
 	for k, v := range input.Answers {
 		id, err := strconv.ParseUint(k, 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+			respondError(c, http.StatusBadRequest, "Invalid question ID")
 			return
 		}
 		answers[uint(id)] = v
 	}
 
 	// 3. Submit ke service
-	passed, err := h.Service.SubmitAnswers(sessionID, answers)
+	passed, err := h.Service.SubmitAnswers(c.Request.Context(), sessionID, answers)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "System error processing answers",
-		})
+		if errors.Is(err, service.ErrVerificationClosed) {
+			respondError(c, http.StatusGone, err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "System error processing answers")
 		return
 	}
 
@@ -104,8 +108,54 @@ func (h *VerificationHandler) SubmitVerification(c *gin.Context) {
 		})
 	} else {
 		c.JSON(http.StatusForbidden, gin.H{
-			"status":  "FAILED",
-			"message": "Verification failed. Access denied.",
+			"status":     "FAILED",
+			"message":    "Verification failed. Access denied.",
+			"request_id": c.GetString("request_id"),
 		})
 	}
 }
+
+// onboardingVerificationAnswers: Langkah onboarding (lihat Login & ConfirmEmail)
+const onboardingVerificationAnswers = "VERIFICATION_ANSWERS"
+
+// GetOnboarding (USER Only) - GET /api/user/onboarding
+func (h *VerificationHandler) GetOnboarding(c *gin.Context) {
+	status, err := h.Service.GetOnboarding(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Gagal mengambil status onboarding")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollVerificationAnswers (USER Only) - PUT /api/user/onboarding/verification-answers
+func (h *VerificationHandler) EnrollVerificationAnswers(c *gin.Context) {
+	var input struct {
+		Answers         map[string]string `json:"answers" binding:"required"`
+		CurrentPassword string            `json:"current_password"` // Wajib jika jawaban sudah pernah di-enroll
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid input format")
+		return
+	}
+
+	answers := make(map[uint]string, len(input.Answers))
+	for k, v := range input.Answers {
+		id, err := strconv.ParseUint(k, 10, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid question ID")
+			return
+		}
+		answers[uint(id)] = v
+	}
+
+	if err := h.Service.EnrollAnswers(c.Request.Context(), c.GetUint("user_id"), answers, input.CurrentPassword); err != nil {
+		if errors.Is(err, service.ErrReauthRequired) {
+			respondError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Verification answers enrolled"})
+}
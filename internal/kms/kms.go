@@ -0,0 +1,98 @@
+// Package kms membungkus (wrap) data key dengan master key (KEK) untuk envelope encryption.
+// Implementasi bisa diganti ke KMS cloud / HSM tanpa mengubah pemakai.
+package kms
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+var ErrUnknownKey = errors.New("unknown key encryption key")
+
+// KeyProvider: Sumber master key (KEK). Data key tidak pernah disimpan tanpa dibungkus.
+type KeyProvider interface {
+	// Wrap membungkus data key dengan KEK aktif, mengembalikan ID KEK yang dipakai
+	Wrap(ctx context.Context, dataKey []byte) (kekID string, wrapped []byte, err error)
+	// Unwrap membuka data key dengan KEK sesuai ID (KEK lama tetap bisa membuka setelah rotasi)
+	Unwrap(ctx context.Context, kekID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalFileKeyProvider: KEK disimpan di file lokal, satu baris per key: "<id> <base64 32 byte>".
+// Baris terakhir = KEK aktif; rotasi cukup menambah baris baru.
+type LocalFileKeyProvider struct {
+	Path   string
+	keys   map[string][]byte
+	active string
+}
+
+// NewLocalFileKeyProvider memuat file KEK, atau membuatnya (0600) dengan key baru jika belum ada
+func NewLocalFileKeyProvider(path string) (*LocalFileKeyProvider, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := generateKeyFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	p := &LocalFileKeyProvider{Path: path, keys: make(map[string][]byte)}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(text, " ")
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if !ok || err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s:%d: invalid key line", path, line)
+		}
+		p.keys[id] = key
+		p.active = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if p.active == "" {
+		return nil, fmt.Errorf("%s: no key found", path)
+	}
+	return p, nil
+}
+
+func (p *LocalFileKeyProvider) Wrap(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := utils.SealGCM(p.keys[p.active], dataKey)
+	return p.active, wrapped, err
+}
+
+func (p *LocalFileKeyProvider) Unwrap(ctx context.Context, kekID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[kekID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return utils.OpenGCM(key, wrapped)
+}
+
+func generateKeyFile(path string) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	content := "# KEK lokal (JANGAN commit). Baris terakhir = key aktif.\nk1 " + base64.StdEncoding.EncodeToString(key) + "\n"
+	return os.WriteFile(path, []byte(content), 0o600)
+}
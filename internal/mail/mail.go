@@ -0,0 +1,68 @@
+// Package mail mengirim email transaksional (konfirmasi registrasi, dll.).
+// Implementasi dipilih lewat MAIL_PROVIDER: log (default, untuk development) | smtp.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Sender: Pengirim email teks biasa
+type Sender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// LogSender menulis email ke log server alih-alih mengirimnya. Hanya untuk development:
+// isi email (termasuk link rahasia) ikut tercetak.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, to, subject, body string) error {
+	log.Printf("[mail] to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
+
+// SMTPSender mengirim lewat server SMTP (AUTH PLAIN jika Username diisi)
+type SMTPSender struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+}
+
+func (s SMTPSender) Send(ctx context.Context, to, subject, body string) error {
+	// Header injection: alamat & subject tidak boleh mengandung baris baru
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid mail header")
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	msg := "From: " + s.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+	return smtp.SendMail(s.Addr, auth, s.From, []string{to}, []byte(msg))
+}
+
+// FromEnv memilih Sender berdasarkan MAIL_PROVIDER, SMTP_ADDR, SMTP_FROM, SMTP_USERNAME, SMTP_PASSWORD
+func FromEnv() Sender {
+	if os.Getenv("MAIL_PROVIDER") == "smtp" {
+		return SMTPSender{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			From:     os.Getenv("SMTP_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	}
+	return LogSender{}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+)
+
+// Key context yang diisi handler agar middleware tahu apa yang dibaca auditor
+const (
+	accessRowsKey    = "access_rows"
+	accessTicketsKey = "access_ticket_ids"
+)
+
+// RecordAccess dipanggil handler untuk mencatat jumlah baris & tiket yang dikembalikan
+func RecordAccess(c *gin.Context, rows int, ticketIDs ...uint) {
+	c.Set(accessRowsKey, rows)
+
+	existing, _ := c.Get(accessTicketsKey)
+	ids, _ := existing.([]uint)
+	c.Set(accessTicketsKey, append(ids, ticketIDs...))
+}
+
+// AuditAccessLogger mencatat setiap query yang dilakukan role AUDITOR ke stream terpisah.
+// Harus dipasang SETELAH AuthMiddleware agar identitas sudah tersedia.
+func AuditAccessLogger(repo *repository.AccessLogRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.GetString("role") != domain.RoleAuditor {
+			return
+		}
+
+		// 1. Kumpulkan tiket yang disentuh (param URL + yang dilaporkan handler)
+		seen := map[uint]bool{}
+		var tickets []string
+		addTicket := func(id uint) {
+			if id == 0 || seen[id] {
+				return
+			}
+			seen[id] = true
+			tickets = append(tickets, strconv.FormatUint(uint64(id), 10))
+		}
+
+		if idParam, err := strconv.ParseUint(c.Param("id"), 10, 64); err == nil {
+			addTicket(uint(idParam))
+		}
+		if raw, ok := c.Get(accessTicketsKey); ok {
+			for _, id := range raw.([]uint) {
+				addTicket(id)
+			}
+		}
+
+		// 2. Simpan jejak (gagal simpan tidak boleh mengubah response, cukup di-log server)
+		entry := &domain.AuditAccessLog{
+			ActorID:      c.GetUint("user_id"),
+			ActorRole:    c.GetString("role"),
+			Method:       c.Request.Method,
+			Path:         c.FullPath(),
+			Filters:      c.Request.URL.RawQuery,
+			TicketIDs:    strings.Join(tickets, ","),
+			RowsReturned: c.GetInt(accessRowsKey),
+			StatusCode:   c.Writer.Status(),
+			ClientIP:     c.ClientIP(),
+			RequestID:    c.GetString("request_id"),
+		}
+		if entry.Path == "" {
+			entry.Path = c.Request.URL.Path
+		}
+
+		if err := repo.CreateAccessLog(c.Request.Context(), entry); err != nil {
+			log.Printf("[%s] failed to write auditor access log: %v", entry.RequestID, err)
+		}
+	}
+}
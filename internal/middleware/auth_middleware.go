@@ -1,56 +1,113 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/syukurgit/zta/internal/repository"
 	"github.com/syukurgit/zta/pkg/utils"
 )
 
-// AuthMiddleware memverifikasi Bearer Token
-func AuthMiddleware() gin.HandlerFunc {
+// PermissionResolver: Sumber permission efektif dari daftar role (service.PermissionService, ter-cache)
+type PermissionResolver interface {
+	Resolve(ctx context.Context, roles []string) ([]string, error)
+}
+
+// AuthMiddleware memverifikasi Bearer Token + sesi server-side (bisa dicabut ADMIN / logout),
+// lalu menghitung permission efektif dari role sesi (mapping terbaru, bukan salinan di JWT)
+func AuthMiddleware(sessions *repository.SessionRepository, permissions PermissionResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Ambil header Authorization
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			abortWithError(c, http.StatusUnauthorized, "Authorization header required")
 			return
 		}
 
 		// 2. Format harus "Bearer <token>"
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
+			abortWithError(c, http.StatusUnauthorized, "Invalid authorization format")
 			return
 		}
 
 		// 3. Validasi Token
 		claims, err := utils.ValidateToken(parts[1])
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			abortWithError(c, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		// 3b. Sesi harus masih aktif (belum logout / dicabut, akun tidak DISABLED)
+		if claims.SessionID == "" {
+			abortWithError(c, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+		session, err := sessions.GetActive(c.Request.Context(), claims.SessionID, claims.UserID)
+		if err != nil {
+			abortWithError(c, http.StatusUnauthorized, "Session has been revoked or expired")
+			return
+		}
+		_ = sessions.Touch(c.Request.Context(), session.ID)
+
+		roles := session.RoleList()
+		perms, err := permissions.Resolve(c.Request.Context(), roles)
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, "Failed to resolve permissions")
 			return
 		}
 
 		// 4. Set Context (Identity Injection)
 		// Simpan identitas ini agar bisa dipakai di Controller/Service nanti
 		c.Set("user_id", claims.UserID)
-		c.Set("role", claims.Role)
+		c.Set("role", claims.Role) // Role utama; grup route menggantinya lewat ActAs
+		c.Set("roles", roles)
+		c.Set("permissions", perms)
+		c.Set("session_id", session.ID)
+		if session.StepUpAt != nil {
+			c.Set("step_up_at", *session.StepUpAt)
+		}
 
 		c.Next() // Lanjut ke handler berikutnya
 	}
 }
 
-// Tambahkan ini di internal/middleware/auth_middleware.go
+// RequirePermission: Route hanya bisa diakses jika sesi memegang SEMUA permission yang disebut
+func RequirePermission(required ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		held := c.GetStringSlice("permissions")
+		for _, perm := range required {
+			if !slices.Contains(held, perm) {
+				abortWithError(c, http.StatusForbidden, "Access denied: missing permission "+perm)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// ActAs menetapkan role yang dipakai handler/service di grup route ini (akun multi-role,
+// mis. team lead bertindak sebagai CS di /api/cs dan sebagai SUPERVISOR di /api/supervisor).
+// Pasang setelah RequirePermission.
+func ActAs(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("role", role)
+		c.Next()
+	}
+}
 
-func EnforceRole(allowedRole string) gin.HandlerFunc {
+// RequireStepUp: Aksi sensitif wajib re-autentikasi TOTP (POST /api/account/step-up) dalam maxAge terakhir
+func RequireStepUp(maxAge time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role := c.GetString("role")
-		if role != allowedRole {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied: Insufficient privileges"})
+		stepUpAt := c.GetTime("step_up_at")
+		if stepUpAt.IsZero() || time.Since(stepUpAt) > maxAge {
+			abortWithError(c, http.StatusForbidden, "Step-up authentication required")
 			return
 		}
 		c.Next()
 	}
 }
-
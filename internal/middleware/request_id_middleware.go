@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// Hanya terima ID dari client yang aman (mencegah log injection)
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{8,128}$`)
+
+// RequestID menerima X-Request-ID dari client atau membuat yang baru,
+// lalu menyimpannya di gin.Context, context.Context (untuk service/repository) dan response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(utils.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// RequestLogger: Pengganti logger bawaan gin agar setiap baris log server membawa Request ID
+func RequestLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		requestID, _ := p.Keys["request_id"].(string)
+		return fmt.Sprintf("[GIN] %s | %s | %3d | %13v | %15s | %-7s %s\n",
+			p.TimeStamp.Format(time.RFC3339),
+			requestID,
+			p.StatusCode,
+			p.Latency,
+			p.ClientIP,
+			p.Method,
+			p.Path,
+		)
+	})
+}
+
+// abortWithError: Response error standar middleware (selalu menyertakan request_id)
+func abortWithError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": message, "request_id": c.GetString("request_id")})
+}
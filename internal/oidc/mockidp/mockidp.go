@@ -0,0 +1,339 @@
+// Package mockidp: IdP OpenID Connect minimal (in-process) untuk integration test & development lokal.
+// Mendukung discovery, authorization code + PKCE S256, ID token RS256 dan JWKS. JANGAN dipakai di production:
+// tidak ada login sungguhan, user dipilih lewat login_hint.
+package mockidp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/syukurgit/zta/internal/oidc"
+)
+
+const (
+	codeTTL    = time.Minute
+	idTokenTTL = 5 * time.Minute
+	keyID      = "mock-1"
+)
+
+// User: Akun di IdP tiruan
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+	AMR           []string // Default: ["pwd"]
+}
+
+// Server: IdP tiruan. Issuer = URL server (Issuer()).
+type Server struct {
+	ClientID     string
+	ClientSecret string
+
+	key    *rsa.PrivateKey
+	issuer string
+	http   *http.Server
+	test   *httptest.Server
+
+	mu    sync.Mutex
+	users []User
+	codes map[string]*authCode
+}
+
+type authCode struct {
+	user        User
+	redirectURI string
+	challenge   string
+	nonce       string
+	expiresAt   time.Time
+}
+
+// New membuat IdP tanpa menjalankannya (pakai Handler / ListenAndServe)
+func New(issuer, clientID, clientSecret string, users ...User) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		users:        users,
+		codes:        make(map[string]*authCode),
+	}, nil
+}
+
+// Start menjalankan IdP di port acak (httptest) - untuk integration test. Panggil Close setelah selesai.
+func Start(clientID, clientSecret string, users ...User) (*Server, error) {
+	s, err := New("", clientID, clientSecret, users...)
+	if err != nil {
+		return nil, err
+	}
+	s.test = httptest.NewServer(s.Handler())
+	s.issuer = s.test.URL
+	return s, nil
+}
+
+// ListenAndServe menjalankan IdP di addr (dipakai cmd/mockidp)
+func (s *Server) ListenAndServe(addr string) error {
+	s.http = &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	return s.http.ListenAndServe()
+}
+
+func (s *Server) Close() {
+	if s.test != nil {
+		s.test.Close()
+	}
+	if s.http != nil {
+		s.http.Close()
+	}
+}
+
+// Issuer: Nilai OIDC_ISSUER untuk aplikasi
+func (s *Server) Issuer() string { return s.issuer }
+
+// AddUser menambah / mengganti (berdasarkan Subject) user IdP, mis. untuk mensimulasikan perubahan grup
+func (s *Server) AddUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].Subject == u.Subject {
+			s.users[i] = u
+			return
+		}
+	}
+	s.users = append(s.users, u)
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.jwks)
+	return mux
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+	})
+}
+
+var chooserTmpl = template.Must(template.New("chooser").Parse(`<!doctype html>
+<title>Mock IdP</title>
+<h1>Mock IdP - pilih akun</h1>
+<ul>{{range .Users}}<li><a href="?{{$.Query}}&login_hint={{.Email}}">{{.Email}}</a> {{.Groups}}</li>{{end}}</ul>`))
+
+// authorize: user dipilih lewat login_hint (email / subject); tanpa hint -> halaman pilihan akun
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	if q.Get("client_id") != s.ClientID || redirectURI == "" {
+		http.Error(w, "unknown client_id or missing redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("response_type") != "code" || q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		redirectError(w, r, redirectURI, q.Get("state"), "invalid_request", "authorization code with PKCE S256 required")
+		return
+	}
+
+	hint := q.Get("login_hint")
+	if hint == "" {
+		s.mu.Lock()
+		users := append([]User(nil), s.users...)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		chooserTmpl.Execute(w, map[string]interface{}{"Users": users, "Query": template.URL(r.URL.RawQuery)})
+		return
+	}
+	user, ok := s.findUser(hint)
+	if !ok {
+		redirectError(w, r, redirectURI, q.Get("state"), "access_denied", "unknown user")
+		return
+	}
+
+	code, err := oidc.RandomString(24)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	s.codes[code] = &authCode{
+		user:        user,
+		redirectURI: redirectURI,
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		expiresAt:   time.Now().Add(codeTTL),
+	}
+	s.mu.Unlock()
+
+	params := url.Values{"code": {code}}
+	if state := q.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	http.Redirect(w, r, withQuery(redirectURI, params), http.StatusFound)
+}
+
+// token: Tukar code (sekali pakai) -> id_token setelah cek client secret, redirect_uri & code_verifier
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	// 1. Autentikasi client (basic atau post)
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.ClientID || subtle.ConstantTimeCompare([]byte(secret), []byte(s.ClientSecret)) != 1 {
+		tokenError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+		return
+	}
+
+	// 2. Code sekali pakai (dihapus walaupun validasi berikutnya gagal)
+	s.mu.Lock()
+	code, found := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+	if !found || time.Now().After(code.expiresAt) {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "unknown or expired code")
+		return
+	}
+	if r.PostForm.Get("redirect_uri") != code.redirectURI {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "redirect_uri mismatch")
+		return
+	}
+	if oidc.S256Challenge(r.PostForm.Get("code_verifier")) != code.challenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	// 3. ID token
+	idToken, err := s.SignIDToken(code.user, code.nonce)
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	accessToken, _ := oidc.RandomString(24)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// SignIDToken menandatangani ID token untuk user (juga berguna untuk menguji verifikasi token secara langsung)
+func (s *Server) SignIDToken(u User, nonce string) (string, error) {
+	amr := u.AMR
+	if len(amr) == 0 {
+		amr = []string{"pwd"}
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            s.issuer,
+		"sub":            u.Subject,
+		"aud":            s.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(idTokenTTL).Unix(),
+		"email":          u.Email,
+		"email_verified": u.EmailVerified,
+		"groups":         u.Groups,
+		"amr":            amr,
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	return token.SignedString(s.key)
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) findUser(hint string) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, hint) || u.Subject == hint {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+func withQuery(rawURL string, params url.Values) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + params.Encode()
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, redirectURI, state, code, description string) {
+	params := url.Values{"error": {code}, "error_description": {description}}
+	if state != "" {
+		params.Set("state", state)
+	}
+	http.Redirect(w, r, withQuery(redirectURI, params), http.StatusFound)
+}
+
+func tokenError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		fmt.Println("mockidp: write response:", err)
+	}
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+// AccessLogRepository hanya bisa menulis (append-only).
+// Tidak ada method baca/update/delete agar Auditor tidak bisa melihat atau mengubah jejaknya sendiri.
+type AccessLogRepository struct {
+	DB *gorm.DB
+}
+
+func NewAccessLogRepository(db *gorm.DB) *AccessLogRepository {
+	return &AccessLogRepository{DB: db}
+}
+
+// CreateAccessLog menyimpan satu jejak bacaan auditor
+func (r *AccessLogRepository) CreateAccessLog(ctx context.Context, entry *domain.AuditAccessLog) error {
+	return r.DB.WithContext(ctx).Create(entry).Error
+}
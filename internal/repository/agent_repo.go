@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+type AgentRepository struct {
+	DB *gorm.DB
+}
+
+func NewAgentRepository(db *gorm.DB) *AgentRepository {
+	return &AgentRepository{DB: db}
+}
+
+// GetProfile mengambil profil agent (+ tim); profil default (OFFLINE, kapasitas default) jika belum ada
+func (r *AgentRepository) GetProfile(ctx context.Context, userID uint) (*domain.AgentProfile, error) {
+	var profile domain.AgentProfile
+	err := r.DB.WithContext(ctx).Preload("Team").First(&profile, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &domain.AgentProfile{UserID: userID, Presence: domain.PresenceOffline}, nil
+	}
+	return &profile, err
+}
+
+// GetProfiles mengambil semua profil agent (untuk Supervisor)
+func (r *AgentRepository) GetProfiles(ctx context.Context) ([]domain.AgentProfile, error) {
+	var profiles []domain.AgentProfile
+	err := r.DB.WithContext(ctx).Preload("User").Preload("Team").Order("user_id asc").Find(&profiles).Error
+	return profiles, err
+}
+
+// SaveProfile membuat/memperbarui profil agent
+func (r *AgentRepository) SaveProfile(ctx context.Context, profile *domain.AgentProfile) error {
+	return r.DB.WithContext(ctx).Omit("User", "Team").Save(profile).Error
+}
+
+// GetAvailableProfiles mengambil agent dengan presence AVAILABLE
+func (r *AgentRepository) GetAvailableProfiles(ctx context.Context) ([]domain.AgentProfile, error) {
+	var profiles []domain.AgentProfile
+	err := r.DB.WithContext(ctx).Preload("Team").Where("presence = ?", domain.PresenceAvailable).Find(&profiles).Error
+	return profiles, err
+}
+
+// CountActiveByAgents menghitung tiket aktif per CS sekaligus (hindari N+1 query saat routing)
+func (r *AgentRepository) CountActiveByAgents(ctx context.Context, csIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		CSID  uint
+		Count int64
+	}
+	err := r.DB.WithContext(ctx).Table("ticket_assignments").
+		Select("ticket_assignments.cs_id AS cs_id, COUNT(*) AS count").
+		Joins("JOIN tickets ON tickets.id = ticket_assignments.ticket_id").
+		Where("ticket_assignments.cs_id IN ? AND tickets.status IN ?", csIDs, domain.ActiveTicketStatuses).
+		Group("ticket_assignments.cs_id").
+		Scan(&rows).Error
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CSID] = row.Count
+	}
+	return counts, err
+}
+
+// TouchLastAssigned memperbarui penanda round-robin
+func (r *AgentRepository) TouchLastAssigned(ctx context.Context, userID uint, at time.Time) error {
+	return r.DB.WithContext(ctx).Model(&domain.AgentProfile{}).
+		Where("user_id = ?", userID).Update("last_assigned_at", at).Error
+}
+
+// GetTeams mengambil semua tim
+func (r *AgentRepository) GetTeams(ctx context.Context) ([]domain.Team, error) {
+	var teams []domain.Team
+	err := r.DB.WithContext(ctx).Order("name asc").Find(&teams).Error
+	return teams, err
+}
+
+// GetTeam mengambil satu tim
+func (r *AgentRepository) GetTeam(ctx context.Context, id uint) (*domain.Team, error) {
+	var team domain.Team
+	err := r.DB.WithContext(ctx).First(&team, id).Error
+	return &team, err
+}
+
+// SaveTeam membuat/memperbarui tim
+func (r *AgentRepository) SaveTeam(ctx context.Context, team *domain.Team) error {
+	return r.DB.WithContext(ctx).Save(team).Error
+}
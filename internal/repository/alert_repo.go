@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+type AlertRepository struct {
+	DB *gorm.DB
+}
+
+func NewAlertRepository(db *gorm.DB) *AlertRepository {
+	return &AlertRepository{DB: db}
+}
+
+// CreateAlert menyimpan alert baru
+func (r *AlertRepository) CreateAlert(ctx context.Context, alert *domain.Alert) error {
+	return r.DB.WithContext(ctx).Create(alert).Error
+}
+
+// GetAlerts mengambil alert terbaru (opsional filter severity)
+func (r *AlertRepository) GetAlerts(ctx context.Context, severity string) ([]domain.Alert, error) {
+	var alerts []domain.Alert
+	query := r.DB.WithContext(ctx).Order("created_at desc")
+	if severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+	err := query.Find(&alerts).Error
+	return alerts, err
+}
+
+// HasRecentAlert mencegah alert yang sama dikirim berulang (dedup per rule + tiket + actor)
+func (r *AlertRepository) HasRecentAlert(ctx context.Context, rule string, ticketID uint, actorHash string, since time.Time) bool {
+	var count int64
+	r.DB.WithContext(ctx).Model(&domain.Alert{}).
+		Where("rule = ? AND ticket_id = ? AND actor_hash = ? AND created_at > ?", rule, ticketID, actorHash, since).
+		Count(&count)
+	return count > 0
+}
+
+// --- Query pendukung rule ---
+
+// CountRecentActions menghitung event AuditLog oleh actor yang sama sejak waktu tertentu
+func (r *AlertRepository) CountRecentActions(ctx context.Context, actorHash, action, result string, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domain.AuditLog{}).
+		Where("actor_hash = ? AND action = ? AND result = ? AND timestamp > ?", actorHash, action, result, since).
+		Count(&count).Error
+	return count, err
+}
+
+// CountVerifiedTicketsForUser menghitung berapa tiket berbeda yang memulai verifikasi untuk user yang sama
+func (r *AlertRepository) CountVerifiedTicketsForUser(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domain.VerificationSession{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Distinct("ticket_id").
+		Count(&count).Error
+	return count, err
+}
+
+// CountUnusedPrivileges menghitung privilege CS pada tiket yang tidak pernah dipakai
+func (r *AlertRepository) CountUnusedPrivileges(ctx context.Context, ticketID uint) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domain.TemporaryPrivilege{}).
+		Where("ticket_id = ? AND cs_id <> 0 AND is_used = ?", ticketID, false).
+		Count(&count).Error
+	return count, err
+}
+
+// GetTicket mengambil tiket (tanpa preload) untuk konteks rule
+func (r *AlertRepository) GetTicket(ctx context.Context, ticketID uint) (*domain.Ticket, error) {
+	var ticket domain.Ticket
+	err := r.DB.WithContext(ctx).First(&ticket, ticketID).Error
+	return &ticket, err
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ArchiveRepository struct {
+	DB *gorm.DB
+}
+
+func NewArchiveRepository(db *gorm.DB) *ArchiveRepository {
+	return &ArchiveRepository{DB: db}
+}
+
+// GetRetentionPolicies mengambil semua kebijakan retensi
+func (r *ArchiveRepository) GetRetentionPolicies(ctx context.Context) ([]domain.RetentionPolicy, error) {
+	var policies []domain.RetentionPolicy
+	err := r.DB.WithContext(ctx).Order("kind, action").Find(&policies).Error
+	return policies, err
+}
+
+// legalHoldTickets: Subquery tiket yang sedang ditahan (legal hold)
+func (r *ArchiveRepository) legalHoldTickets(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Model(&domain.Ticket{}).Select("id").Where("legal_hold = ?", true)
+}
+
+// GetExpiredAuditLogs mengambil log yang melewati retensi (kecuali tiket legal hold).
+// Jika action == "*", semua action KECUALI yang ada di excludeActions ikut terambil.
+func (r *ArchiveRepository) GetExpiredAuditLogs(ctx context.Context, action string, excludeActions []string, cutoff time.Time, limit int) ([]domain.AuditLog, error) {
+	var logs []domain.AuditLog
+	query := r.DB.WithContext(ctx).Where("timestamp < ?", cutoff).
+		Where("ticket_id NOT IN (?)", r.legalHoldTickets(ctx))
+
+	if action == "*" {
+		if len(excludeActions) > 0 {
+			query = query.Where("action NOT IN ?", excludeActions)
+		}
+	} else {
+		query = query.Where("action = ?", action)
+	}
+
+	err := query.Order("id asc").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// ArchivedChat: Baris chat apa adanya di DB (Message tetap ciphertext envelope),
+// agar segmen arsip ikut tak terbaca setelah data key tiket di-shred
+type ArchivedChat struct {
+	ID         uint
+	TicketID   uint
+	SenderID   uint
+	SenderRole string
+	Message    string
+	CreatedAt  time.Time
+}
+
+func (ArchivedChat) TableName() string { return "chats" }
+
+// GetExpiredChats mengambil chat tiket CLOSED yang melewati retensi (kecuali tiket legal hold)
+func (r *ArchiveRepository) GetExpiredChats(ctx context.Context, cutoff time.Time, limit int) ([]ArchivedChat, error) {
+	var chats []ArchivedChat
+	closedTickets := r.DB.WithContext(ctx).Model(&domain.Ticket{}).Select("id").Where("status = ? AND legal_hold = ?", domain.TicketClosed, false)
+
+	err := r.DB.WithContext(ctx).Where("created_at < ?", cutoff).
+		Where("ticket_id IN (?)", closedTickets).
+		Order("id asc").Limit(limit).Find(&chats).Error
+	return chats, err
+}
+
+// GetLastSegment mengambil ujung hash chain (nil jika belum ada segment)
+func (r *ArchiveRepository) GetLastSegment(ctx context.Context) (*domain.ArchiveSegment, error) {
+	var segment domain.ArchiveSegment
+	err := r.DB.WithContext(ctx).Order("id desc").First(&segment).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &segment, err
+}
+
+// SaveSegmentAndPurge mencatat segment lalu menghapus baris yang sudah diarsip (atomic)
+func (r *ArchiveRepository) SaveSegmentAndPurge(ctx context.Context, segment *domain.ArchiveSegment, model interface{}, ids []uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(segment).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(model).Error
+	})
+}
+
+// GetSegments mengambil semua segment berurutan sesuai hash chain
+func (r *ArchiveRepository) GetSegments(ctx context.Context) ([]domain.ArchiveSegment, error) {
+	var segments []domain.ArchiveSegment
+	err := r.DB.WithContext(ctx).Order("id asc").Find(&segments).Error
+	return segments, err
+}
+
+// GetSegmentByID mengambil satu segment
+func (r *ArchiveRepository) GetSegmentByID(ctx context.Context, id uint) (*domain.ArchiveSegment, error) {
+	var segment domain.ArchiveSegment
+	err := r.DB.WithContext(ctx).First(&segment, id).Error
+	return &segment, err
+}
+
+// RestoreRows memasukkan kembali baris arsip ke tabel asal (ID asli dipertahankan, duplikat dilewati)
+func (r *ArchiveRepository) RestoreRows(ctx context.Context, rows interface{}) error {
+	return r.DB.WithContext(ctx).Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(rows).Error
+}
+
+// SetLegalHold mengubah flag legal hold tiket
+func (r *ArchiveRepository) SetLegalHold(ctx context.Context, ticketID uint, hold bool) error {
+	return r.DB.WithContext(ctx).Model(&domain.Ticket{}).Where("id = ?", ticketID).Update("legal_hold", hold).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"slices"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository struct {
+	DB *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{DB: db}
+}
+
+// GetAttachment mengambil metadata lampiran
+func (r *AttachmentRepository) GetAttachment(ctx context.Context, id uint) (*domain.Attachment, error) {
+	var attachment domain.Attachment
+	err := r.DB.WithContext(ctx).First(&attachment, id).Error
+	return &attachment, err
+}
+
+// DeleteByTicket menghapus metadata lampiran tiket (dipakai saat crypto-shredding) dan mengembalikan
+// blob key yang tidak lagi dipakai tiket lain (blob di-dedup berdasarkan hash konten)
+func (r *AttachmentRepository) DeleteByTicket(ctx context.Context, ticketID uint) (int64, []string, error) {
+	var orphaned []string
+	var removed int64
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var keys []string
+		if err := tx.Model(&domain.Attachment{}).Where("ticket_id = ?", ticketID).Distinct().Pluck("blob_key", &keys).Error; err != nil {
+			return err
+		}
+		result := tx.Where("ticket_id = ?", ticketID).Delete(&domain.Attachment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+		if len(keys) == 0 {
+			return nil
+		}
+
+		var shared []string
+		if err := tx.Model(&domain.Attachment{}).Where("blob_key IN ?", keys).Distinct().Pluck("blob_key", &shared).Error; err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !slices.Contains(shared, key) {
+				orphaned = append(orphaned, key)
+			}
+		}
+		return nil
+	})
+	return removed, orphaned, err
+}
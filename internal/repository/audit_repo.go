@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/syukurgit/zta/internal/domain"
 	"gorm.io/gorm"
 )
@@ -14,15 +17,15 @@ func NewAuditRepository(db *gorm.DB) *AuditRepository {
 }
 
 // CreateLog menyimpan jejak aktivitas (Immutable / Gak bisa diedit)
-func (r *AuditRepository) CreateLog(log *domain.AuditLog) error {
-	return r.DB.Create(log).Error
+func (r *AuditRepository) CreateLog(ctx context.Context, log *domain.AuditLog) error {
+	return r.DB.WithContext(ctx).Create(log).Error
 }
 
 // GetAllLogs mengambil semua log untuk dashboard Auditor
-func (r *AuditRepository) GetAllLogs() ([]domain.AuditLog, error) {
+func (r *AuditRepository) GetAllLogs(ctx context.Context) ([]domain.AuditLog, error) {
 	var logs []domain.AuditLog
 	// Urutkan dari yang terbaru
-	err := r.DB.Order("timestamp desc").Find(&logs).Error
+	err := r.DB.WithContext(ctx).Order("timestamp desc").Find(&logs).Error
 	return logs, err
 }
 
@@ -32,10 +35,10 @@ func (r *AuditRepository) GetAllLogs() ([]domain.AuditLog, error) {
 
 // internal/repository/audit_repo.go
 
-func (r *AuditRepository) GetAuditReports() ([]domain.Ticket, error) {
+func (r *AuditRepository) GetAuditReports(ctx context.Context) ([]domain.Ticket, error) {
 	var tickets []domain.Ticket
 	// Mengambil daftar tiket yang memiliki log audit
-	err := r.DB.Preload("User").
+	err := r.DB.WithContext(ctx).Preload("User").
 		Joins("JOIN audit_logs ON audit_logs.ticket_id = tickets.id").
 		Group("tickets.id").
 		Order("tickets.updated_at desc").
@@ -43,8 +46,86 @@ func (r *AuditRepository) GetAuditReports() ([]domain.Ticket, error) {
 	return tickets, err
 }
 
-func (r *AuditRepository) GetLogsByTicket(ticketID uint) ([]domain.AuditLog, error) {
+func (r *AuditRepository) GetLogsByTicket(ctx context.Context, ticketID uint) ([]domain.AuditLog, error) {
 	var logs []domain.AuditLog
-	err := r.DB.Where("ticket_id = ?", ticketID).Order("timestamp asc").Find(&logs).Error
+	err := r.DB.WithContext(ctx).Where("ticket_id = ?", ticketID).Order("timestamp asc").Find(&logs).Error
 	return logs, err
-}
\ No newline at end of file
+}
+
+// --- ANALYTICS (Laporan Agregat Auditor) ---
+
+// CountDeniedByActor menghitung aksi DENIED per pseudonym CS dalam rentang waktu
+func (r *AuditRepository) CountDeniedByActor(ctx context.Context, from, to time.Time) ([]domain.ActorDeniedCount, error) {
+	var rows []domain.ActorDeniedCount
+	err := r.DB.WithContext(ctx).Model(&domain.AuditLog{}).
+		Select("actor_hash, action, COUNT(*) AS count").
+		Where("actor_role = ? AND result = ? AND timestamp BETWEEN ? AND ?", domain.RoleCS, "DENIED", from, to).
+		Group("actor_hash, action").
+		Order("count desc").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CountPolicyViolations menghitung pelanggaran kebijakan (semua Result DENIED) per aksi
+func (r *AuditRepository) CountPolicyViolations(ctx context.Context, from, to time.Time) ([]domain.PolicyViolationCount, error) {
+	var rows []domain.PolicyViolationCount
+	err := r.DB.WithContext(ctx).Model(&domain.AuditLog{}).
+		Select("action, result, COUNT(*) AS count").
+		Where("result = ? AND timestamp BETWEEN ? AND ?", "DENIED", from, to).
+		Group("action, result").
+		Order("count desc").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CountVerificationByStatus menghitung sesi verifikasi per status
+func (r *AuditRepository) CountVerificationByStatus(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.DB.WithContext(ctx).Model(&domain.VerificationSession{}).
+		Select("status, COUNT(*) AS count").
+		Where("created_at BETWEEN ? AND ?", from, to).
+		Group("status").
+		Scan(&rows).Error
+
+	result := make(map[string]int64)
+	for _, row := range rows {
+		result[row.Status] = row.Count
+	}
+	return result, err
+}
+
+// GetUsedPrivileges mengambil privilege CS yang sudah dipakai (untuk hitung latency)
+func (r *AuditRepository) GetUsedPrivileges(ctx context.Context, from, to time.Time) ([]domain.TemporaryPrivilege, error) {
+	var privileges []domain.TemporaryPrivilege
+	err := r.DB.WithContext(ctx).Where("cs_id <> 0 AND is_used = ? AND used_at IS NOT NULL AND granted_at BETWEEN ? AND ?", true, from, to).
+		Find(&privileges).Error
+	return privileges, err
+}
+
+// CountUnusedPrivileges menghitung privilege CS yang belum dipakai.
+// expired=true: sudah lewat ExpiresAt (hangus tanpa dipakai), expired=false: masih aktif.
+func (r *AuditRepository) CountUnusedPrivileges(ctx context.Context, from, to time.Time, expired bool) (int64, error) {
+	var count int64
+	query := r.DB.WithContext(ctx).Model(&domain.TemporaryPrivilege{}).
+		Where("cs_id <> 0 AND is_used = ? AND granted_at BETWEEN ? AND ?", false, from, to)
+	if expired {
+		query = query.Where("expires_at <= ?", time.Now())
+	} else {
+		query = query.Where("expires_at > ?", time.Now())
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// CountClosedWithoutVerification menghitung tiket CLOSED yang tidak pernah lulus verifikasi
+func (r *AuditRepository) CountClosedWithoutVerification(ctx context.Context, from, to time.Time) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domain.Ticket{}).
+		Where("status = ? AND updated_at BETWEEN ? AND ?", domain.TicketClosed, from, to).
+		Where("NOT EXISTS (SELECT 1 FROM verification_sessions vs WHERE vs.ticket_id = tickets.id AND vs.status = ?)", "PASSED").
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+type CannedResponseRepository struct {
+	DB *gorm.DB
+}
+
+func NewCannedResponseRepository(db *gorm.DB) *CannedResponseRepository {
+	return &CannedResponseRepository{DB: db}
+}
+
+// Save membuat / memperbarui canned response
+func (r *CannedResponseRepository) Save(ctx context.Context, response *domain.CannedResponse) error {
+	return r.DB.WithContext(ctx).Save(response).Error
+}
+
+// Get mengambil satu canned response
+func (r *CannedResponseRepository) Get(ctx context.Context, id uint) (*domain.CannedResponse, error) {
+	var response domain.CannedResponse
+	err := r.DB.WithContext(ctx).First(&response, id).Error
+	return &response, err
+}
+
+// Delete menghapus canned response (jejak pemakaian tetap disimpan)
+func (r *CannedResponseRepository) Delete(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Delete(&domain.CannedResponse{}, id).Error
+}
+
+// ListForAgent: Library pribadi CS + library tim-nya; search mencari di judul / shortcut
+func (r *CannedResponseRepository) ListForAgent(ctx context.Context, csID uint, teamID *uint, search string) ([]domain.CannedResponse, error) {
+	var responses []domain.CannedResponse
+	query := r.DB.WithContext(ctx)
+	if teamID != nil {
+		query = query.Where("(scope = ? AND owner_id = ?) OR (scope = ? AND team_id = ?)",
+			domain.CannedScopePersonal, csID, domain.CannedScopeTeam, *teamID)
+	} else {
+		query = query.Where("scope = ? AND owner_id = ?", domain.CannedScopePersonal, csID)
+	}
+	if search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("(title LIKE ? OR shortcut LIKE ?)", pattern, pattern)
+	}
+	err := query.Order("usage_count desc, title asc").Find(&responses).Error
+	return responses, err
+}
+
+// ListTeamResponses: Library tim (teamID nil = semua tim)
+func (r *CannedResponseRepository) ListTeamResponses(ctx context.Context, teamID *uint) ([]domain.CannedResponse, error) {
+	var responses []domain.CannedResponse
+	query := r.DB.WithContext(ctx).Where("scope = ?", domain.CannedScopeTeam)
+	if teamID != nil {
+		query = query.Where("team_id = ?", *teamID)
+	}
+	err := query.Order("team_id asc, title asc").Find(&responses).Error
+	return responses, err
+}
+
+// RecordUsage mencatat pemakaian & menaikkan counter secara atomic
+func (r *CannedResponseRepository) RecordUsage(ctx context.Context, responseID, ticketID, csID uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&domain.CannedResponseUsage{ResponseID: responseID, TicketID: ticketID, CSID: csID}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.CannedResponse{}).Where("id = ?", responseID).Updates(map[string]interface{}{
+			"usage_count":  gorm.Expr("usage_count + 1"),
+			"last_used_at": time.Now(),
+		}).Error
+	})
+}
+
+// GetUsageStats: Pemakaian per canned response dalam rentang waktu (terbanyak dulu)
+func (r *CannedResponseRepository) GetUsageStats(ctx context.Context, from, to time.Time) ([]domain.CannedResponseStats, error) {
+	var stats []domain.CannedResponseStats
+	err := r.DB.WithContext(ctx).Table("canned_response_usages AS u").
+		Select("u.response_id, c.title, c.scope, c.team_id, COUNT(*) AS uses, COUNT(DISTINCT u.cs_id) AS agents, MAX(u.created_at) AS last_used_at").
+		Joins("JOIN canned_responses AS c ON c.id = u.response_id").
+		Where("u.created_at >= ? AND u.created_at < ?", from, to).
+		Group("u.response_id, c.title, c.scope, c.team_id").
+		Order("uses desc").
+		Scan(&stats).Error
+	return stats, err
+}
@@ -1,26 +1,227 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/syukurgit/zta/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChatRepository struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	waiters *chatWaiters // Long-poll: membangunkan request yang menunggu pesan baru
 }
 
+// currentVersion: Lampiran & redaksi milik isi pesan saat ini (milik versi lama hanya lewat GetRevisions)
+var currentVersion = []interface{}{"revision_id IS NULL"}
+
 func NewChatRepository(db *gorm.DB) *ChatRepository {
-	return &ChatRepository{DB: db}
+	return &ChatRepository{DB: db, waiters: newChatWaiters()}
 }
 
 // CreateChat menyimpan pesan baru
-func (r *ChatRepository) CreateChat(chat *domain.Chat) error {
-	return r.DB.Create(chat).Error
+func (r *ChatRepository) CreateChat(ctx context.Context, chat *domain.Chat) error {
+	if err := r.DB.WithContext(ctx).Create(chat).Error; err != nil {
+		return err
+	}
+	r.waiters.notify(chat.TicketID)
+	return nil
 }
 
 // GetChatHistory mengambil semua pesan dalam 1 tiket (urut dari lama ke baru)
-func (r *ChatRepository) GetChatHistory(ticketID uint) ([]domain.Chat, error) {
+func (r *ChatRepository) GetChatHistory(ctx context.Context, ticketID uint) ([]domain.Chat, error) {
 	var chats []domain.Chat
-	err := r.DB.Where("ticket_id = ?", ticketID).Order("created_at asc").Find(&chats).Error
+	err := r.DB.WithContext(ctx).Preload("Attachments", currentVersion...).
+		Where("ticket_id = ?", ticketID).Order("created_at asc").Find(&chats).Error
 	return chats, err
-}
\ No newline at end of file
+}
+
+// ListChatHistory: Riwayat chat per halaman (default urut dari lama ke baru)
+func (r *ChatRepository) ListChatHistory(ctx context.Context, ticketID uint, q domain.ListQuery) (*domain.Page[domain.Chat], error) {
+	query := r.DB.WithContext(ctx).Model(&domain.Chat{}).Where("ticket_id = ?", ticketID)
+	return paginate[domain.Chat](query, q, listSpec{
+		TimeColumn:   "created_at", // Tanpa SearchColumn: isi pesan terenkripsi, tidak bisa di-LIKE
+		SortColumns:  map[string]sortKey{"created_at": {Column: "created_at"}},
+		DefaultOrder: []sortKey{{Column: "created_at"}},
+		TieBreaker:   sortKey{Column: "id"},
+		Preloads:     []string{"Attachments", "Redactions", "Receipts"},
+		PreloadConds: map[string][]interface{}{"Attachments": currentVersion, "Redactions": currentVersion},
+	})
+}
+
+// GetRedaction mengambil satu data tersamarkan (ciphertext) milik tiket
+func (r *ChatRepository) GetRedaction(ctx context.Context, ticketID, redactionID uint) (*domain.ChatRedaction, error) {
+	var redaction domain.ChatRedaction
+	err := r.DB.WithContext(ctx).Where("id = ? AND ticket_id = ?", redactionID, ticketID).First(&redaction).Error
+	return &redaction, err
+}
+
+// GetChat mengambil satu pesan milik tiket
+func (r *ChatRepository) GetChat(ctx context.Context, ticketID, chatID uint) (*domain.Chat, error) {
+	var chat domain.Chat
+	err := r.DB.WithContext(ctx).Where("id = ? AND ticket_id = ?", chatID, ticketID).First(&chat).Error
+	return &chat, err
+}
+
+// ReviseChat menyimpan versi lama ke ChatRevision lalu mengganti isi pesan (atomic).
+// expectedVersion mencegah dua edit paralel saling menimpa.
+func (r *ChatRepository) ReviseChat(ctx context.Context, chat *domain.Chat, expectedVersion int, action, newMessage string, redactions []domain.ChatRedaction, actorID uint) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Kunci pesan & cek versi
+		var current domain.Chat
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, chat.ID).Error; err != nil {
+			return err
+		}
+		if current.Version != expectedVersion || current.RetractedAt != nil {
+			return errors.New("message was changed concurrently, please reload")
+		}
+
+		// 2. Versi lama -> revisi (append-only)
+		revision := &domain.ChatRevision{
+			ChatID:   current.ID,
+			TicketID: current.TicketID,
+			Version:  current.Version,
+			Message:  current.Message,
+			Action:   action,
+			ActorID:  actorID,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		// 2b. Data DLP isi lama (dan lampiran jika ditarik) menjadi milik revisi: tetap tersimpan untuk Auditor,
+		// tidak lagi tampil / bisa dibuka User & CS
+		if err := tx.Model(&domain.ChatRedaction{}).Where("chat_id = ? AND revision_id IS NULL", current.ID).
+			Update("revision_id", revision.ID).Error; err != nil {
+			return err
+		}
+		if action == "RETRACT" {
+			if err := tx.Model(&domain.Attachment{}).Where("chat_id = ? AND revision_id IS NULL", current.ID).
+				Update("revision_id", revision.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		// 3. Update pesan (pakai struct, bukan map, agar serializer enkripsi ikut jalan)
+		now := time.Now()
+		current.Message = newMessage
+		current.Version++
+		current.UpdatedAt = now
+		if action == "RETRACT" {
+			current.RetractedAt = &now
+		} else {
+			current.EditedAt = &now
+		}
+		if err := tx.Model(&current).Select("message", "version", "edited_at", "retracted_at", "updated_at").Updates(&current).Error; err != nil {
+			return err
+		}
+
+		// 4. Redaksi DLP untuk isi baru
+		for i := range redactions {
+			redactions[i].ChatID = current.ID
+		}
+		if len(redactions) > 0 {
+			if err := tx.Create(&redactions).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Preload("Attachments", currentVersion...).Preload("Redactions", currentVersion...).First(chat, current.ID).Error
+	})
+	if err != nil {
+		return err
+	}
+	// Pesan lama berubah -> bangunkan long-poll agar client menerima versi baru
+	r.waiters.notify(chat.TicketID)
+	return nil
+}
+
+// GetRevisions riwayat versi pesan (lama ke baru)
+func (r *ChatRepository) GetRevisions(ctx context.Context, ticketID, chatID uint) ([]domain.ChatRevision, error) {
+	var revisions []domain.ChatRevision
+	err := r.DB.WithContext(ctx).Preload("Attachments").Preload("Redactions").
+		Where("chat_id = ? AND ticket_id = ?", chatID, ticketID).Order("version asc").Find(&revisions).Error
+	return revisions, err
+}
+
+// GetRevisionsByTicket: Semua versi lama pesan di satu tiket (urut pesan lalu versi), untuk transcript
+func (r *ChatRepository) GetRevisionsByTicket(ctx context.Context, ticketID uint) ([]domain.ChatRevision, error) {
+	var revisions []domain.ChatRevision
+	err := r.DB.WithContext(ctx).Preload("Attachments").
+		Where("ticket_id = ?", ticketID).Order("chat_id asc, version asc").Find(&revisions).Error
+	return revisions, err
+}
+
+// MarkDelivered menandai pesan sudah terkirim ke peserta (tidak menimpa waktu sebelumnya)
+func (r *ChatRepository) MarkDelivered(ctx context.Context, userID uint, chatIDs []uint) error {
+	if len(chatIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	receipts := make([]domain.ChatReceipt, len(chatIDs))
+	for i, id := range chatIDs {
+		receipts[i] = domain.ChatReceipt{ChatID: id, UserID: userID, DeliveredAt: &now}
+	}
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chat_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"delivered_at": gorm.Expr("COALESCE(delivered_at, ?)", now)}),
+	}).Create(&receipts).Error
+}
+
+// MarkRead menandai semua pesan pihak lain di tiket sampai upToID sebagai dibaca
+func (r *ChatRepository) MarkRead(ctx context.Context, userID, ticketID, upToID uint) (int, error) {
+	var chatIDs []uint
+	if err := r.DB.WithContext(ctx).Model(&domain.Chat{}).
+		Where("ticket_id = ? AND id <= ? AND sender_id <> ?", ticketID, upToID, userID).
+		Pluck("id", &chatIDs).Error; err != nil || len(chatIDs) == 0 {
+		return 0, err
+	}
+
+	now := time.Now()
+	receipts := make([]domain.ChatReceipt, len(chatIDs))
+	for i, id := range chatIDs {
+		receipts[i] = domain.ChatReceipt{ChatID: id, UserID: userID, DeliveredAt: &now, ReadAt: &now}
+	}
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chat_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"delivered_at": gorm.Expr("COALESCE(delivered_at, ?)", now),
+			"read_at":      gorm.Expr("COALESCE(read_at, ?)", now),
+		}),
+	}).Create(&receipts).Error
+	return len(chatIDs), err
+}
+
+// CountUnread menghitung pesan pihak lain yang belum dibaca peserta, per tiket.
+// tickets: subquery ID tiket milik peserta (tiket User / tiket aktif CS).
+func (r *ChatRepository) CountUnread(ctx context.Context, userID uint, tickets *gorm.DB) ([]domain.UnreadCount, error) {
+	var counts []domain.UnreadCount
+	err := r.DB.WithContext(ctx).Table("chats").
+		Select("chats.ticket_id AS ticket_id, COUNT(*) AS unread").
+		Joins("LEFT JOIN chat_receipts ON chat_receipts.chat_id = chats.id AND chat_receipts.user_id = ?", userID).
+		Where("chats.ticket_id IN (?) AND chats.sender_id <> ? AND chats.retracted_at IS NULL AND chat_receipts.read_at IS NULL", tickets, userID).
+		Group("chats.ticket_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// UserTicketIDs subquery tiket milik User
+func (r *ChatRepository) UserTicketIDs(userID uint) *gorm.DB {
+	return r.DB.Model(&domain.Ticket{}).Select("id").Where("user_id = ?", userID)
+}
+
+// CSTicketIDs subquery tiket aktif yang dipegang CS
+func (r *ChatRepository) CSTicketIDs(csID uint) *gorm.DB {
+	return r.DB.Table("ticket_assignments").Select("ticket_assignments.ticket_id").
+		Joins("JOIN tickets ON tickets.id = ticket_assignments.ticket_id").
+		Where("ticket_assignments.cs_id = ? AND tickets.status IN ?", csID, domain.ActiveTicketStatuses)
+}
+
+// DeleteRedactions menghapus nilai asli DLP milik tiket (dipakai saat crypto-shredding)
+func (r *ChatRepository) DeleteRedactions(ctx context.Context, ticketID uint) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("ticket_id = ?", ticketID).Delete(&domain.ChatRedaction{})
+	return result.RowsAffected, result.Error
+}
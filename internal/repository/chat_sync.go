@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+)
+
+// chatPollInterval: Cek ulang DB saat long-poll, untuk pesan yang masuk lewat instance API lain
+const chatPollInterval = 2 * time.Second
+
+var ErrInvalidChatCursor = errors.New("invalid chat cursor")
+
+// ChatCursor: Posisi sinkronisasi (updated_at, id) pesan terakhir yang sudah diterima client.
+// Pesan yang diedit / ditarik mendapat updated_at baru sehingga ikut terkirim ulang.
+type ChatCursor struct {
+	UpdatedAt time.Time
+	ID        uint
+}
+
+// ChatCursorOf: Cursor setelah pesan ini
+func ChatCursorOf(chat *domain.Chat) ChatCursor {
+	return ChatCursor{UpdatedAt: chat.UpdatedAt, ID: chat.ID}
+}
+
+// String: "<unix mikrodetik>-<id>" (opaque bagi client)
+func (c ChatCursor) String() string {
+	if c.UpdatedAt.IsZero() {
+		return "0-0"
+	}
+	return fmt.Sprintf("%d-%d", c.UpdatedAt.UnixMicro(), c.ID)
+}
+
+// ParseChatCursor membaca cursor dari String; kosong / "0" / "0-0" = dari awal
+func ParseChatCursor(value string) (ChatCursor, error) {
+	if value == "" || value == "0" || value == "0-0" {
+		return ChatCursor{}, nil
+	}
+	micros, id, ok := strings.Cut(value, "-")
+	us, errT := strconv.ParseInt(micros, 10, 64)
+	chatID, errID := strconv.ParseUint(id, 10, 64)
+	if !ok || errT != nil || errID != nil {
+		return ChatCursor{}, ErrInvalidChatCursor
+	}
+	return ChatCursor{UpdatedAt: time.UnixMicro(us), ID: uint(chatID)}, nil
+}
+
+// ChatSince: Titik awal sinkronisasi inkremental.
+// Cursor == nil -> hanya pesan baru (ID > AfterID); Cursor != nil -> pesan baru ATAU diedit / ditarik setelah cursor.
+type ChatSince struct {
+	AfterID uint
+	Cursor  *ChatCursor
+}
+
+// ListChatsAfter: Pesan dengan ID > afterID (urut naik), untuk sinkronisasi inkremental
+func (r *ChatRepository) ListChatsAfter(ctx context.Context, ticketID, afterID uint, limit int) ([]domain.Chat, error) {
+	var chats []domain.Chat
+	err := r.DB.WithContext(ctx).
+		Preload("Attachments", currentVersion...).Preload("Redactions", currentVersion...).Preload("Receipts").
+		Where("ticket_id = ? AND id > ?", ticketID, afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&chats).Error
+	return chats, err
+}
+
+// ListChatsSince: Pesan yang dibuat / diubah setelah cursor (urut updated_at, id), untuk sinkronisasi inkremental
+func (r *ChatRepository) ListChatsSince(ctx context.Context, ticketID uint, cursor ChatCursor, limit int) ([]domain.Chat, error) {
+	var chats []domain.Chat
+	query := r.DB.WithContext(ctx).
+		Preload("Attachments", currentVersion...).Preload("Redactions", currentVersion...).Preload("Receipts").
+		Where("ticket_id = ?", ticketID)
+	if !cursor.UpdatedAt.IsZero() {
+		query = query.Where("updated_at > ? OR (updated_at = ? AND id > ?)", cursor.UpdatedAt, cursor.UpdatedAt, cursor.ID)
+	}
+	err := query.Order("updated_at asc, id asc").Limit(limit).Find(&chats).Error
+	return chats, err
+}
+
+// listSince memilih ListChatsAfter / ListChatsSince sesuai titik awal
+func (r *ChatRepository) listSince(ctx context.Context, ticketID uint, since ChatSince, limit int) ([]domain.Chat, error) {
+	if since.Cursor != nil {
+		return r.ListChatsSince(ctx, ticketID, *since.Cursor, limit)
+	}
+	return r.ListChatsAfter(ctx, ticketID, since.AfterID, limit)
+}
+
+// WaitForChats: Seperti ListChatsAfter / ListChatsSince, tapi jika belum ada pesan baru / berubah request ditahan
+// sampai ada perubahan, wait habis, atau client memutus koneksi.
+func (r *ChatRepository) WaitForChats(ctx context.Context, ticketID uint, since ChatSince, limit int, wait time.Duration) ([]domain.Chat, error) {
+	// 1. Daftar sebagai waiter SEBELUM query pertama agar pesan di antara query & wait tidak terlewat
+	wake, cancel := r.waiters.subscribe(ticketID)
+	defer cancel()
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	poll := time.NewTicker(chatPollInterval)
+	defer poll.Stop()
+
+	for {
+		// 2. Ada pesan baru / berubah -> langsung kembalikan
+		chats, err := r.listSince(ctx, ticketID, since, limit)
+		if err != nil || len(chats) > 0 || wait <= 0 {
+			return chats, err
+		}
+
+		// 3. Tunggu notifikasi / poll berikutnya
+		select {
+		case <-wake:
+		case <-poll.C:
+		case <-timeout.C:
+			return chats, nil
+		case <-ctx.Done():
+			return chats, ctx.Err()
+		}
+	}
+}
+
+// chatWaiters: Registry in-process request long-poll per tiket
+type chatWaiters struct {
+	mu       sync.Mutex
+	byTicket map[uint]map[chan struct{}]struct{}
+}
+
+func newChatWaiters() *chatWaiters {
+	return &chatWaiters{byTicket: make(map[uint]map[chan struct{}]struct{})}
+}
+
+func (w *chatWaiters) subscribe(ticketID uint) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	if w.byTicket[ticketID] == nil {
+		w.byTicket[ticketID] = make(map[chan struct{}]struct{})
+	}
+	w.byTicket[ticketID][ch] = struct{}{}
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		delete(w.byTicket[ticketID], ch)
+		if len(w.byTicket[ticketID]) == 0 {
+			delete(w.byTicket, ticketID)
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *chatWaiters) notify(ticketID uint) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.byTicket[ticketID] {
+		// Non-blocking: satu sinyal tertunda sudah cukup untuk membangunkan waiter
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
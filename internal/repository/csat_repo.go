@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+type CSATRepository struct {
+	DB *gorm.DB
+}
+
+func NewCSATRepository(db *gorm.DB) *CSATRepository {
+	return &CSATRepository{DB: db}
+}
+
+// Create menyimpan survei baru
+func (r *CSATRepository) Create(ctx context.Context, survey *domain.CSATSurvey) error {
+	return r.DB.WithContext(ctx).Create(survey).Error
+}
+
+// GetByToken mengambil survei dari token link
+func (r *CSATRepository) GetByToken(ctx context.Context, token string) (*domain.CSATSurvey, error) {
+	var survey domain.CSATSurvey
+	err := r.DB.WithContext(ctx).Where("token = ?", token).First(&survey).Error
+	return &survey, err
+}
+
+// Submit menyimpan penilaian hanya jika survei masih PENDING & belum kedaluwarsa.
+// false = link sudah dipakai / kedaluwarsa (aman untuk submit paralel).
+func (r *CSATRepository) Submit(ctx context.Context, survey *domain.CSATSurvey) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(survey).
+		Where("status = ? AND expires_at > ?", domain.CSATPending, time.Now()).
+		Select("status", "rating", "comment", "submitted_at").
+		Updates(survey)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ExpirePending menghanguskan survei tiket yang belum diisi (mis. tiket dibuka kembali)
+func (r *CSATRepository) ExpirePending(ctx context.Context, ticketID uint) (int64, error) {
+	result := r.DB.WithContext(ctx).Model(&domain.CSATSurvey{}).
+		Where("ticket_id = ? AND status = ?", ticketID, domain.CSATPending).
+		Update("status", domain.CSATExpired)
+	return result.RowsAffected, result.Error
+}
+
+// --- LAPORAN ---
+
+// CountByStatus menghitung survei yang dikirim dalam rentang waktu per status
+func (r *CSATRepository) CountByStatus(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.DB.WithContext(ctx).Model(&domain.CSATSurvey{}).
+		Select("status, COUNT(*) AS count").
+		Where("created_at BETWEEN ? AND ?", from, to).
+		Group("status").
+		Scan(&rows).Error
+
+	result := make(map[string]int64)
+	for _, row := range rows {
+		result[row.Status] = row.Count
+	}
+	return result, err
+}
+
+// RatingDistribution menghitung jumlah penilaian per rating (1-5)
+func (r *CSATRepository) RatingDistribution(ctx context.Context, from, to time.Time) (map[int]int64, error) {
+	var rows []struct {
+		Rating int
+		Count  int64
+	}
+	err := r.DB.WithContext(ctx).Model(&domain.CSATSurvey{}).
+		Select("rating, COUNT(*) AS count").
+		Where("status = ? AND created_at BETWEEN ? AND ?", domain.CSATSubmitted, from, to).
+		Group("rating").
+		Scan(&rows).Error
+
+	result := make(map[int]int64)
+	for _, row := range rows {
+		result[row.Rating] = row.Count
+	}
+	return result, err
+}
+
+// GroupStats: Rata-rata rating per kolom (cs_hash / category)
+func (r *CSATRepository) GroupStats(ctx context.Context, from, to time.Time, column string) ([]domain.CSATGroupStats, error) {
+	var rows []domain.CSATGroupStats
+	err := r.DB.WithContext(ctx).Model(&domain.CSATSurvey{}).
+		Select(column+" AS group_key, COUNT(*) AS responses, AVG(rating) AS avg_rating, SUM(CASE WHEN rating >= 4 THEN 1 ELSE 0 END) AS satisfied").
+		Where("status = ? AND created_at BETWEEN ? AND ?", domain.CSATSubmitted, from, to).
+		Group(column).
+		Order("avg_rating desc").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NoteRepository struct {
+	DB *gorm.DB
+}
+
+func NewNoteRepository(db *gorm.DB) *NoteRepository {
+	return &NoteRepository{DB: db}
+}
+
+// CreateNote menyimpan catatan beserta mention-nya dalam satu transaksi
+func (r *NoteRepository) CreateNote(ctx context.Context, note *domain.TicketNote) error {
+	return r.DB.WithContext(ctx).Create(note).Error
+}
+
+// GetNote mengambil satu catatan (+ mention)
+func (r *NoteRepository) GetNote(ctx context.Context, id uint) (*domain.TicketNote, error) {
+	var note domain.TicketNote
+	err := r.DB.WithContext(ctx).Preload("Mentions").First(&note, id).Error
+	return &note, err
+}
+
+// GetNotesByTicket mengambil semua catatan tiket (lama ke baru)
+func (r *NoteRepository) GetNotesByTicket(ctx context.Context, ticketID uint) ([]domain.TicketNote, error) {
+	var notes []domain.TicketNote
+	err := r.DB.WithContext(ctx).Preload("Mentions").
+		Where("ticket_id = ?", ticketID).Order("created_at asc, id asc").Find(&notes).Error
+	return notes, err
+}
+
+// GetRevisionsByTicket mengambil riwayat edit semua catatan tiket
+func (r *NoteRepository) GetRevisionsByTicket(ctx context.Context, ticketID uint) ([]domain.TicketNoteRevision, error) {
+	var revisions []domain.TicketNoteRevision
+	err := r.DB.WithContext(ctx).
+		Joins("JOIN ticket_notes ON ticket_notes.id = ticket_note_revisions.note_id").
+		Where("ticket_notes.ticket_id = ?", ticketID).
+		Order("ticket_note_revisions.created_at asc").Find(&revisions).Error
+	return revisions, err
+}
+
+// GetRevisions mengambil riwayat edit satu catatan
+func (r *NoteRepository) GetRevisions(ctx context.Context, noteID uint) ([]domain.TicketNoteRevision, error) {
+	var revisions []domain.TicketNoteRevision
+	err := r.DB.WithContext(ctx).Where("note_id = ?", noteID).Order("revision asc").Find(&revisions).Error
+	return revisions, err
+}
+
+// UpdateNote menyimpan isi lama sebagai revisi lalu mengganti isi & mention (atomic)
+func (r *NoteRepository) UpdateNote(ctx context.Context, noteID uint, body string, mentionIDs []uint, editorID uint) (*domain.TicketNote, error) {
+	var note domain.TicketNote
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Kunci catatan agar dua edit paralel tidak kehilangan revisi
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&note, noteID).Error; err != nil {
+			return errors.New("note not found")
+		}
+
+		// 2. Simpan isi lama sebagai revisi
+		if err := tx.Create(&domain.TicketNoteRevision{
+			NoteID:   note.ID,
+			TicketID: note.TicketID,
+			Revision: note.Revision,
+			Body:     note.Body,
+			EditedBy: editorID,
+		}).Error; err != nil {
+			return err
+		}
+
+		// 3. Update isi
+		note.Body = body
+		note.Revision++
+		if err := tx.Model(&note).Select("body", "revision").Updates(&note).Error; err != nil { // Struct agar Body lewat serializer
+			return err
+		}
+
+		// 4. Ganti mention
+		if err := tx.Where("note_id = ?", note.ID).Delete(&domain.TicketNoteMention{}).Error; err != nil {
+			return err
+		}
+		note.Mentions = nil
+		for _, id := range mentionIDs {
+			note.Mentions = append(note.Mentions, domain.TicketNoteMention{NoteID: note.ID, MentionedID: id})
+		}
+		if len(note.Mentions) > 0 {
+			return tx.Create(&note.Mentions).Error
+		}
+		return nil
+	})
+	return &note, err
+}
+
+// ListMentions: Catatan yang menyebut agent tertentu (terbaru dulu)
+func (r *NoteRepository) ListMentions(ctx context.Context, agentID uint, q domain.ListQuery) (*domain.Page[domain.TicketNote], error) {
+	query := r.DB.WithContext(ctx).Model(&domain.TicketNote{}).
+		Where("ticket_notes.id IN (?)", r.DB.Model(&domain.TicketNoteMention{}).Select("note_id").Where("mentioned_id = ?", agentID))
+	return paginate[domain.TicketNote](query, q, listSpec{
+		TimeColumn:   "ticket_notes.created_at", // Tanpa SearchColumn: isi catatan terenkripsi, tidak bisa di-LIKE
+		SortColumns:  map[string]sortKey{"created_at": {Column: "ticket_notes.created_at"}, "updated_at": {Column: "ticket_notes.updated_at"}},
+		DefaultOrder: []sortKey{{Column: "ticket_notes.created_at", Desc: true}},
+		TieBreaker:   sortKey{Column: "ticket_notes.id"},
+		Preloads:     []string{"Mentions"},
+	})
+}
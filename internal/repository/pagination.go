@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// sortKey: Satu kolom urutan listing. Nilainya dibaca dari item terakhir untuk keyset cursor.
+type sortKey struct {
+	Column   string // Kolom SQL (boleh dengan prefix tabel); nama setelah titik = kolom di model
+	Expr     string // Opsional: ekspresi urutan dengan %s = kolom / nilai cursor (mis. FIELD(%s, ...))
+	Desc     bool
+	Nullable bool // NULL selalu di akhir (mis. tiket tanpa tenggat SLA)
+}
+
+func (k sortKey) expr(operand string) string {
+	if k.Expr == "" {
+		return operand
+	}
+	return fmt.Sprintf(k.Expr, operand)
+}
+
+// listSpec: Kolom yang dipakai filter & sort generik untuk satu jenis listing
+type listSpec struct {
+	StatusColumn string             // Kosong = filter status tidak berlaku
+	TimeColumn   string             // Kolom untuk from/to
+	SearchColumn string             // Kolom untuk pencarian teks (LIKE)
+	SortColumns  map[string]sortKey // Nama field publik -> kolom SQL (arah dari ?order=)
+	DefaultOrder []sortKey
+	TieBreaker   sortKey                  // Kolom unik terakhir agar halaman stabil (mis. tickets.id asc)
+	Preloads     []string                 // Relasi yang di-preload (setelah COUNT)
+	PreloadConds map[string][]interface{} // Opsional: kondisi preload per relasi
+}
+
+// listCursor: Isi cursor (base64 JSON). Sort ikut disimpan agar cursor tidak dipakai untuk urutan lain.
+type listCursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// paginate menerapkan filter ListQuery, menghitung total, lalu mengambil satu halaman
+func paginate[T any](query *gorm.DB, q domain.ListQuery, spec listSpec) (*domain.Page[T], error) {
+	// 1. Filter
+	if len(q.Status) > 0 && spec.StatusColumn != "" {
+		query = query.Where(spec.StatusColumn+" IN ?", q.Status)
+	}
+	if q.From != nil {
+		query = query.Where(spec.TimeColumn+" >= ?", *q.From)
+	}
+	if q.To != nil {
+		query = query.Where(spec.TimeColumn+" < ?", *q.To)
+	}
+	if q.Search != "" && spec.SearchColumn != "" {
+		query = query.Where(spec.SearchColumn+" LIKE ?", "%"+escapeLike(q.Search)+"%")
+	}
+
+	// 2. Total (sebelum cursor/limit/offset)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	// 3. Urutan: field yang diminta (harus ada di whitelist) atau default listing, diakhiri tie breaker
+	keys := spec.DefaultOrder
+	sortName := ""
+	if q.Sort != "" {
+		key, ok := spec.SortColumns[q.Sort]
+		if !ok {
+			return nil, domain.ErrInvalidSort
+		}
+		key.Desc = q.Desc
+		keys = []sortKey{key}
+		sortName = q.Sort + ":asc"
+		if q.Desc {
+			sortName = q.Sort + ":desc"
+		}
+	}
+	keys = append(append([]sortKey{}, keys...), spec.TieBreaker)
+	for _, key := range keys {
+		if key.Nullable {
+			query = query.Order(key.Column + " IS NULL")
+		}
+		direction := " asc"
+		if key.Desc {
+			direction = " desc"
+		}
+		query = query.Order(key.expr(key.Column) + direction)
+	}
+
+	// 4. Halaman: keyset cursor (setelah item terakhir halaman sebelumnya) atau offset nomor halaman
+	if err := query.Statement.Parse(query.Statement.Model); err != nil {
+		return nil, err
+	}
+	fields, err := sortFields(query.Statement.Schema, keys)
+	if err != nil {
+		return nil, err
+	}
+	if q.Cursor != "" {
+		values, err := decodeListCursor(q.Cursor, sortName, fields)
+		if err != nil {
+			return nil, err
+		}
+		sql, args := keysetAfter(keys, values)
+		query = query.Where(sql, args...)
+	}
+	for _, relation := range spec.Preloads {
+		query = query.Preload(relation, spec.PreloadConds[relation]...)
+	}
+	var items []T
+	if err := query.Offset(q.Offset()).Limit(q.Limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	// 5. Baris ekstra = masih ada halaman berikutnya
+	next := ""
+	if len(items) > q.Limit {
+		items = items[:q.Limit]
+		if next, err = encodeListCursor(query, &items[len(items)-1], sortName, fields); err != nil {
+			return nil, err
+		}
+	}
+	return domain.NewPage(items, total, q.Limit, next), nil
+}
+
+// sortFields mencari field model untuk setiap kolom urutan
+func sortFields(s *schema.Schema, keys []sortKey) ([]*schema.Field, error) {
+	fields := make([]*schema.Field, len(keys))
+	for i, key := range keys {
+		column := key.Column[strings.LastIndex(key.Column, ".")+1:]
+		if fields[i] = s.LookUpField(column); fields[i] == nil {
+			return nil, fmt.Errorf("pagination: %s has no column %s", s.Name, column)
+		}
+	}
+	return fields, nil
+}
+
+// keysetAfter: Kondisi "urutan setelah values" untuk keys (k1 > v1 OR (k1 = v1 AND (k2 > v2 OR ...)))
+func keysetAfter(keys []sortKey, values []interface{}) (string, []interface{}) {
+	key, value := keys[0], values[0]
+	column := key.expr(key.Column)
+	operator := " > "
+	if key.Desc {
+		operator = " < "
+	}
+
+	var after, equal string
+	var afterArgs, equalArgs []interface{}
+	switch {
+	case key.Nullable && isNull(value):
+		// NULL di akhir: tidak ada yang lebih besar, hanya sesama NULL yang dibandingkan kolom berikutnya
+		after, equal = "1 = 0", key.Column+" IS NULL"
+	case key.Nullable:
+		after, afterArgs = "("+key.Column+" IS NULL OR "+column+operator+key.expr("?")+")", []interface{}{value}
+		equal, equalArgs = column+" = "+key.expr("?"), []interface{}{value}
+	default:
+		after, afterArgs = column+operator+key.expr("?"), []interface{}{value}
+		equal, equalArgs = column+" = "+key.expr("?"), []interface{}{value}
+	}
+	if len(keys) == 1 {
+		return after, afterArgs
+	}
+
+	rest, restArgs := keysetAfter(keys[1:], values[1:])
+	args := append(append(afterArgs, equalArgs...), restArgs...)
+	return "(" + after + " OR (" + equal + " AND " + rest + "))", args
+}
+
+func isNull(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// encodeListCursor: Cursor opaque berisi nilai kolom urutan item terakhir
+func encodeListCursor(query *gorm.DB, item interface{}, sortName string, fields []*schema.Field) (string, error) {
+	cursor := listCursor{Sort: sortName}
+	for _, field := range fields {
+		value, _ := field.ValueOf(query.Statement.Context, reflect.ValueOf(item).Elem())
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		cursor.Values = append(cursor.Values, raw)
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeListCursor membaca cursor ke tipe field masing-masing; cursor untuk urutan lain ditolak
+func decodeListCursor(value, sortName string, fields []*schema.Field) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sortName || len(cursor.Values) != len(fields) {
+		return nil, domain.ErrInvalidCursor
+	}
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		target := reflect.New(field.FieldType)
+		if err := json.Unmarshal(cursor.Values[i], target.Interface()); err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		values[i] = target.Elem().Interface()
+	}
+	return values, nil
+}
+
+// escapeLike mencegah karakter wildcard dari input user ikut diinterpretasikan
+func escapeLike(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		if r == '%' || r == '_' || r == '\\' {
+			out = append(out, '\\')
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+type PermissionRepository struct {
+	DB *gorm.DB
+}
+
+func NewPermissionRepository(db *gorm.DB) *PermissionRepository {
+	return &PermissionRepository{DB: db}
+}
+
+// ListRolePermissions mengambil seluruh mapping role -> permission (tabel kecil, di-cache PermissionService)
+func (r *PermissionRepository) ListRolePermissions(ctx context.Context) ([]domain.RolePermission, error) {
+	var rows []domain.RolePermission
+	err := r.DB.WithContext(ctx).Order("role asc, permission asc").Find(&rows).Error
+	return rows, err
+}
+
+// ReplaceRolePermissions mengganti seluruh permission milik satu role dalam satu transaksi
+func (r *PermissionRepository) ReplaceRolePermissions(ctx context.Context, role string, permissions []string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&domain.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+		rows := make([]domain.RolePermission, len(permissions))
+		for i, p := range permissions {
+			rows[i] = domain.RolePermission{Role: role, Permission: p}
+		}
+		return tx.Create(&rows).Error
+	})
+}
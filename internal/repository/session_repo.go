@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+// lastSeenResolution: LastSeenAt hanya diperbarui jika sudah lebih lama dari ini (hemat write per request)
+const lastSeenResolution = time.Minute
+
+type SessionRepository struct {
+	DB *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{DB: db}
+}
+
+// Create menyimpan sesi baru saat login
+func (r *SessionRepository) Create(ctx context.Context, session *domain.UserSession) error {
+	return r.DB.WithContext(ctx).Create(session).Error
+}
+
+// GetActive mengambil sesi yang belum dicabut / kedaluwarsa milik user yang masih ACTIVE
+func (r *SessionRepository) GetActive(ctx context.Context, sessionID string, userID uint) (*domain.UserSession, error) {
+	var session domain.UserSession
+	err := r.DB.WithContext(ctx).
+		Joins("JOIN users ON users.id = user_sessions.user_id AND users.status = ?", domain.UserActive).
+		Where("user_sessions.id = ? AND user_sessions.user_id = ? AND user_sessions.revoked_at IS NULL AND user_sessions.expires_at > ?",
+			sessionID, userID, time.Now()).
+		First(&session).Error
+	return &session, err
+}
+
+// Touch memperbarui LastSeenAt (paling sering sekali per lastSeenResolution)
+func (r *SessionRepository) Touch(ctx context.Context, sessionID string) error {
+	now := time.Now()
+	return r.DB.WithContext(ctx).Model(&domain.UserSession{}).
+		Where("id = ? AND last_seen_at < ?", sessionID, now.Add(-lastSeenResolution)).
+		Update("last_seen_at", now).Error
+}
+
+// MarkStepUp mencatat re-autentikasi TOTP pada sesi
+func (r *SessionRepository) MarkStepUp(ctx context.Context, sessionID string, at time.Time) error {
+	return r.DB.WithContext(ctx).Model(&domain.UserSession{}).Where("id = ?", sessionID).Update("step_up_at", at).Error
+}
+
+// Revoke mencabut satu sesi (logout)
+func (r *SessionRepository) Revoke(ctx context.Context, sessionID string) error {
+	return r.DB.WithContext(ctx).Model(&domain.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser mencabut semua sesi aktif user (disable, ganti role, reset MFA)
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID uint) (int64, error) {
+	result := r.DB.WithContext(ctx).Model(&domain.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// ListByUser: Sesi user terbaru dulu (termasuk yang sudah dicabut / kedaluwarsa)
+func (r *SessionRepository) ListByUser(ctx context.Context, userID uint, limit int) ([]domain.UserSession, error) {
+	var sessions []domain.UserSession
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Find(&sessions).Error
+	return sessions, err
+}
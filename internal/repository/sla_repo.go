@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+type SLARepository struct {
+	DB *gorm.DB
+}
+
+func NewSLARepository(db *gorm.DB) *SLARepository {
+	return &SLARepository{DB: db}
+}
+
+// GetPolicy mencari policy paling spesifik: (kategori, prioritas) lalu (kategori, "*")
+func (r *SLARepository) GetPolicy(ctx context.Context, category, priority string) (*domain.SLAPolicy, error) {
+	var policy domain.SLAPolicy
+	err := r.DB.WithContext(ctx).
+		Where("category = ? AND priority IN ?", category, []string{priority, "*"}).
+		Order("CASE WHEN priority = '*' THEN 1 ELSE 0 END").
+		First(&policy).Error
+	return &policy, err
+}
+
+// GetPolicies mengambil semua policy SLA
+func (r *SLARepository) GetPolicies(ctx context.Context) ([]domain.SLAPolicy, error) {
+	var policies []domain.SLAPolicy
+	err := r.DB.WithContext(ctx).Order("category, priority").Find(&policies).Error
+	return policies, err
+}
+
+// UpsertPolicy membuat atau memperbarui policy berdasarkan (kategori, prioritas)
+func (r *SLARepository) UpsertPolicy(ctx context.Context, policy *domain.SLAPolicy) error {
+	var existing domain.SLAPolicy
+	err := r.DB.WithContext(ctx).Where("category = ? AND priority = ?", policy.Category, policy.Priority).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.DB.WithContext(ctx).Create(policy).Error
+	}
+	if err != nil {
+		return err
+	}
+	policy.ID = existing.ID
+	return r.DB.WithContext(ctx).Model(&existing).Updates(map[string]interface{}{
+		"first_response_minutes": policy.FirstResponseMinutes,
+		"resolution_minutes":     policy.ResolutionMinutes,
+	}).Error
+}
+
+// MarkFirstResponse mencatat respon pertama CS (hanya sekali)
+func (r *SLARepository) MarkFirstResponse(ctx context.Context, ticketID uint, at time.Time, breached bool) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&domain.Ticket{}).
+		Where("id = ? AND first_responded_at IS NULL", ticketID).
+		Updates(map[string]interface{}{
+			"first_responded_at":      at,
+			"first_response_breached": gorm.Expr("first_response_breached OR ?", breached),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Pause menghentikan timer SLA (idempotent)
+func (r *SLARepository) Pause(ctx context.Context, ticketID uint, at time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&domain.Ticket{}).
+		Where("id = ? AND sla_paused_at IS NULL", ticketID).
+		Update("sla_paused_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Resume menjalankan lagi timer SLA dan menggeser deadline penyelesaian sebesar durasi jeda
+func (r *SLARepository) Resume(ctx context.Context, ticketID uint, at time.Time) (time.Duration, error) {
+	var paused time.Duration
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ticket domain.Ticket
+		if err := tx.Where("id = ? AND sla_paused_at IS NOT NULL", ticketID).First(&ticket).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil // Tidak sedang dijeda
+			}
+			return err
+		}
+
+		paused = at.Sub(*ticket.SLAPausedAt)
+		updates := map[string]interface{}{"sla_paused_at": nil}
+		if ticket.ResolutionDueAt != nil {
+			updates["resolution_due_at"] = ticket.ResolutionDueAt.Add(paused)
+		}
+		return tx.Model(&domain.Ticket{}).
+			Where("id = ? AND sla_paused_at = ?", ticketID, ticket.SLAPausedAt).
+			Updates(updates).Error
+	})
+	return paused, err
+}
+
+// FindFirstResponseBreaches: Tiket belum direspon CS dan sudah lewat deadline respon pertama
+func (r *SLARepository) FindFirstResponseBreaches(ctx context.Context, now time.Time) ([]domain.Ticket, error) {
+	var tickets []domain.Ticket
+	err := r.DB.WithContext(ctx).
+		Where("status <> ? AND first_responded_at IS NULL AND first_response_breached = ? AND first_response_due_at < ?", domain.TicketClosed, false, now).
+		Find(&tickets).Error
+	return tickets, err
+}
+
+// FindResolutionBreaches: Tiket belum selesai, tidak sedang dijeda, dan lewat deadline penyelesaian
+func (r *SLARepository) FindResolutionBreaches(ctx context.Context, now time.Time) ([]domain.Ticket, error) {
+	var tickets []domain.Ticket
+	err := r.DB.WithContext(ctx).
+		Where("status <> ? AND sla_paused_at IS NULL AND resolution_breached = ? AND resolution_due_at < ?", domain.TicketClosed, false, now).
+		Find(&tickets).Error
+	return tickets, err
+}
+
+// MarkBreach menandai pelanggaran SLA; false jika sudah ditandai proses lain
+func (r *SLARepository) MarkBreach(ctx context.Context, ticketID uint, column string) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&domain.Ticket{}).
+		Where("id = ? AND "+column+" = ?", ticketID, false).
+		Update(column, true)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetBreachedTickets untuk dashboard Supervisor
+func (r *SLARepository) GetBreachedTickets(ctx context.Context, includeClosed bool, q domain.ListQuery) (*domain.Page[domain.Ticket], error) {
+	query := r.DB.WithContext(ctx).Model(&domain.Ticket{}).
+		Where("first_response_breached = ? OR resolution_breached = ?", true, true)
+	if !includeClosed {
+		query = query.Where("status <> ?", domain.TicketClosed)
+	}
+	return paginate[domain.Ticket](query, q, ticketListSpec(sortKey{Column: "tickets.resolution_due_at", Nullable: true}))
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrLoginStateInvalid = errors.New("SSO login state is invalid, expired or already used")
+
+type SSORepository struct {
+	DB *gorm.DB
+}
+
+func NewSSORepository(db *gorm.DB) *SSORepository {
+	return &SSORepository{DB: db}
+}
+
+// --- STATE LOGIN (PKCE) ---
+
+// SaveState menyimpan state login baru sekaligus membersihkan state yang sudah kedaluwarsa
+func (r *SSORepository) SaveState(ctx context.Context, state *domain.OIDCLoginState) error {
+	db := r.DB.WithContext(ctx)
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&domain.OIDCLoginState{}).Error; err != nil {
+		return err
+	}
+	return db.Create(state).Error
+}
+
+// ConsumeState mengambil & menghapus state (sekali pakai) dalam satu transaksi
+func (r *SSORepository) ConsumeState(ctx context.Context, stateID string) (*domain.OIDCLoginState, error) {
+	var state domain.OIDCLoginState
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("state = ? AND expires_at > ?", stateID, time.Now()).
+			First(&state).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLoginStateInvalid
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&state).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// --- IDENTITAS IdP ---
+
+// GetIdentity mencari tautan issuer + subject (gorm.ErrRecordNotFound jika belum pernah login SSO)
+func (r *SSORepository) GetIdentity(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+	err := r.DB.WithContext(ctx).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	return &identity, err
+}
+
+// CreateSSOUser: Provisioning just-in-time - user + role tambahan + identitas dalam satu transaksi
+func (r *SSORepository) CreateSSOUser(ctx context.Context, user *domain.User, identity *domain.UserIdentity) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// LinkIdentity menautkan akun staff lokal yang sudah ada ke IdP; password lokal dihapus (login hanya lewat SSO)
+func (r *SSORepository) LinkIdentity(ctx context.Context, userID uint, identity *domain.UserIdentity) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"auth_provider": domain.AuthOIDC, "password_hash": ""}).Error; err != nil {
+			return err
+		}
+		identity.UserID = userID
+		return tx.Create(identity).Error
+	})
+}
+
+// TouchIdentity mencatat waktu login SSO terakhir
+func (r *SSORepository) TouchIdentity(ctx context.Context, identityID uint, at time.Time) error {
+	return r.DB.WithContext(ctx).Model(&domain.UserIdentity{}).Where("id = ?", identityID).Update("last_login_at", at).Error
+}
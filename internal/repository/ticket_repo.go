@@ -1,11 +1,14 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/syukurgit/zta/internal/domain"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TicketRepository struct {
@@ -17,31 +20,96 @@ func NewTicketRepository(db *gorm.DB) *TicketRepository {
 }
 
 // Create menyimpan tiket baru dari User
-func (r *TicketRepository) Create(ticket *domain.Ticket) error {
-	return r.DB.Create(ticket).Error
+func (r *TicketRepository) Create(ctx context.Context, ticket *domain.Ticket) error {
+	return r.DB.WithContext(ctx).Create(ticket).Error
 }
 
 // GetOpenTickets mengambil semua tiket yang belum dikerjakan (untuk Queue CS)
-func (r *TicketRepository) GetOpenTickets() ([]domain.Ticket, error) {
+func (r *TicketRepository) GetOpenTickets(ctx context.Context) ([]domain.Ticket, error) {
 	var tickets []domain.Ticket
 	// Preload User agar CS tahu siapa yang lapor (tapi hanya email/ID)
-	err := r.DB.Preload("User").Where("status = ?", "OPEN").Find(&tickets).Error
+	// Urut berdasarkan deadline SLA terdekat, lalu prioritas tertinggi
+	err := r.DB.WithContext(ctx).Preload("User").Where("status = ?", domain.TicketOpen).
+		Order("first_response_due_at IS NULL, first_response_due_at asc").
+		Order("FIELD(priority, 'URGENT', 'HIGH', 'MEDIUM', 'LOW')").
+		Order("created_at asc").
+		Find(&tickets).Error
 	return tickets, err
 }
 
+// ticketPriorityKey: Urutan prioritas URGENT -> LOW (bukan urutan enum)
+var ticketPriorityKey = sortKey{Column: "tickets.priority", Expr: "FIELD(%s, 'URGENT', 'HIGH', 'MEDIUM', 'LOW')"}
+
+// ticketListSpec: Filter & sort yang diizinkan untuk semua listing tiket
+func ticketListSpec(defaultOrder ...sortKey) listSpec {
+	return listSpec{
+		StatusColumn: "tickets.status",
+		TimeColumn:   "tickets.created_at",
+		SearchColumn: "tickets.subject",
+		SortColumns: map[string]sortKey{
+			"created_at":            {Column: "tickets.created_at"},
+			"updated_at":            {Column: "tickets.updated_at"},
+			"priority":              ticketPriorityKey,
+			"first_response_due_at": {Column: "tickets.first_response_due_at", Nullable: true},
+			"resolution_due_at":     {Column: "tickets.resolution_due_at", Nullable: true},
+		},
+		DefaultOrder: defaultOrder,
+		TieBreaker:   sortKey{Column: "tickets.id"},
+		Preloads:     []string{"User"},
+	}
+}
+
+// ListOpenTickets: Antrian CS per halaman (default: urutan SLA seperti GetOpenTickets)
+func (r *TicketRepository) ListOpenTickets(ctx context.Context, q domain.ListQuery) (*domain.Page[domain.Ticket], error) {
+	query := r.DB.WithContext(ctx).Model(&domain.Ticket{}).Where("tickets.status = ?", domain.TicketOpen)
+	return paginate[domain.Ticket](query, q, ticketListSpec(
+		sortKey{Column: "tickets.first_response_due_at", Nullable: true},
+		ticketPriorityKey,
+		sortKey{Column: "tickets.created_at"},
+	))
+}
+
+// ListUserTickets: Tiket milik user (terbaru dulu)
+func (r *TicketRepository) ListUserTickets(ctx context.Context, userID uint, q domain.ListQuery) (*domain.Page[domain.Ticket], error) {
+	query := r.DB.WithContext(ctx).Model(&domain.Ticket{}).Where("tickets.user_id = ?", userID)
+	return paginate[domain.Ticket](query, q, ticketListSpec(sortKey{Column: "tickets.created_at", Desc: true}))
+}
+
+// ListCSTickets: Tiket yang di-assign ke CS dengan status tertentu (terakhir diperbarui dulu)
+func (r *TicketRepository) ListCSTickets(ctx context.Context, csID uint, statuses []string, q domain.ListQuery) (*domain.Page[domain.Ticket], error) {
+	query := r.DB.WithContext(ctx).Model(&domain.Ticket{}).
+		Joins("JOIN ticket_assignments ON ticket_assignments.ticket_id = tickets.id").
+		Where("ticket_assignments.cs_id = ? AND tickets.status IN ?", csID, statuses)
+	return paginate[domain.Ticket](query, q, ticketListSpec(sortKey{Column: "tickets.updated_at", Desc: true}))
+}
+
 // GetByID mengambil detail tiket
-func (r *TicketRepository) GetByID(id uint) (*domain.Ticket, error) {
+func (r *TicketRepository) GetByID(ctx context.Context, id uint) (*domain.Ticket, error) {
 	var ticket domain.Ticket
-	err := r.DB.Preload("User").First(&ticket, id).Error
+	err := r.DB.WithContext(ctx).Preload("User").First(&ticket, id).Error
 	return &ticket, err
 }
 
 // AssignTicketToCS menangani logika "Claim" dengan transaksi aman
-func (r *TicketRepository) AssignTicketToCS(ticketID, csID uint) error {
-	return r.DB.Transaction(func(tx *gorm.DB) error {
+func (r *TicketRepository) AssignTicketToCS(ctx context.Context, ticketID, csID uint) error {
+	return r.assignOpenTicket(ctx, ticketID, csID, "CLAIM", csID, domain.RoleCS)
+}
+
+// AutoAssignTicket dipakai routing otomatis (actor SYSTEM)
+func (r *TicketRepository) AutoAssignTicket(ctx context.Context, ticketID, csID uint) error {
+	return r.assignOpenTicket(ctx, ticketID, csID, "AUTO_ROUTE", 0, domain.RoleSystem)
+}
+
+func (r *TicketRepository) assignOpenTicket(ctx context.Context, ticketID, csID uint, kind string, assignedBy uint, assignedByRole string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 0. Kunci kapasitas CS (claim paralel oleh CS yang sama harus antre di sini)
+		if err := reserveAgentCapacity(tx, csID); err != nil {
+			return err
+		}
+
 		// 1. Cek apakah tiket masih OPEN? (PENTING: Mencegah race condition)
 		var ticket domain.Ticket
-		if err := tx.Where("id = ? AND status = ?", ticketID, "OPEN").First(&ticket).Error; err != nil {
+		if err := tx.Where("id = ? AND status = ?", ticketID, domain.TicketOpen).First(&ticket).Error; err != nil {
 			return errors.New("ticket is not available or already taken")
 		}
 
@@ -56,44 +124,189 @@ func (r *TicketRepository) AssignTicketToCS(ticketID, csID uint) error {
 		}
 
 		// 3. Update status tiket jadi IN_PROGRESS
-		if err := tx.Model(&ticket).Update("status", "IN_PROGRESS").Error; err != nil {
+		if err := tx.Model(&ticket).Update("status", domain.TicketInProgress).Error; err != nil {
 			return err
 		}
 
-		return nil
+		// 4. Catat riwayat assignment
+		return tx.Create(&domain.TicketAssignmentHistory{
+			TicketID:       ticketID,
+			ToCSID:         csID,
+			Kind:           kind,
+			AssignedBy:     assignedBy,
+			AssignedByRole: assignedByRole,
+		}).Error
 	})
 }
 
-func (r *TicketRepository) CountActiveTicketsByCS(csID uint) (int64, error) {
-	var count int64
-	// Kita harus join tabel assignment dengan tiket untuk cek statusnya
-	err := r.DB.Table("ticket_assignments").
+// reserveAgentCapacity mengunci baris AgentProfile (SELECT ... FOR UPDATE) lalu menghitung tiket aktif CS
+// di dalam transaksi yang sama. Harus dipanggil sebagai langkah pertama transaksi: snapshot InnoDB
+// baru dibuat pada read non-locking pertama, sehingga hitungan melihat assignment yang baru di-commit.
+func reserveAgentCapacity(tx *gorm.DB, csID uint) error {
+	// 1. Pastikan baris profil ada agar ada yang bisa dikunci
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("User", "Team").
+		Create(&domain.AgentProfile{UserID: csID, Presence: domain.PresenceOffline}).Error; err != nil {
+		return err
+	}
+
+	// 2. Kunci profil
+	var profile domain.AgentProfile
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&profile, "user_id = ?", csID).Error; err != nil {
+		return err
+	}
+	if profile.TeamID != nil {
+		var team domain.Team
+		if err := tx.First(&team, *profile.TeamID).Error; err == nil {
+			profile.Team = &team
+		}
+	}
+
+	// 3. Hitung beban kerja saat ini
+	var active int64
+	if err := tx.Table("ticket_assignments").
 		Joins("JOIN tickets ON tickets.id = ticket_assignments.ticket_id").
-		Where("ticket_assignments.cs_id = ? AND tickets.status = ?", csID, "IN_PROGRESS").
-		Count(&count).Error
-	
-	return count, err
+		Where("ticket_assignments.cs_id = ? AND tickets.status IN ?", csID, domain.ActiveTicketStatuses).
+		Count(&active).Error; err != nil {
+		return err
+	}
+
+	limit := profile.EffectiveCapacity()
+	if active >= int64(limit) {
+		return fmt.Errorf("%w (max %d)", domain.ErrActiveLimitReached, limit)
+	}
+	return nil
 }
 
 // internal/repository/ticket_repo.go
 
-func (r *TicketRepository) UpdateStatus(ticketID uint, status string) error {
-	return r.DB.Model(&domain.Ticket{}).Where("id = ?", ticketID).Update("status", status).Error
+// TransitionStatus memindahkan status secara atomic (WHERE status = from) agar transisi paralel tidak saling timpa
+func (r *TicketRepository) TransitionStatus(ctx context.Context, ticketID uint, from, to string) error {
+	updates := map[string]interface{}{"status": to}
+	if to == domain.TicketClosed {
+		updates["closed_at"] = time.Now()
+	}
+
+	result := r.DB.WithContext(ctx).Model(&domain.Ticket{}).
+		Where("id = ? AND status = ?", ticketID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("ticket status changed concurrently, please retry")
+	}
+	return nil
+}
+
+// GetAssignedCS mengembalikan ID CS yang memegang tiket (0 jika belum di-assign)
+func (r *TicketRepository) GetAssignedCS(ctx context.Context, ticketID uint) (uint, error) {
+	var assignment domain.TicketAssignment
+	err := r.DB.WithContext(ctx).Where("ticket_id = ?", ticketID).First(&assignment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return assignment.CSID, err
+}
+
+// RevokePrivileges menghanguskan semua privilege JIT tiket yang belum dipakai
+func (r *TicketRepository) RevokePrivileges(ctx context.Context, ticketID uint) error {
+	return r.DB.WithContext(ctx).Model(&domain.TemporaryPrivilege{}).
+		Where("ticket_id = ? AND is_used = ? AND expires_at > ?", ticketID, false, time.Now()).
+		Update("expires_at", time.Now()).Error
 }
 
 // internal/repository/ticket_repo.go
 
-func (r *TicketRepository) GetPrivilegeByToken(token string) (*domain.TemporaryPrivilege, error) {
+func (r *TicketRepository) GetPrivilegeByToken(ctx context.Context, token string) (*domain.TemporaryPrivilege, error) {
 	var priv domain.TemporaryPrivilege
-	err := r.DB.Where("token = ? AND action = ? AND is_used = ? AND expires_at > NOW()", 
+	err := r.DB.WithContext(ctx).Where("token = ? AND action = ? AND is_used = ? AND expires_at > NOW()", 
 		token, "USER_SET_PASSWORD", false).First(&priv).Error
 	return &priv, err
 }
 
-func (r *TicketRepository) UpdateUserPassword(userID uint, hashedPassword string) error {
-	return r.DB.Model(&domain.User{}).Where("id = ?", userID).Update("password_hash", hashedPassword).Error
+func (r *TicketRepository) UpdateUserPassword(ctx context.Context, userID uint, hashedPassword string) error {
+	return r.DB.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Update("password_hash", hashedPassword).Error
+}
+
+func (r *TicketRepository) MarkPrivilegeUsed(ctx context.Context, privilegeID uint) error {
+	return r.DB.WithContext(ctx).Model(&domain.TemporaryPrivilege{}).Where("id = ?", privilegeID).
+		Updates(map[string]interface{}{"is_used": true, "used_at": time.Now()}).Error
+}
+
+// TransferAssignment memindahkan tiket ke CS lain secara atomic.
+// Privilege JIT milik CS lama selalu dicabut (tidak pernah dipindah ke CS baru). carryOver hanya menentukan nasib
+// sesi verifikasi PENDING/PASSED: true = tetap berlaku & diikat ke CS baru, false = di-EXPIRED-kan (wajib verifikasi ulang).
+func (r *TicketRepository) TransferAssignment(ctx context.Context, history *domain.TicketAssignmentHistory, carryOver bool) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Kunci assignment & pastikan pemegangnya masih sama (mencegah transfer ganda)
+		var assignment domain.TicketAssignment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("ticket_id = ?", history.TicketID).First(&assignment).Error; err != nil {
+			return errors.New("ticket is not assigned to any CS")
+		}
+		if assignment.CSID != history.FromCSID {
+			return errors.New("ticket assignment changed concurrently, please retry")
+		}
+
+		// 1b. Kunci kapasitas CS tujuan
+		if err := reserveAgentCapacity(tx, history.ToCSID); err != nil {
+			return err
+		}
+
+		// 2. Pindahkan assignment
+		if err := tx.Model(&assignment).Updates(map[string]interface{}{
+			"cs_id":       history.ToCSID,
+			"assigned_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		// 3. Privilege CS lama selalu dicabut (termasuk VIEW_REDACTED yang disetujui Supervisor khusus untuk CS lama)
+		if err := tx.Model(&domain.TemporaryPrivilege{}).
+			Where("ticket_id = ? AND cs_id = ? AND is_used = ? AND expires_at > ?", history.TicketID, history.FromCSID, false, time.Now()).
+			Update("expires_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		// 3b. Sesi verifikasi: dibawa ke CS baru atau dibatalkan
+		sessions := tx.Model(&domain.VerificationSession{}).
+			Where("ticket_id = ? AND status IN ?", history.TicketID, []string{"PENDING", "PASSED"})
+		if carryOver {
+			if err := sessions.Update("cs_id", history.ToCSID).Error; err != nil {
+				return err
+			}
+		} else if err := sessions.Update("status", "EXPIRED").Error; err != nil {
+			return err
+		}
+
+		// 4. Riwayat
+		return tx.Create(history).Error
+	})
+}
+
+// GetAssignmentHistory mengambil riwayat assignment tiket (urut lama ke baru)
+func (r *TicketRepository) GetAssignmentHistory(ctx context.Context, ticketID uint) ([]domain.TicketAssignmentHistory, error) {
+	var history []domain.TicketAssignmentHistory
+	err := r.DB.WithContext(ctx).Where("ticket_id = ?", ticketID).Order("id asc").Find(&history).Error
+	return history, err
+}
+
+// GetUserByID dipakai untuk validasi target transfer
+func (r *TicketRepository) GetUserByID(ctx context.Context, userID uint) (*domain.User, error) {
+	var user domain.User
+	err := r.DB.WithContext(ctx).Preload("ExtraRoles").First(&user, userID).Error
+	return &user, err
 }
 
-func (r *TicketRepository) MarkPrivilegeUsed(privilegeID uint) error {
-	return r.DB.Model(&domain.TemporaryPrivilege{}).Where("id = ?", privilegeID).Update("is_used", true).Error
-}
\ No newline at end of file
+// GetActivePrivilege mengambil privilege JIT yang masih berlaku untuk CS pada tiket
+func (r *TicketRepository) GetActivePrivilege(ctx context.Context, csID, ticketID uint, action string) (*domain.TemporaryPrivilege, error) {
+	var privilege domain.TemporaryPrivilege
+	err := r.DB.WithContext(ctx).Where("cs_id = ? AND ticket_id = ? AND action = ? AND expires_at > ? AND is_used = ?",
+		csID, ticketID, action, time.Now(), false).First(&privilege).Error
+	return &privilege, err
+}
+
+// CreatePrivilege menyimpan privilege JIT baru
+func (r *TicketRepository) CreatePrivilege(ctx context.Context, privilege *domain.TemporaryPrivilege) error {
+	return r.DB.WithContext(ctx).Create(privilege).Error
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"gorm.io/gorm"
+)
+
+type TranscriptRepository struct {
+	DB *gorm.DB
+}
+
+func NewTranscriptRepository(db *gorm.DB) *TranscriptRepository {
+	return &TranscriptRepository{DB: db}
+}
+
+// CreateExport mencatat ekspor transcript
+func (r *TranscriptRepository) CreateExport(ctx context.Context, export *domain.TranscriptExport) error {
+	return r.DB.WithContext(ctx).Create(export).Error
+}
+
+// GetExport mengambil catatan ekspor berdasarkan export ID (UUID di manifest)
+func (r *TranscriptRepository) GetExport(ctx context.Context, exportID string) (*domain.TranscriptExport, error) {
+	var export domain.TranscriptExport
+	err := r.DB.WithContext(ctx).Where("export_id = ?", exportID).First(&export).Error
+	return &export, err
+}
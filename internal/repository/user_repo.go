@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTokenInvalid = errors.New("token is invalid, expired or already used")
+
+type UserRepository struct {
+	DB *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{DB: db}
+}
+
+// GetByEmail mencari user lewat blind index (kolom email terenkripsi)
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	var user domain.User
+	err := r.DB.WithContext(ctx).Preload("ExtraRoles").Where("email_index = ?", utils.BlindIndex(email)).First(&user).Error
+	return &user, err
+}
+
+// Create menyimpan user baru (unique index email_index menolak duplikat)
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	return r.DB.WithContext(ctx).Create(user).Error
+}
+
+// --- KONFIRMASI EMAIL ---
+
+// CreateEmailToken menyimpan token konfirmasi email
+func (r *UserRepository) CreateEmailToken(ctx context.Context, token *domain.EmailVerificationToken) error {
+	return r.DB.WithContext(ctx).Create(token).Error
+}
+
+// CountEmailTokensSince menghitung token yang dibuat untuk user sejak waktu tertentu (batas kirim ulang)
+func (r *UserRepository) CountEmailTokensSince(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domain.EmailVerificationToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ConfirmEmail memakai token (sekali pakai) lalu mengaktifkan user dalam satu transaksi.
+// riskCredit: pengurangan risk score setelah email terbukti dimiliki (tidak di bawah 0).
+func (r *UserRepository) ConfirmEmail(ctx context.Context, token string, riskCredit int) (*domain.User, error) {
+	var user domain.User
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Kunci token agar konfirmasi paralel tidak dobel
+		var record domain.EmailVerificationToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", token, domain.TokenConfirmEmail, time.Now()).
+			First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		// 2. Token hangus
+		now := time.Now()
+		if err := tx.Model(&record).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		// 3. Aktifkan user
+		if err := tx.Model(&domain.User{}).
+			Where("id = ? AND status = ?", record.UserID, domain.UserPendingVerification).
+			Updates(map[string]interface{}{
+				"status":            domain.UserActive,
+				"email_verified_at": now,
+				"risk_score":        gorm.Expr("GREATEST(risk_score - ?, 0)", riskCredit),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.First(&user, record.UserID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResetPendingRegistration: Pendaftaran ulang akun yang belum dikonfirmasi - ganti password & nama, hanguskan link lama
+func (r *UserRepository) ResetPendingRegistration(ctx context.Context, userID uint, passwordHash, displayName string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Struct (bukan map) agar serializer enkripsi DisplayName ikut jalan
+		result := tx.Model(&domain.User{}).
+			Where("id = ? AND status = ?", userID, domain.UserPendingVerification).
+			Select("password_hash", "display_name").
+			Updates(&domain.User{PasswordHash: passwordHash, DisplayName: displayName})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenInvalid // Sudah dikonfirmasi di antara lookup & update
+		}
+		return tx.Model(&domain.EmailVerificationToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, domain.TokenConfirmEmail).
+			Update("used_at", time.Now()).Error
+	})
+}
+
+// --- UNDANGAN AKUN STAFF ---
+
+// GetInviteUser: Pemilik token undangan yang masih berlaku (ErrTokenInvalid jika tidak ada)
+func (r *UserRepository) GetInviteUser(ctx context.Context, token string) (*domain.User, error) {
+	var user domain.User
+	err := r.DB.WithContext(ctx).
+		Joins("JOIN email_verification_tokens t ON t.user_id = users.id").
+		Where("t.token = ? AND t.purpose = ? AND t.used_at IS NULL AND t.expires_at > ? AND users.status = ?",
+			token, domain.TokenStaffInvite, time.Now(), domain.UserPendingVerification).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTokenInvalid
+	}
+	return &user, err
+}
+
+// AcceptInvite memakai token undangan, menyimpan password pilihan penerima & mengaktifkan akun (satu transaksi)
+func (r *UserRepository) AcceptInvite(ctx context.Context, token, passwordHash string) (*domain.User, error) {
+	var user domain.User
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var record domain.EmailVerificationToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", token, domain.TokenStaffInvite, time.Now()).
+			First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&record).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&domain.User{}).
+			Where("id = ? AND status = ?", record.UserID, domain.UserPendingVerification).
+			Updates(map[string]interface{}{"password_hash": passwordHash, "status": domain.UserActive, "email_verified_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenInvalid
+		}
+		return tx.First(&user, record.UserID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByID mengambil user (+ role tambahan)
+func (r *UserRepository) GetByID(ctx context.Context, id uint) (*domain.User, error) {
+	var user domain.User
+	err := r.DB.WithContext(ctx).Preload("ExtraRoles").First(&user, id).Error
+	return &user, err
+}
+
+// UpdateDisplayName mengganti nama tampilan (struct update agar serializer enkripsi ikut jalan)
+func (r *UserRepository) UpdateDisplayName(ctx context.Context, userID uint, displayName string) error {
+	return r.DB.WithContext(ctx).Model(&domain.User{ID: userID}).Select("display_name").
+		Updates(&domain.User{DisplayName: displayName}).Error
+}
+
+// --- AKUN STAFF (ADMIN) ---
+
+var staffListSpec = listSpec{
+	StatusColumn: "users.status",
+	TimeColumn:   "users.created_at",
+	SortColumns: map[string]sortKey{
+		"created_at": {Column: "users.created_at"},
+		// Enum diurutkan per indeks; FIELD agar perbandingan cursor memakai urutan yang sama
+		"role":   {Column: "users.role", Expr: "FIELD(%s, 'USER', 'CS', 'AUDITOR', 'SUPERVISOR', 'ADMIN')"},
+		"status": {Column: "users.status", Expr: "FIELD(%s, 'ACTIVE', 'PENDING_VERIFICATION', 'DISABLED')"},
+	},
+	DefaultOrder: []sortKey{{Column: "users.created_at", Desc: true}},
+	TieBreaker:   sortKey{Column: "users.id", Desc: true},
+}
+
+// ListStaff: Akun non-USER, opsional filter role (utama maupun tambahan).
+// Email terenkripsi, jadi pencarian hanya exact lewat blind index.
+func (r *UserRepository) ListStaff(ctx context.Context, role, email string, q domain.ListQuery) (*domain.Page[domain.User], error) {
+	query := r.DB.WithContext(ctx).Model(&domain.User{}).Where("users.role IN ?", domain.StaffRoles)
+	if role != "" {
+		query = query.Where("users.role = ? OR EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id AND user_roles.role = ?)", role, role)
+	}
+	if email != "" {
+		query = query.Where("users.email_index = ?", utils.BlindIndex(email))
+	}
+	return paginate[domain.User](query, q, staffListSpec)
+}
+
+// ExtraRolesByUser: Role tambahan untuk banyak user sekaligus (listing; paginate tidak bisa Preload)
+func (r *UserRepository) ExtraRolesByUser(ctx context.Context, ids []uint) (map[uint][]domain.UserRole, error) {
+	var rows []domain.UserRole
+	err := r.DB.WithContext(ctx).Where("user_id IN ?", ids).Order("id asc").Find(&rows).Error
+	result := make(map[uint][]domain.UserRole, len(ids))
+	for _, row := range rows {
+		result[row.UserID] = append(result[row.UserID], row)
+	}
+	return result, err
+}
+
+// SetRoles mengganti role utama + seluruh role tambahan user dalam satu transaksi
+func (r *UserRepository) SetRoles(ctx context.Context, userID uint, primary string, extra []string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.User{}).Where("id = ?", userID).Update("role", primary).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.UserRole{}).Error; err != nil {
+			return err
+		}
+		if len(extra) == 0 {
+			return nil
+		}
+		rows := make([]domain.UserRole, len(extra))
+		for i, role := range extra {
+			rows[i] = domain.UserRole{UserID: userID, Role: role}
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// UpdateStatus mengganti status akun (ACTIVE / DISABLED)
+func (r *UserRepository) UpdateStatus(ctx context.Context, userID uint, status string) error {
+	return r.DB.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Update("status", status).Error
+}
+
+// --- MFA (TOTP) ---
+
+// GetMFA mengambil enrollment MFA user (nil jika belum ada)
+func (r *UserRepository) GetMFA(ctx context.Context, userID uint) (*domain.UserMFA, error) {
+	var records []domain.UserMFA
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+// SaveMFA membuat / mengganti enrollment yang belum aktif
+func (r *UserRepository) SaveMFA(ctx context.Context, mfa *domain.UserMFA) error {
+	return r.DB.WithContext(ctx).Save(mfa).Error
+}
+
+// UseMFAStep mencatat time-step kode TOTP yang dipakai; false = kode sudah pernah dipakai (replay).
+// activate=true sekaligus mengaktifkan enrollment.
+func (r *UserRepository) UseMFAStep(ctx context.Context, userID uint, step int64, activate bool) (bool, error) {
+	updates := map[string]interface{}{"last_step": step}
+	if activate {
+		updates["activated_at"] = time.Now()
+	}
+	result := r.DB.WithContext(ctx).Model(&domain.UserMFA{}).
+		Where("user_id = ? AND last_step < ?", userID, step).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteMFA menghapus enrollment (reset oleh ADMIN; user wajib enroll ulang)
+func (r *UserRepository) DeleteMFA(ctx context.Context, userID uint) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.UserMFA{})
+	return result.RowsAffected, result.Error
+}
+
+// MFAEnabled: Himpunan user (dari ids) yang MFA-nya aktif
+func (r *UserRepository) MFAEnabled(ctx context.Context, ids []uint) (map[uint]bool, error) {
+	var enabled []uint
+	err := r.DB.WithContext(ctx).Model(&domain.UserMFA{}).
+		Where("user_id IN ? AND activated_at IS NOT NULL", ids).
+		Pluck("user_id", &enabled).Error
+	result := make(map[uint]bool, len(enabled))
+	for _, id := range enabled {
+		result[id] = true
+	}
+	return result, err
+}
+
+// --- PERSETUJUAN ROLE ---
+
+// CreateRoleRequest menyimpan permintaan pemberian role yang butuh persetujuan
+func (r *UserRepository) CreateRoleRequest(ctx context.Context, req *domain.RoleGrantRequest) error {
+	return r.DB.WithContext(ctx).Create(req).Error
+}
+
+// GetRoleRequest mengambil satu permintaan pemberian role
+func (r *UserRepository) GetRoleRequest(ctx context.Context, id uint) (*domain.RoleGrantRequest, error) {
+	var req domain.RoleGrantRequest
+	err := r.DB.WithContext(ctx).First(&req, id).Error
+	return &req, err
+}
+
+// ListRoleRequests: Permintaan terbaru, opsional filter status
+func (r *UserRepository) ListRoleRequests(ctx context.Context, status string, limit int) ([]domain.RoleGrantRequest, error) {
+	var reqs []domain.RoleGrantRequest
+	query := r.DB.WithContext(ctx).Order("id desc").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&reqs).Error
+	return reqs, err
+}
+
+// DecideRoleRequest menutup permintaan yang masih PENDING; false jika sudah diputuskan lebih dulu (persetujuan paralel)
+func (r *UserRepository) DecideRoleRequest(ctx context.Context, id, adminID uint, status, reason string) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&domain.RoleGrantRequest{}).
+		Where("id = ? AND status = ?", id, domain.RoleGrantPending).
+		Updates(map[string]interface{}{"status": status, "decided_by": adminID, "reason": reason, "decided_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -17,26 +18,33 @@ func NewVerificationRepository(db *gorm.DB) *VerificationRepository {
 }
 
 // GetUserRiskScore mengambil data user untuk pengecekan keamanan
-func (r *VerificationRepository) GetUserByTicket(ticketID uint) (*domain.User, error) {
+func (r *VerificationRepository) GetUserByTicket(ctx context.Context, ticketID uint) (*domain.User, error) {
 	var ticket domain.Ticket
-	if err := r.DB.Preload("User").First(&ticket, ticketID).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Preload("User").First(&ticket, ticketID).Error; err != nil {
 		return nil, err
 	}
 	return &ticket.User, nil
 }
 
 // CountRecentSessions mengecek berapa kali user diverifikasi hari ini (Anti-Brute Force)
-func (r *VerificationRepository) CountRecentSessions(userID uint) (int64, error) {
+func (r *VerificationRepository) CountRecentSessions(ctx context.Context, userID uint) (int64, error) {
 	var count int64
-	err := r.DB.Model(&domain.VerificationSession{}).
+	err := r.DB.WithContext(ctx).Model(&domain.VerificationSession{}).
 		Where("user_id = ? AND created_at > ?", userID, time.Now().Add(-24*time.Hour)).
 		Count(&count).Error
 	return count, err
 }
 
-// GetRandomQuestions memilih 1 pertanyaan dari setiap kategori
-func (r *VerificationRepository) GetSecureQuestionSet() ([]domain.VerificationQuestion, error) {
+// GetRandomQuestions memilih 1 pertanyaan dari setiap kategori.
+// Jika User sudah enroll jawaban pribadi, hanya pertanyaan yang dia jawab saat onboarding yang dipilih.
+func (r *VerificationRepository) GetSecureQuestionSet(ctx context.Context, userID uint) ([]domain.VerificationQuestion, error) {
 	var questions []domain.VerificationQuestion
+
+	var enrolled []uint
+	if err := r.DB.WithContext(ctx).Model(&domain.UserVerificationAnswer{}).
+		Where("user_id = ?", userID).Pluck("question_id", &enrolled).Error; err != nil {
+		return nil, err
+	}
 	
 	// Ambil 1 dari STATIC, 1 dari HISTORY, 1 dari USAGE
 	// Menggunakan Raw SQL untuk random (MySQL specific: ORDER BY RAND())
@@ -45,7 +53,11 @@ func (r *VerificationRepository) GetSecureQuestionSet() ([]domain.VerificationQu
 	for _, cat := range categories {
 		var q domain.VerificationQuestion
 		// Hati-hati: ORDER BY RAND() lambat untuk data jutaan, tapi oke untuk ratusan soal.
-		result := r.DB.Where("category = ?", cat).Order("RAND()").First(&q)
+		query := r.DB.WithContext(ctx).Where("category = ?", cat)
+		if len(enrolled) > 0 {
+			query = query.Where("id IN ?", enrolled)
+		}
+		result := query.Order("RAND()").First(&q)
 		if result.Error == nil {
 			questions = append(questions, q)
 		}
@@ -58,8 +70,8 @@ func (r *VerificationRepository) GetSecureQuestionSet() ([]domain.VerificationQu
 }
 
 // CreateSession menyimpan sesi DAN pertanyaan yang terpilih ke database
-func (r *VerificationRepository) CreateSession(session *domain.VerificationSession, questions []domain.VerificationQuestion) error {
-	return r.DB.Transaction(func(tx *gorm.DB) error {
+func (r *VerificationRepository) CreateSession(ctx context.Context, session *domain.VerificationSession, questions []domain.VerificationQuestion) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. Simpan Sesi
 		if err := tx.Create(session).Error; err != nil {
 			return err
@@ -84,22 +96,22 @@ func (r *VerificationRepository) CreateSession(session *domain.VerificationSessi
 // GetSessionByID mengambil data sesi beserta User-nya (untuk cek risk score/email)
 // internal/repository/verification_repo.go
 
-func (r *VerificationRepository) GetSessionByID(sessionID string) (*domain.VerificationSession, error) {
+func (r *VerificationRepository) GetSessionByID(ctx context.Context, sessionID string) (*domain.VerificationSession, error) {
     var session domain.VerificationSession
     // Error "unsupported relations" muncul di sini jika struct di atas tidak punya field User
-    if err := r.DB.Preload("User").First(&session, "id = ?", sessionID).Error; err != nil {
+    if err := r.DB.WithContext(ctx).Preload("User").First(&session, "id = ?", sessionID).Error; err != nil {
         return nil, err
     }
     return &session, nil
 }
 
 // GetQuestionsBySession mengambil daftar pertanyaan yang SUDAH dipilihkan untuk sesi ini
-func (r *VerificationRepository) GetQuestionsBySession(sessionID string) ([]domain.VerificationQuestion, error) {
+func (r *VerificationRepository) GetQuestionsBySession(ctx context.Context, sessionID string) ([]domain.VerificationQuestion, error) {
 	var attempts []domain.VerificationAttempt
 	var questions []domain.VerificationQuestion
 
 	// 1. Ambil daftar ID pertanyaan dari tabel attempts
-	if err := r.DB.Where("session_id = ?", sessionID).Find(&attempts).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Where("session_id = ?", sessionID).Find(&attempts).Error; err != nil {
 		return nil, err
 	}
 
@@ -109,7 +121,7 @@ func (r *VerificationRepository) GetQuestionsBySession(sessionID string) ([]doma
 		questionIDs[i] = a.QuestionID
 	}
 
-	if err := r.DB.Where("id IN ?", questionIDs).Find(&questions).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Where("id IN ?", questionIDs).Find(&questions).Error; err != nil {
 		return nil, err
 	}
 
@@ -117,8 +129,8 @@ func (r *VerificationRepository) GetQuestionsBySession(sessionID string) ([]doma
 }
 
 // UpdateSessionResult menyimpan hasil akhir: Status Sesi & Log Attempt
-func (r *VerificationRepository) UpdateSessionResult(sessionID string, status string, riskIncrement int) error {
-	return r.DB.Transaction(func(tx *gorm.DB) error {
+func (r *VerificationRepository) UpdateSessionResult(ctx context.Context, sessionID string, status string, riskIncrement int) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. Update Status Sesi (PASSED / FAILED)
 		if err := tx.Model(&domain.VerificationSession{}).Where("id = ?", sessionID).Update("status", status).Error; err != nil {
 			return err
@@ -140,14 +152,45 @@ func (r *VerificationRepository) UpdateSessionResult(sessionID string, status st
 }
 
 // SavePrivilege (JIT) memberikan hak akses sementara ke CS
-func (r *VerificationRepository) SavePrivilege(privilege *domain.TemporaryPrivilege) error {
-	return r.DB.Create(privilege).Error
+func (r *VerificationRepository) SavePrivilege(ctx context.Context, privilege *domain.TemporaryPrivilege) error {
+	return r.DB.WithContext(ctx).Create(privilege).Error
 }
 
-// GetCSByTicket Helper untuk mencari siapa CS yang memegang tiket ini
-func (r *VerificationRepository) GetCSByTicket(ticketID uint) (uint, error) {
-	var assignment domain.TicketAssignment
-	err := r.DB.Where("ticket_id = ?", ticketID).First(&assignment).Error
-	return assignment.CSID, err
+// GetSessionsByTicket semua sesi verifikasi satu tiket (tanpa data User)
+func (r *VerificationRepository) GetSessionsByTicket(ctx context.Context, ticketID uint) ([]domain.VerificationSession, error) {
+	var sessions []domain.VerificationSession
+	err := r.DB.WithContext(ctx).Where("ticket_id = ?", ticketID).Order("created_at asc").Find(&sessions).Error
+	return sessions, err
 }
 
+// --- ENROLLMENT JAWABAN PRIBADI (Onboarding) ---
+
+// ListQuestionBank semua pertanyaan yang bisa dipilih User saat onboarding
+func (r *VerificationRepository) ListQuestionBank(ctx context.Context) ([]domain.VerificationQuestion, error) {
+	var questions []domain.VerificationQuestion
+	err := r.DB.WithContext(ctx).Order("category, id").Find(&questions).Error
+	return questions, err
+}
+
+// GetUserAnswers jawaban pribadi User, dipetakan per QuestionID
+func (r *VerificationRepository) GetUserAnswers(ctx context.Context, userID uint) (map[uint]domain.UserVerificationAnswer, error) {
+	var answers []domain.UserVerificationAnswer
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&answers).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]domain.UserVerificationAnswer, len(answers))
+	for _, a := range answers {
+		result[a.QuestionID] = a
+	}
+	return result, nil
+}
+
+// ReplaceUserAnswers mengganti seluruh jawaban pribadi User (enroll ulang = set baru)
+func (r *VerificationRepository) ReplaceUserAnswers(ctx context.Context, userID uint, answers []domain.UserVerificationAnswer) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.UserVerificationAnswer{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&answers).Error
+	})
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+const (
+	// SessionTTL: Umur sesi login (= umur JWT)
+	SessionTTL = time.Hour
+	// StepUpMaxAge: Aksi sensitif (ADMIN) butuh re-autentikasi TOTP dalam rentang ini
+	StepUpMaxAge = 10 * time.Minute
+	// mfaIssuer: Nama yang tampil di aplikasi authenticator
+	mfaIssuer = "ZTA-CS"
+	// maxDisplayNameLength: Batas nama tampilan (karakter)
+	maxDisplayNameLength = 60
+)
+
+var (
+	ErrMFARequired    = errors.New("MFA code required")
+	ErrMFAInvalid     = errors.New("invalid or already used MFA code")
+	ErrMFANotEnrolled = errors.New("MFA is not enabled for this account")
+	ErrMFAEnrolled    = errors.New("MFA is already enabled; ask an admin to reset it")
+	ErrDisplayName    = fmt.Errorf("display name must be at most %d characters without control characters or braces", maxDisplayNameLength)
+)
+
+// MFAEnrollment: Secret TOTP baru (hanya ditampilkan sekali, saat enroll)
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// SessionToken: Hasil login (JWT + role & permission efektif yang juga dibawa token)
+type SessionToken struct {
+	Token       string
+	Roles       []string
+	Permissions []string
+}
+
+// AccountService: Sesi login, MFA (TOTP) & step-up milik akun yang sedang login
+type AccountService struct {
+	Users       *repository.UserRepository
+	Sessions    *repository.SessionRepository
+	Permissions *PermissionService
+	AuditSvc    *AuditService
+}
+
+func NewAccountService(users *repository.UserRepository, sessions *repository.SessionRepository, permissions *PermissionService, auditSvc *AuditService) *AccountService {
+	return &AccountService{Users: users, Sessions: sessions, Permissions: permissions, AuditSvc: auditSvc}
+}
+
+// StartSession dipanggil setelah password cocok: cek MFA (jika aktif) lalu buat sesi + JWT.
+// user.ExtraRoles harus sudah di-preload.
+func (s *AccountService) StartSession(ctx context.Context, user *domain.User, otp, ip, userAgent string) (*SessionToken, error) {
+	// 1. MFA wajib jika sudah diaktifkan
+	mfa, err := s.Users.GetMFA(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	mfaUsed := false
+	if mfa != nil && mfa.ActivatedAt != nil {
+		if otp == "" {
+			return nil, ErrMFARequired
+		}
+		if err := s.useCode(ctx, mfa, otp, false); err != nil {
+			s.AuditSvc.LogEvent(ctx, user.ID, user.Role, "LOGIN_MFA", "FAILED", "Invalid TOTP code")
+			return nil, err
+		}
+		mfaUsed = true
+	}
+	return s.createSession(ctx, user, mfaUsed, ip, userAgent)
+}
+
+// StartSSOSession dipanggil setelah ID token IdP terverifikasi. MFA ditangani IdP (idpMFA = claim amr berisi "mfa"),
+// jadi TOTP lokal tidak diminta. user.ExtraRoles harus sudah di-preload.
+func (s *AccountService) StartSSOSession(ctx context.Context, user *domain.User, idpMFA bool, ip, userAgent string) (*SessionToken, error) {
+	return s.createSession(ctx, user, idpMFA, ip, userAgent)
+}
+
+// createSession: Sesi + JWT berisi role & permission efektif
+func (s *AccountService) createSession(ctx context.Context, user *domain.User, mfaUsed bool, ip, userAgent string) (*SessionToken, error) {
+	// 1. Permission efektif dari semua role (mapping DB, via cache)
+	roles := user.AllRoles()
+	perms, err := s.Permissions.Resolve(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Sesi (JWT membawa ID sesi)
+	now := time.Now()
+	session := &domain.UserSession{
+		ID:         uuid.New().String(),
+		UserID:     user.ID,
+		Role:       user.Role,
+		Roles:      strings.Join(roles, ","),
+		IPAddress:  ip,
+		UserAgent:  truncate(userAgent, 255),
+		MFAUsed:    mfaUsed,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(SessionTTL),
+	}
+	if err := s.Sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	token, err := utils.GenerateToken(user.ID, user.Role, roles, perms, session.ID, SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionToken{Token: token, Roles: roles, Permissions: perms}, nil
+}
+
+// Logout mencabut sesi saat ini
+func (s *AccountService) Logout(ctx context.Context, sessionID string) error {
+	return s.Sessions.Revoke(ctx, sessionID)
+}
+
+// GetMFAStatus: true jika MFA aktif
+func (s *AccountService) GetMFAStatus(ctx context.Context, userID uint) (bool, error) {
+	mfa, err := s.Users.GetMFA(ctx, userID)
+	return mfa != nil && mfa.ActivatedAt != nil, err
+}
+
+// EnrollMFA membuat secret baru (belum aktif sampai ActivateMFA dengan kode valid)
+func (s *AccountService) EnrollMFA(ctx context.Context, user *domain.User) (*MFAEnrollment, error) {
+	existing, err := s.Users.GetMFA(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ActivatedAt != nil {
+		return nil, ErrMFAEnrolled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Users.SaveMFA(ctx, &domain.UserMFA{UserID: user.ID, Secret: secret}); err != nil {
+		return nil, err
+	}
+	return &MFAEnrollment{Secret: secret, OTPAuthURL: utils.TOTPURI(mfaIssuer, user.Email, secret)}, nil
+}
+
+// ActivateMFA mengkonfirmasi enrollment dengan kode pertama dari aplikasi authenticator
+func (s *AccountService) ActivateMFA(ctx context.Context, userID uint, role, code string) error {
+	mfa, err := s.Users.GetMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil {
+		return ErrMFANotEnrolled
+	}
+	if mfa.ActivatedAt != nil {
+		return ErrMFAEnrolled
+	}
+	if err := s.useCode(ctx, mfa, code, true); err != nil {
+		return err
+	}
+	s.AuditSvc.LogEvent(ctx, userID, role, "MFA_ENABLED", "SUCCESS", "TOTP activated")
+	return nil
+}
+
+// StepUp: Re-autentikasi TOTP pada sesi berjalan (berlaku StepUpMaxAge)
+func (s *AccountService) StepUp(ctx context.Context, userID uint, role, sessionID, code string) (time.Time, error) {
+	mfa, err := s.Users.GetMFA(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if mfa == nil || mfa.ActivatedAt == nil {
+		return time.Time{}, ErrMFANotEnrolled
+	}
+	if err := s.useCode(ctx, mfa, code, false); err != nil {
+		s.AuditSvc.LogEvent(ctx, userID, role, "STEP_UP", "FAILED", "Invalid TOTP code")
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	if err := s.Sessions.MarkStepUp(ctx, sessionID, now); err != nil {
+		return time.Time{}, err
+	}
+	s.AuditSvc.LogEvent(ctx, userID, role, "STEP_UP", "SUCCESS", fmt.Sprintf("Valid for %s", StepUpMaxAge))
+	return now.Add(StepUpMaxAge), nil
+}
+
+// useCode memverifikasi kode & menandai time-step-nya terpakai (kode sama tidak bisa dipakai dua kali)
+func (s *AccountService) useCode(ctx context.Context, mfa *domain.UserMFA, code string, activate bool) error {
+	step, ok := utils.VerifyTOTP(mfa.Secret, code, time.Now())
+	if !ok {
+		return ErrMFAInvalid
+	}
+	fresh, err := s.Users.UseMFAStep(ctx, mfa.UserID, step, activate)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrMFAInvalid
+	}
+	return nil
+}
+
+// SetDisplayName: Akun mengganti nama tampilannya sendiri (dipakai placeholder {{user.name}}; kosong = hapus)
+func (s *AccountService) SetDisplayName(ctx context.Context, userID uint, role, name string) (string, error) {
+	name, err := NormalizeDisplayName(name)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Users.UpdateDisplayName(ctx, userID, name); err != nil {
+		return "", err
+	}
+	s.AuditSvc.LogEvent(ctx, userID, role, "PROFILE_UPDATED", "SUCCESS", "display name changed") // Isi nama tidak dicatat (PII)
+	return name, nil
+}
+
+// NormalizeDisplayName merapikan spasi & menolak nama terlalu panjang, karakter kontrol, atau kurung kurawal
+// (mencegah nama berisi placeholder)
+func NormalizeDisplayName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if utf8.RuneCountInString(name) > maxDisplayNameLength || strings.ContainsAny(name, "{}") {
+		return "", ErrDisplayName
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return "", ErrDisplayName
+		}
+	}
+	return name, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
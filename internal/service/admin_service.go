@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/mail"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxListedSessions: Jumlah sesi terbaru yang ditampilkan per akun
+	MaxListedSessions = 50
+	// MaxListedRoleRequests: Jumlah permintaan pemberian role terbaru yang ditampilkan
+	MaxListedRoleRequests = 100
+	// InviteTTL: Masa berlaku link undangan akun staff
+	InviteTTL = 72 * time.Hour
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrNotStaff        = errors.New("target account is not a staff account")
+	ErrInvalidRole     = errors.New("role must be one of: " + strings.Join(domain.StaffRoles, ", "))
+	ErrSelfAdminister  = errors.New("admins cannot change the role or status of their own account")
+	ErrNoRoles         = errors.New("at least one role is required")
+	ErrIncompatible    = errors.New("roles cannot be held by the same account")
+	ErrSelfApproval    = errors.New("role grants must be approved by another admin (not the requester or the target)")
+	ErrRequestDecided  = errors.New("role request has already been decided")
+	ErrRequestNotFound = errors.New("role request not found")
+)
+
+// AdminService: Manajemen akun staff oleh ADMIN. Setiap aksi dicatat di AuditLog (ticket_id = 0).
+type AdminService struct {
+	Users    *repository.UserRepository
+	Sessions *repository.SessionRepository
+	AuditSvc *AuditService
+	Mailer   mail.Sender // Link undangan akun staff
+}
+
+func NewAdminService(users *repository.UserRepository, sessions *repository.SessionRepository, auditSvc *AuditService, mailer mail.Sender) *AdminService {
+	return &AdminService{Users: users, Sessions: sessions, AuditSvc: auditSvc, Mailer: mailer}
+}
+
+// ListStaff: Akun staff + status MFA
+func (s *AdminService) ListStaff(ctx context.Context, role, email string, q domain.ListQuery) (*domain.Page[domain.StaffAccount], error) {
+	page, err := s.Users.ListStaff(ctx, role, email, q)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(page.Items))
+	for i, u := range page.Items {
+		ids[i] = u.ID
+	}
+	mfa, err := s.Users.MFAEnabled(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := s.Users.ExtraRolesByUser(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]domain.StaffAccount, len(page.Items))
+	for i, u := range page.Items {
+		u.ExtraRoles = extra[u.ID]
+		accounts[i] = staffAccount(&u, mfa[u.ID])
+	}
+	return &domain.Page[domain.StaffAccount]{Items: accounts, Total: page.Total, Limit: page.Limit, NextCursor: page.NextCursor}, nil
+}
+
+// CreateStaff membuat akun staff baru berstatus PENDING_VERIFICATION tanpa password: penerima menetapkan
+// password sendiri lewat link undangan, jadi ADMIN tidak pernah memegang kredensial akun yang dibuatnya.
+// Role yang butuh persetujuan (AUDITOR) -> undangan baru dikirim setelah ADMIN lain menyetujui.
+func (s *AdminService) CreateStaff(ctx context.Context, adminID uint, email, role string) (*domain.StaffAccount, *domain.RoleGrantRequest, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !slices.Contains(domain.StaffRoles, role) {
+		return nil, nil, ErrInvalidRole
+	}
+	if _, err := s.Users.GetByEmail(ctx, email); err == nil {
+		return nil, nil, errors.New("email is already registered")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	user := &domain.User{Email: email, Role: role, Status: domain.UserPendingVerification}
+	if err := s.Users.Create(ctx, user); err != nil {
+		return nil, nil, err
+	}
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_CREATE_USER", "SUCCESS",
+		fmt.Sprintf("user #%d created with role %s", user.ID, role))
+	account := staffAccount(user, false)
+
+	if needsApproval(nil, []string{role}) {
+		req, err := s.requestApproval(ctx, adminID, user.ID, []string{role}, true)
+		return &account, req, err
+	}
+	if err := s.sendInvite(ctx, adminID, user); err != nil {
+		return nil, nil, err
+	}
+	return &account, nil, nil
+}
+
+// SetRoles mengganti seluruh role akun staff; roles[0] menjadi role utama. Sesi lama dicabut (JWT membawa role lama).
+// ADMIN tidak boleh mengubah role akunnya sendiri (mis. memberi dirinya AUDITOR). Penambahan role yang butuh
+// persetujuan tidak langsung berlaku: hasilnya RoleGrantRequest untuk ADMIN lain.
+func (s *AdminService) SetRoles(ctx context.Context, adminID, userID uint, roles []string) (*domain.StaffAccount, *domain.RoleGrantRequest, error) {
+	roles, err := validateStaffRoles(roles)
+	if err != nil {
+		return nil, nil, err
+	}
+	if userID == adminID {
+		s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_CHANGE_ROLE", "DENIED",
+			fmt.Sprintf("self role change to %v", roles))
+		return nil, nil, ErrSelfAdminister
+	}
+	user, err := s.staffUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if needsApproval(user.AllRoles(), roles) {
+		account, err := s.account(ctx, user)
+		if err != nil {
+			return nil, nil, err
+		}
+		req, err := s.requestApproval(ctx, adminID, userID, roles, false)
+		return account, req, err
+	}
+	if err := s.applyRoles(ctx, adminID, user, roles); err != nil {
+		return nil, nil, err
+	}
+	account, err := s.account(ctx, user)
+	return account, nil, err
+}
+
+// ListRoleRequests: Permintaan pemberian role (status kosong = semua)
+func (s *AdminService) ListRoleRequests(ctx context.Context, status string) ([]domain.RoleGrantRequest, error) {
+	return s.Users.ListRoleRequests(ctx, status, MaxListedRoleRequests)
+}
+
+// ApproveRoleRequest: ADMIN kedua (bukan pemohon, bukan target) menyetujui pemberian role
+func (s *AdminService) ApproveRoleRequest(ctx context.Context, adminID, requestID uint) (*domain.StaffAccount, error) {
+	req, user, err := s.pendingRequest(ctx, adminID, requestID, "ADMIN_APPROVE_ROLE")
+	if err != nil {
+		return nil, err
+	}
+	// Validasi ulang: role target bisa berubah sejak permintaan dibuat
+	roles, err := validateStaffRoles(strings.Split(req.Roles, ","))
+	if err != nil {
+		return nil, err
+	}
+	decided, err := s.Users.DecideRoleRequest(ctx, req.ID, adminID, domain.RoleGrantApproved, "")
+	if err != nil {
+		return nil, err
+	}
+	if !decided {
+		return nil, ErrRequestDecided
+	}
+
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_APPROVE_ROLE", "SUCCESS",
+		fmt.Sprintf("request #%d by admin #%d: user #%d -> %v", req.ID, req.RequestedBy, user.ID, roles))
+	if req.NewAccount {
+		if err := s.sendInvite(ctx, adminID, user); err != nil {
+			return nil, err
+		}
+	} else if err := s.applyRoles(ctx, adminID, user, roles); err != nil {
+		return nil, err
+	}
+	return s.account(ctx, user)
+}
+
+// RejectRoleRequest menolak pemberian role; akun baru yang menunggu persetujuan dinonaktifkan
+func (s *AdminService) RejectRoleRequest(ctx context.Context, adminID, requestID uint, reason string) error {
+	req, user, err := s.pendingRequest(ctx, adminID, requestID, "ADMIN_REJECT_ROLE")
+	if err != nil {
+		return err
+	}
+	decided, err := s.Users.DecideRoleRequest(ctx, req.ID, adminID, domain.RoleGrantRejected, truncate(reason, 255))
+	if err != nil {
+		return err
+	}
+	if !decided {
+		return ErrRequestDecided
+	}
+	if req.NewAccount && user.Status == domain.UserPendingVerification {
+		if err := s.Users.UpdateStatus(ctx, user.ID, domain.UserDisabled); err != nil {
+			return err
+		}
+	}
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_REJECT_ROLE", "SUCCESS",
+		fmt.Sprintf("request #%d by admin #%d: user #%d -> %s: %s", req.ID, req.RequestedBy, user.ID, req.Roles, reason))
+	return nil
+}
+
+// SetDisabled menonaktifkan (semua sesi dicabut, login ditolak) atau mengaktifkan kembali akun staff
+func (s *AdminService) SetDisabled(ctx context.Context, adminID, userID uint, disabled bool, reason string) (*domain.StaffAccount, error) {
+	action, status := "ADMIN_ENABLE_USER", domain.UserActive
+	if disabled {
+		action, status = "ADMIN_DISABLE_USER", domain.UserDisabled
+	}
+	if userID == adminID {
+		s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, action, "DENIED", "self status change")
+		return nil, ErrSelfAdminister
+	}
+	user, err := s.staffUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Users.UpdateStatus(ctx, userID, status); err != nil {
+		return nil, err
+	}
+	var revoked int64
+	if disabled {
+		if revoked, err = s.Sessions.RevokeAllForUser(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	user.Status = status
+
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, action, "SUCCESS",
+		fmt.Sprintf("user #%d, %d sessions revoked: %s", userID, revoked, reason))
+	return s.account(ctx, user)
+}
+
+// ResetMFA menghapus enrollment TOTP akun staff; user wajib enroll ulang. Sesi dicabut.
+func (s *AdminService) ResetMFA(ctx context.Context, adminID, userID uint, reason string) error {
+	if _, err := s.staffUser(ctx, userID); err != nil {
+		return err
+	}
+	removed, err := s.Users.DeleteMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	revoked, err := s.Sessions.RevokeAllForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_RESET_MFA", "SUCCESS",
+		fmt.Sprintf("user #%d, enrollment removed: %t, %d sessions revoked: %s", userID, removed > 0, revoked, reason))
+	return nil
+}
+
+// ListSessions: Sesi login terbaru akun staff
+func (s *AdminService) ListSessions(ctx context.Context, adminID, userID uint) ([]domain.UserSession, error) {
+	if _, err := s.staffUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	sessions, err := s.Sessions.ListByUser(ctx, userID, MaxListedSessions)
+	if err != nil {
+		return nil, err
+	}
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_VIEW_SESSIONS", "SUCCESS",
+		fmt.Sprintf("user #%d, %d sessions", userID, len(sessions)))
+	return sessions, nil
+}
+
+// RevokeSessions mencabut semua sesi aktif akun staff (paksa login ulang)
+func (s *AdminService) RevokeSessions(ctx context.Context, adminID, userID uint) (int64, error) {
+	if _, err := s.staffUser(ctx, userID); err != nil {
+		return 0, err
+	}
+	revoked, err := s.Sessions.RevokeAllForUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_REVOKE_SESSIONS", "SUCCESS",
+		fmt.Sprintf("user #%d, %d sessions revoked", userID, revoked))
+	return revoked, nil
+}
+
+// applyRoles menyimpan role baru & mencabut sesi target
+func (s *AdminService) applyRoles(ctx context.Context, adminID uint, user *domain.User, roles []string) error {
+	from := user.AllRoles()
+	if err := s.Users.SetRoles(ctx, user.ID, roles[0], roles[1:]); err != nil {
+		return err
+	}
+	revoked, err := s.Sessions.RevokeAllForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	user.Role, user.ExtraRoles = roles[0], nil
+	for _, role := range roles[1:] {
+		user.ExtraRoles = append(user.ExtraRoles, domain.UserRole{UserID: user.ID, Role: role})
+	}
+
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_CHANGE_ROLE", "SUCCESS",
+		fmt.Sprintf("user #%d: %v -> %v, %d sessions revoked", user.ID, from, roles, revoked))
+	return nil
+}
+
+// needsApproval: true jika perubahan menambahkan role yang butuh persetujuan ADMIN kedua
+func needsApproval(from, to []string) bool {
+	for _, role := range domain.ApprovalRoles {
+		if slices.Contains(to, role) && !slices.Contains(from, role) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AdminService) requestApproval(ctx context.Context, adminID, userID uint, roles []string, newAccount bool) (*domain.RoleGrantRequest, error) {
+	req := &domain.RoleGrantRequest{
+		UserID:      userID,
+		Roles:       strings.Join(roles, ","),
+		NewAccount:  newAccount,
+		Status:      domain.RoleGrantPending,
+		RequestedBy: adminID,
+	}
+	if err := s.Users.CreateRoleRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_CHANGE_ROLE", "PENDING_APPROVAL",
+		fmt.Sprintf("request #%d: user #%d -> %v", req.ID, userID, roles))
+	return req, nil
+}
+
+// pendingRequest: Permintaan PENDING yang boleh diputuskan adminID (bukan pemohon & bukan target)
+func (s *AdminService) pendingRequest(ctx context.Context, adminID, requestID uint, action string) (*domain.RoleGrantRequest, *domain.User, error) {
+	req, err := s.Users.GetRoleRequest(ctx, requestID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Status != domain.RoleGrantPending {
+		return nil, nil, ErrRequestDecided
+	}
+	if adminID == req.RequestedBy || adminID == req.UserID {
+		s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, action, "DENIED", fmt.Sprintf("request #%d: self approval", req.ID))
+		return nil, nil, ErrSelfApproval
+	}
+	user, err := s.staffUser(ctx, req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, user, nil
+}
+
+// sendInvite membuat token undangan sekali pakai & mengirim link ke email staff baru
+func (s *AdminService) sendInvite(ctx context.Context, adminID uint, user *domain.User) error {
+	token := &domain.EmailVerificationToken{
+		UserID:    user.ID,
+		Token:     utils.GenerateRandomToken(64),
+		Purpose:   domain.TokenStaffInvite,
+		ExpiresAt: time.Now().Add(InviteTTL),
+	}
+	if err := s.Users.CreateEmailToken(ctx, token); err != nil {
+		return err
+	}
+
+	inviteURL := fmt.Sprintf("http://localhost:3000/invite/%s", token.Token)
+	if err := s.Mailer.Send(ctx, user.Email, "Undangan akun staff ZTA-CS",
+		"Akun staff telah dibuat untuk Anda. Tetapkan password Anda lewat link berikut (berlaku 72 jam):\n"+inviteURL); err != nil {
+		log.Printf("staff invite mail not sent: %v", err)
+	}
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "STAFF_INVITE_SENT", "SUCCESS",
+		fmt.Sprintf("user #%d, token #%d valid until %s", user.ID, token.ID, token.ExpiresAt.UTC().Format(time.RFC3339)))
+	return nil
+}
+
+// validateStaffRoles: Hanya role staff, tanpa duplikat & tanpa pasangan yang melanggar separation of duties
+func validateStaffRoles(roles []string) ([]string, error) {
+	var result []string
+	for _, role := range roles {
+		role = strings.ToUpper(strings.TrimSpace(role))
+		if !slices.Contains(domain.StaffRoles, role) {
+			return nil, ErrInvalidRole
+		}
+		if !slices.Contains(result, role) {
+			result = append(result, role)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrNoRoles
+	}
+	for _, pair := range domain.IncompatibleRoles {
+		if slices.Contains(result, pair[0]) && slices.Contains(result, pair[1]) {
+			return nil, fmt.Errorf("%w: %s + %s", ErrIncompatible, pair[0], pair[1])
+		}
+	}
+	return result, nil
+}
+
+// staffUser: Target aksi ADMIN harus akun staff (akun USER tidak dikelola lewat API ini)
+func (s *AdminService) staffUser(ctx context.Context, userID uint) (*domain.User, error) {
+	user, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !slices.Contains(domain.StaffRoles, user.Role) {
+		return nil, ErrNotStaff
+	}
+	return user, nil
+}
+
+func (s *AdminService) account(ctx context.Context, user *domain.User) (*domain.StaffAccount, error) {
+	mfa, err := s.Users.MFAEnabled(ctx, []uint{user.ID})
+	if err != nil {
+		return nil, err
+	}
+	account := staffAccount(user, mfa[user.ID])
+	return &account, nil
+}
+
+func staffAccount(user *domain.User, mfaEnabled bool) domain.StaffAccount {
+	return domain.StaffAccount{
+		ID:           user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		Roles:        user.AllRoles(),
+		Status:       user.Status,
+		AuthProvider: user.AuthProvider,
+		MFAEnabled:   mfaEnabled,
+		CreatedAt:    user.CreatedAt,
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+)
+
+// AnomalyRule dievaluasi untuk setiap AuditLog yang baru ditulis.
+// Kembalikan nil jika event tersebut tidak mencurigakan.
+type AnomalyRule interface {
+	Name() string
+	Evaluate(ctx context.Context, entry *domain.AuditLog, repo *repository.AlertRepository) *domain.Alert
+}
+
+type AnomalyService struct {
+	Repo       *repository.AlertRepository
+	Rules      []AnomalyRule
+	WebhookURL string // Opsional: kosong = tidak dikirim ke webhook
+
+	client *http.Client
+}
+
+func NewAnomalyService(repo *repository.AlertRepository, webhookURL string) *AnomalyService {
+	return &AnomalyService{
+		Repo:       repo,
+		Rules:      DefaultAnomalyRules(),
+		WebhookURL: webhookURL,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// DefaultAnomalyRules: Kumpulan rule bawaan beserta ambang batasnya
+func DefaultAnomalyRules() []AnomalyRule {
+	return []AnomalyRule{
+		&RepeatedDeniedResetRule{Threshold: 3, Window: 15 * time.Minute},
+		&VerificationSprayRule{Threshold: 3, Window: 24 * time.Hour},
+		&UnusedPrivilegeRule{},
+		&QuickResetRule{Window: 10 * time.Minute},
+	}
+}
+
+// Evaluate dipasang sebagai hook AuditService (lihat AuditService.OnLog)
+func (s *AnomalyService) Evaluate(ctx context.Context, entry *domain.AuditLog) {
+	for _, rule := range s.Rules {
+		alert := rule.Evaluate(ctx, entry, s.Repo)
+		if alert == nil {
+			continue
+		}
+
+		// Dedup: rule yang sama untuk tiket & actor yang sama cukup sekali per 10 menit
+		if s.Repo.HasRecentAlert(ctx, alert.Rule, alert.TicketID, alert.ActorHash, time.Now().Add(-10*time.Minute)) {
+			continue
+		}
+
+		alert.AuditLogID = entry.ID
+		if err := s.Repo.CreateAlert(ctx, alert); err != nil {
+			log.Printf("[%s] failed to store alert %s: %v", entry.RequestID, alert.Rule, err)
+			continue
+		}
+
+		if s.WebhookURL != "" {
+			go s.dispatch(*alert)
+		}
+	}
+}
+
+// GetAlerts untuk dashboard Auditor
+func (s *AnomalyService) GetAlerts(ctx context.Context, severity string) ([]domain.Alert, error) {
+	return s.Repo.GetAlerts(ctx, severity)
+}
+
+func (s *AnomalyService) dispatch(alert domain.Alert) {
+	body, _ := json.Marshal(alert)
+	resp, err := s.client.Post(s.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("failed to dispatch alert %d to webhook: %v", alert.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("webhook rejected alert %d: status %d", alert.ID, resp.StatusCode)
+	}
+}
+
+// --- RULES ---
+
+// RepeatedDeniedResetRule: CS berulang kali mencoba GENERATE_RESET_LINK tanpa privilege
+type RepeatedDeniedResetRule struct {
+	Threshold int64
+	Window    time.Duration
+}
+
+func (r *RepeatedDeniedResetRule) Name() string { return "REPEATED_DENIED_RESET" }
+
+func (r *RepeatedDeniedResetRule) Evaluate(ctx context.Context, entry *domain.AuditLog, repo *repository.AlertRepository) *domain.Alert {
+	if entry.Action != "GENERATE_RESET_LINK" || entry.Result != "DENIED" {
+		return nil
+	}
+	count, err := repo.CountRecentActions(ctx, entry.ActorHash, entry.Action, entry.Result, time.Now().Add(-r.Window))
+	if err != nil || count < r.Threshold {
+		return nil
+	}
+	return &domain.Alert{
+		Rule:      r.Name(),
+		Severity:  "HIGH",
+		TicketID:  entry.TicketID,
+		ActorHash: entry.ActorHash,
+		Message:   fmt.Sprintf("CS mencoba GENERATE_RESET_LINK tanpa privilege %d kali dalam %s", count, r.Window),
+	}
+}
+
+// VerificationSprayRule: Banyak sesi verifikasi untuk user yang sama dari tiket yang berbeda
+type VerificationSprayRule struct {
+	Threshold int64
+	Window    time.Duration
+}
+
+func (r *VerificationSprayRule) Name() string { return "VERIFICATION_SPRAY" }
+
+func (r *VerificationSprayRule) Evaluate(ctx context.Context, entry *domain.AuditLog, repo *repository.AlertRepository) *domain.Alert {
+	if entry.Action != "START_VERIFICATION" || entry.Result != "SUCCESS" {
+		return nil
+	}
+	ticket, err := repo.GetTicket(ctx, entry.TicketID)
+	if err != nil {
+		return nil
+	}
+	count, err := repo.CountVerifiedTicketsForUser(ctx, ticket.UserID, time.Now().Add(-r.Window))
+	if err != nil || count < r.Threshold {
+		return nil
+	}
+	return &domain.Alert{
+		Rule:      r.Name(),
+		Severity:  "MEDIUM",
+		TicketID:  entry.TicketID,
+		ActorHash: entry.ActorHash,
+		Message:   fmt.Sprintf("User %d menerima sesi verifikasi dari %d tiket berbeda dalam %s", ticket.UserID, count, r.Window),
+	}
+}
+
+// UnusedPrivilegeRule: Tiket ditutup padahal privilege JIT sudah diberikan tapi tidak dipakai
+type UnusedPrivilegeRule struct{}
+
+func (r *UnusedPrivilegeRule) Name() string { return "UNUSED_PRIVILEGE" }
+
+func (r *UnusedPrivilegeRule) Evaluate(ctx context.Context, entry *domain.AuditLog, repo *repository.AlertRepository) *domain.Alert {
+	if entry.Action != "CLOSE_TICKET" || entry.Result != "SUCCESS" {
+		return nil
+	}
+	count, err := repo.CountUnusedPrivileges(ctx, entry.TicketID)
+	if err != nil || count == 0 {
+		return nil
+	}
+	return &domain.Alert{
+		Rule:      r.Name(),
+		Severity:  "LOW",
+		TicketID:  entry.TicketID,
+		ActorHash: entry.ActorHash,
+		Message:   fmt.Sprintf("Tiket ditutup dengan %d privilege JIT yang tidak pernah dipakai", count),
+	}
+}
+
+// QuickResetRule: Password di-reset sangat cepat setelah tiket dibuat (indikasi social engineering)
+type QuickResetRule struct {
+	Window time.Duration
+}
+
+func (r *QuickResetRule) Name() string { return "QUICK_PASSWORD_RESET" }
+
+func (r *QuickResetRule) Evaluate(ctx context.Context, entry *domain.AuditLog, repo *repository.AlertRepository) *domain.Alert {
+	if entry.Action != "SET_NEW_PASSWORD" || entry.Result != "SUCCESS" {
+		return nil
+	}
+	ticket, err := repo.GetTicket(ctx, entry.TicketID)
+	if err != nil {
+		return nil
+	}
+	elapsed := time.Since(ticket.CreatedAt)
+	if elapsed > r.Window {
+		return nil
+	}
+	return &domain.Alert{
+		Rule:      r.Name(),
+		Severity:  "HIGH",
+		TicketID:  entry.TicketID,
+		ActorHash: entry.ActorHash,
+		Message:   fmt.Sprintf("Password di-reset %s setelah tiket dibuat", elapsed.Round(time.Second)),
+	}
+}
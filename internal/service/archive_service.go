@@ -0,0 +1,378 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/envelope"
+	"github.com/syukurgit/zta/internal/repository"
+)
+
+// genesisHash: PrevHash untuk segment pertama dalam chain
+var genesisHash = strings.Repeat("0", 64)
+
+const archiveBatchSize = 5000
+
+type ArchiveService struct {
+	Repo     *repository.ArchiveRepository
+	AuditSvc *AuditService
+	Keyring  *envelope.Keyring // Chat diarsipkan tetap terenkripsi, dibuka saat query
+	Dir      string            // Folder lokal tempat segment file disimpan
+}
+
+func NewArchiveService(repo *repository.ArchiveRepository, auditSvc *AuditService, keyring *envelope.Keyring, dir string) *ArchiveService {
+	if dir == "" {
+		dir = "./archive"
+	}
+	return &ArchiveService{Repo: repo, AuditSvc: auditSvc, Keyring: keyring, Dir: dir}
+}
+
+// segmentHeader: Baris pertama tiap file, agar chain bisa diverifikasi dari disk saja
+type segmentHeader struct {
+	Kind          string `json:"kind"`
+	PrevHash      string `json:"prev_hash"`
+	PayloadSHA256 string `json:"payload_sha256"`
+	Hash          string `json:"hash"`
+	RowCount      int    `json:"row_count"`
+}
+
+// ArchiveQueryResult: Isi segment hasil query (hanya salah satu slice yang terisi sesuai Kind)
+type ArchiveQueryResult struct {
+	Segment   domain.ArchiveSegment `json:"segment"`
+	AuditLogs []domain.AuditLog     `json:"audit_logs,omitempty"`
+	Chats     []domain.Chat         `json:"chats,omitempty"`
+
+	rawChats []repository.ArchivedChat // Baris asli (ciphertext) untuk restore
+}
+
+// RunArchival memindahkan data yang melewati retensi ke segment file lalu menghapusnya dari DB.
+// Tiket dengan LegalHold tidak pernah ikut diarsip.
+func (s *ArchiveService) RunArchival(ctx context.Context) ([]domain.ArchiveSegment, error) {
+	if err := os.MkdirAll(s.Dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	policies, err := s.Repo.GetRetentionPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Pisahkan policy AUDIT_LOG explicit vs default ("*")
+	var explicitActions []string
+	for _, p := range policies {
+		if p.Kind == "AUDIT_LOG" && p.Action != "*" {
+			explicitActions = append(explicitActions, p.Action)
+		}
+	}
+
+	var created []domain.ArchiveSegment
+	for _, p := range policies {
+		cutoff := time.Now().AddDate(0, 0, -p.RetentionDays)
+
+		for {
+			var segment *domain.ArchiveSegment
+			var n int
+
+			switch p.Kind {
+			case "AUDIT_LOG":
+				logs, err := s.Repo.GetExpiredAuditLogs(ctx, p.Action, explicitActions, cutoff, archiveBatchSize)
+				if err != nil {
+					return created, err
+				}
+				if n = len(logs); n == 0 {
+					break
+				}
+				ids := make([]uint, n)
+				rows := make([]interface{}, n)
+				for i := range logs {
+					ids[i], rows[i] = logs[i].ID, logs[i]
+				}
+				if segment, err = s.writeSegment(ctx, p.Kind, rows, ids, &domain.AuditLog{}); err != nil {
+					return created, err
+				}
+
+			case "CHAT":
+				chats, err := s.Repo.GetExpiredChats(ctx, cutoff, archiveBatchSize)
+				if err != nil {
+					return created, err
+				}
+				if n = len(chats); n == 0 {
+					break
+				}
+				ids := make([]uint, n)
+				rows := make([]interface{}, n)
+				for i := range chats {
+					ids[i], rows[i] = chats[i].ID, chats[i]
+				}
+				if segment, err = s.writeSegment(ctx, p.Kind, rows, ids, &domain.Chat{}); err != nil {
+					return created, err
+				}
+			}
+
+			if segment != nil {
+				created = append(created, *segment)
+			}
+			if n < archiveBatchSize {
+				break
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// writeSegment menulis file gzip (header + JSON lines), menyambung hash chain, lalu purge baris di DB
+func (s *ArchiveService) writeSegment(ctx context.Context, kind string, rows []interface{}, ids []uint, model interface{}) (*domain.ArchiveSegment, error) {
+	// 1. Serialisasi payload (1 baris JSON per record)
+	var payload bytes.Buffer
+	for _, row := range rows {
+		line, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		payload.Write(line)
+		payload.WriteByte('\n')
+	}
+	payloadSum := sha256.Sum256(payload.Bytes())
+
+	// 2. Sambungkan ke ujung chain
+	prevHash := genesisHash
+	last, err := s.Repo.GetLastSegment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		prevHash = last.Hash
+	}
+	hash := chainHash(prevHash, hex.EncodeToString(payloadSum[:]))
+
+	header := segmentHeader{
+		Kind:          kind,
+		PrevHash:      prevHash,
+		PayloadSHA256: hex.EncodeToString(payloadSum[:]),
+		Hash:          hash,
+		RowCount:      len(rows),
+	}
+
+	// 3. Tulis file (fsync sebelum baris DB dihapus)
+	path := filepath.Join(s.Dir, fmt.Sprintf("segment-%s-%s-%s.jsonl.gz",
+		strings.ToLower(kind), time.Now().UTC().Format("20060102T150405"), hash[:12]))
+	if err := writeGzipFile(path, header, payload.Bytes()); err != nil {
+		return nil, err
+	}
+
+	segment := &domain.ArchiveSegment{
+		Kind:       kind,
+		FilePath:   path,
+		RowCount:   len(rows),
+		FirstRowID: ids[0],
+		LastRowID:  ids[len(ids)-1],
+		PrevHash:   prevHash,
+		Hash:       hash,
+	}
+
+	// 4. Catat segment & hapus data dari DB dalam satu transaksi
+	if err := s.Repo.SaveSegmentAndPurge(ctx, segment, model, ids); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return segment, nil
+}
+
+// VerifyChain membaca ulang semua segment dari disk dan memastikan hash chain utuh
+func (s *ArchiveService) VerifyChain(ctx context.Context) error {
+	segments, err := s.Repo.GetSegments(ctx)
+	if err != nil {
+		return err
+	}
+
+	prevHash := genesisHash
+	for _, segment := range segments {
+		if segment.PrevHash != prevHash {
+			return fmt.Errorf("segment %d: chain broken (prev hash mismatch)", segment.ID)
+		}
+		if _, _, err := s.readSegment(&segment); err != nil {
+			return fmt.Errorf("segment %d: %w", segment.ID, err)
+		}
+		prevHash = segment.Hash
+	}
+	return nil
+}
+
+// GetSegments daftar semua segment arsip
+func (s *ArchiveService) GetSegments(ctx context.Context) ([]domain.ArchiveSegment, error) {
+	return s.Repo.GetSegments(ctx)
+}
+
+// QuerySegment membaca isi segment (setelah integritas diverifikasi) dengan filter opsional
+func (s *ArchiveService) QuerySegment(ctx context.Context, segmentID, ticketID uint, action string) (*ArchiveQueryResult, error) {
+	segment, err := s.Repo.GetSegmentByID(ctx, segmentID)
+	if err != nil {
+		return nil, errors.New("segment not found")
+	}
+
+	_, lines, err := s.readSegment(segment)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ArchiveQueryResult{Segment: *segment}
+	for _, line := range lines {
+		switch segment.Kind {
+		case "AUDIT_LOG":
+			var entry domain.AuditLog
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return nil, err
+			}
+			if (ticketID == 0 || entry.TicketID == ticketID) && (action == "" || entry.Action == action) {
+				result.AuditLogs = append(result.AuditLogs, entry)
+			}
+		case "CHAT":
+			var raw repository.ArchivedChat
+			if err := json.Unmarshal(line, &raw); err != nil {
+				return nil, err
+			}
+			if ticketID != 0 && raw.TicketID != ticketID {
+				continue
+			}
+			message, err := s.Keyring.Decrypt(ctx, raw.Message)
+			if errors.Is(err, envelope.ErrKeyShredded) {
+				message, err = envelope.ShreddedPlaceholder, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			result.rawChats = append(result.rawChats, raw)
+			result.Chats = append(result.Chats, domain.Chat{
+				ID: raw.ID, TicketID: raw.TicketID, SenderID: raw.SenderID, SenderRole: raw.SenderRole,
+				Message: message, CreatedAt: raw.CreatedAt,
+			})
+		}
+	}
+	return result, nil
+}
+
+// RestoreSegment mengembalikan isi segment ke tabel asal. File arsip tetap disimpan.
+func (s *ArchiveService) RestoreSegment(ctx context.Context, segmentID uint) (int, error) {
+	result, err := s.QuerySegment(ctx, segmentID, 0, "")
+	if err != nil {
+		return 0, err
+	}
+
+	switch result.Segment.Kind {
+	case "AUDIT_LOG":
+		if len(result.AuditLogs) == 0 {
+			return 0, nil
+		}
+		return len(result.AuditLogs), s.Repo.RestoreRows(ctx, &result.AuditLogs)
+	case "CHAT":
+		if len(result.Chats) == 0 {
+			return 0, nil
+		}
+		return len(result.rawChats), s.Repo.RestoreRows(ctx, &result.rawChats)
+	}
+	return 0, errors.New("unknown segment kind")
+}
+
+// SetLegalHold menahan/melepas tiket dari proses arsip & purge
+func (s *ArchiveService) SetLegalHold(ctx context.Context, ticketID, actorID uint, role string, hold bool, reason string) error {
+	if err := s.Repo.SetLegalHold(ctx, ticketID, hold); err != nil {
+		return err
+	}
+
+	action := "LEGAL_HOLD_SET"
+	if !hold {
+		action = "LEGAL_HOLD_RELEASED"
+	}
+	s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, action, "SUCCESS", fmt.Sprintf("Reason: %s", reason))
+	return nil
+}
+
+// readSegment membuka file, memvalidasi header terhadap DB, dan menghitung ulang hash
+func (s *ArchiveService) readSegment(segment *domain.ArchiveSegment) (*segmentHeader, [][]byte, error) {
+	f, err := os.Open(segment.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		return nil, nil, errors.New("empty segment file")
+	}
+	var header segmentHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, nil, errors.New("invalid segment header")
+	}
+
+	h := sha256.New()
+	var lines [][]byte
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		h.Write(line)
+		h.Write([]byte{'\n'})
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	payloadSum := hex.EncodeToString(h.Sum(nil))
+	if payloadSum != header.PayloadSHA256 || len(lines) != header.RowCount {
+		return nil, nil, errors.New("integrity check failed: payload was modified")
+	}
+	if header.PrevHash != segment.PrevHash || header.Hash != segment.Hash ||
+		chainHash(header.PrevHash, payloadSum) != segment.Hash {
+		return nil, nil, errors.New("integrity check failed: hash chain mismatch")
+	}
+
+	return &header, lines, nil
+}
+
+func chainHash(prevHash, payloadSum string) string {
+	sum := sha256.Sum256([]byte(prevHash + payloadSum))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeGzipFile(path string, header segmentHeader, payload []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(f)
+	headerLine, _ := json.Marshal(header)
+	gz.Write(headerLine)
+	gz.Write([]byte{'\n'})
+	gz.Write(payload)
+
+	if err := gz.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
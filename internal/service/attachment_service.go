@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/internal/storage"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+// Default kebijakan lampiran (bisa dioverride lewat env di cmd/api)
+const (
+	DefaultAttachmentMaxBytes = 5 << 20 // 5 MB
+	DefaultAttachmentURLTTL   = 5 * time.Minute
+)
+
+// DefaultAttachmentTypes: MIME yang diizinkan (hasil deteksi konten)
+var DefaultAttachmentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}
+
+type AttachmentService struct {
+	Repo     *repository.AttachmentRepository
+	ChatSvc  *ChatService
+	AuditSvc *AuditService
+	Store    storage.BlobStore
+	Scanner  storage.MalwareScanner
+
+	MaxBytes     int64
+	AllowedTypes map[string]bool
+	URLTTL       time.Duration
+}
+
+func NewAttachmentService(repo *repository.AttachmentRepository, chatSvc *ChatService, auditSvc *AuditService, store storage.BlobStore, scanner storage.MalwareScanner) *AttachmentService {
+	allowed := make(map[string]bool, len(DefaultAttachmentTypes))
+	for _, t := range DefaultAttachmentTypes {
+		allowed[t] = true
+	}
+	return &AttachmentService{
+		Repo:         repo,
+		ChatSvc:      chatSvc,
+		AuditSvc:     auditSvc,
+		Store:        store,
+		Scanner:      scanner,
+		MaxBytes:     DefaultAttachmentMaxBytes,
+		AllowedTypes: allowed,
+		URLTTL:       DefaultAttachmentURLTTL,
+	}
+}
+
+// Upload: Validasi (ukuran, MIME, malware) -> simpan blob (dedup SHA256) -> kirim sebagai pesan chat
+func (s *AttachmentService) Upload(ctx context.Context, ticketID, senderID uint, role, fileName string, r io.Reader, message string) (*domain.Chat, error) {
+	deny := func(reason string) error {
+		s.AuditSvc.LogActivity(ctx, ticketID, senderID, role, "ATTACHMENT_UPLOAD", "DENIED", "Reason: "+reason)
+		return errors.New("attachment rejected: " + reason)
+	}
+
+	// 1. Otorisasi sama dengan kirim chat (sebelum menyentuh storage)
+	if _, err := s.ChatSvc.AuthorizeSend(ctx, ticketID, senderID, role); err != nil {
+		return nil, err
+	}
+
+	// 2. Baca maksimal MaxBytes+1 untuk mendeteksi file kebesaran tanpa membaca semuanya
+	content, err := io.ReadAll(io.LimitReader(r, s.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, deny("empty file")
+	}
+	if int64(len(content)) > s.MaxBytes {
+		return nil, deny(fmt.Sprintf("file exceeds %d bytes", s.MaxBytes))
+	}
+
+	// 3. MIME dari isi file (header Content-Type client tidak dipercaya)
+	mimeType := strings.TrimSpace(strings.SplitN(http.DetectContentType(content), ";", 2)[0])
+	if !s.AllowedTypes[mimeType] {
+		return nil, deny("file type " + mimeType + " is not allowed")
+	}
+
+	// 4. Hook malware scanner
+	if err := s.Scanner.Scan(ctx, fileName, content); err != nil {
+		return nil, deny(err.Error())
+	}
+
+	// 5. Simpan blob (konten yang sama hanya disimpan sekali)
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	exists, err := s.Store.Exists(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := s.Store.Put(ctx, hash, bytes.NewReader(content)); err != nil {
+			return nil, err
+		}
+	}
+
+	// 6. Pesan chat + metadata lampiran
+	attachment := domain.Attachment{
+		TicketID:     ticketID,
+		UploaderID:   senderID,
+		UploaderRole: role,
+		FileName:     sanitizeFileName(fileName),
+		MimeType:     mimeType,
+		Size:         int64(len(content)),
+		SHA256:       hash,
+		BlobKey:      hash,
+	}
+	chat, err := s.ChatSvc.SendMessageWithAttachments(ctx, ticketID, senderID, role, message, []domain.Attachment{attachment})
+	if err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, senderID, role, "ATTACHMENT_UPLOAD", "SUCCESS",
+		fmt.Sprintf("attachment #%d %s (%d bytes, sha256 %s, dedup: %t)", chat.Attachments[0].ID, mimeType, len(content), hash[:12], exists))
+	return chat, nil
+}
+
+// SignedURL: Setelah lolos otorisasi chat, buat link download berumur pendek yang terikat ke aktor
+func (s *AttachmentService) SignedURL(ctx context.Context, ticketID, attachmentID, requestorID uint, role string) (string, time.Time, error) {
+	attachment, err := s.authorizeDownload(ctx, attachmentID, requestorID, role)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if attachment.TicketID != ticketID {
+		return "", time.Time{}, errors.New("attachment not found")
+	}
+
+	expiresAt := time.Now().Add(s.URLTTL)
+	params := url.Values{}
+	params.Set("uid", fmt.Sprint(requestorID))
+	params.Set("role", role)
+	params.Set("exp", fmt.Sprint(expiresAt.Unix()))
+	params.Set("sig", utils.SignParams(expiresAt, fmt.Sprint(attachment.ID), fmt.Sprint(requestorID), role))
+	return fmt.Sprintf("/attachments/%d?%s", attachment.ID, params.Encode()), expiresAt, nil
+}
+
+// Download: Verifikasi tanda tangan, lalu cek ulang otorisasi chat (akses bisa sudah dicabut sejak link dibuat)
+func (s *AttachmentService) Download(ctx context.Context, attachmentID, requestorID uint, role, signature string, expiresUnix int64) (*domain.Attachment, io.ReadCloser, error) {
+	if err := utils.VerifySignedParams(signature, expiresUnix, fmt.Sprint(attachmentID), fmt.Sprint(requestorID), role); err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := s.authorizeDownload(ctx, attachmentID, requestorID, role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.Store.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.AuditSvc.LogActivity(ctx, attachment.TicketID, requestorID, role, "ATTACHMENT_DOWNLOAD", "SUCCESS",
+		fmt.Sprintf("attachment #%d", attachment.ID))
+	return attachment, content, nil
+}
+
+func (s *AttachmentService) authorizeDownload(ctx context.Context, attachmentID, requestorID uint, role string) (*domain.Attachment, error) {
+	attachment, err := s.Repo.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, errors.New("attachment not found")
+	}
+	// Lampiran pesan yang sudah ditarik hanya bisa dibuka Auditor
+	if attachment.RevisionID != nil && role != domain.RoleAuditor {
+		s.AuditSvc.LogActivity(ctx, attachment.TicketID, requestorID, role, "ATTACHMENT_DOWNLOAD", "DENIED",
+			fmt.Sprintf("attachment #%d: message retracted", attachment.ID))
+		return nil, errors.New("attachment not found")
+	}
+	if _, err := s.ChatSvc.AuthorizeRead(ctx, attachment.TicketID, requestorID, role); err != nil {
+		s.AuditSvc.LogActivity(ctx, attachment.TicketID, requestorID, role, "ATTACHMENT_DOWNLOAD", "DENIED",
+			fmt.Sprintf("attachment #%d: %v", attachment.ID, err))
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// sanitizeFileName: Nama file untuk ditampilkan / Content-Disposition (tanpa path & karakter kontrol)
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
@@ -1,7 +1,13 @@
 package service
 
 import (
+	"context"
+	"bytes"
+	"encoding/csv"
 	"fmt"
+	stdlog "log"
+	"strconv"
+	"time"
 
 	"github.com/syukurgit/zta/internal/domain"
 	"github.com/syukurgit/zta/internal/repository"
@@ -9,7 +15,10 @@ import (
 )
 
 type AuditService struct {
-	Repo *repository.AuditRepository
+	Repo    *repository.AuditRepository
+	CSATSvc *CSATService // Opsional: ringkasan CSAT di laporan analitik
+
+	hooks []func(context.Context, *domain.AuditLog) // Dipanggil setiap kali log berhasil ditulis (mis. rule engine anomali)
 }
 
 func NewAuditService(repo *repository.AuditRepository) *AuditService {
@@ -19,12 +28,15 @@ func NewAuditService(repo *repository.AuditRepository) *AuditService {
 // LogActivity DIPERBARUI: Parameter pertama sekarang ticketID
 // internal/service/audit_service.go
 
-func (s *AuditService) LogActivity(ticketID uint, actorID uint, role, action, result, contextData string) {
+func (s *AuditService) LogActivity(ctx context.Context, ticketID uint, actorID uint, role, action, result, contextData string) {
 	actorHash := ""
-	if role == domain.RoleCS {
+	switch role {
+	case domain.RoleCS:
 		// Anonymize CS ID menggunakan Hash
 		actorHash = utils.AnonymizeID(actorID)
-	} else {
+	case domain.RoleSystem:
+		actorHash = domain.RoleSystem
+	default:
 		actorHash = fmt.Sprintf("USER-%d", actorID)
 	}
 
@@ -35,10 +47,183 @@ func (s *AuditService) LogActivity(ticketID uint, actorID uint, role, action, re
 		Action:    action,
 		Result:    result,
 		Context:   contextData,
+		RequestID: utils.RequestIDFromContext(ctx),
+	}
+	if err := s.Repo.CreateLog(ctx, log); err != nil {
+		stdlog.Printf("[%s] failed to write audit log %s/%s: %v", log.RequestID, action, result, err)
+		return
+	}
+
+	for _, hook := range s.hooks {
+		hook(ctx, log)
 	}
-	_ = s.Repo.CreateLog(log)
 }
 
-func (s *AuditService) GetAuditTrail() ([]domain.AuditLog, error) {
-	return s.Repo.GetAllLogs()
-}
\ No newline at end of file
+// LogEvent mencatat event tanpa tiket (akun, sesi, admin, konfigurasi) dengan ticket_id = 0
+func (s *AuditService) LogEvent(ctx context.Context, actorID uint, role, action, result, contextData string) {
+	s.LogActivity(ctx, 0, actorID, role, action, result, contextData)
+}
+
+// OnLog mendaftarkan hook yang menerima setiap AuditLog setelah tersimpan
+func (s *AuditService) OnLog(hook func(context.Context, *domain.AuditLog)) {
+	s.hooks = append(s.hooks, hook)
+}
+
+func (s *AuditService) GetAuditTrail(ctx context.Context) ([]domain.AuditLog, error) {
+	return s.Repo.GetAllLogs(ctx)
+}
+
+// BuildAnalyticsReport menyusun laporan agregat Auditor untuk rentang waktu [from, to]
+func (s *AuditService) BuildAnalyticsReport(ctx context.Context, from, to time.Time) (*domain.AuditAnalyticsReport, error) {
+	report := &domain.AuditAnalyticsReport{From: from, To: to}
+	var err error
+
+	// 1. Aksi DENIED per pseudonym CS
+	if report.DeniedByActor, err = s.Repo.CountDeniedByActor(ctx, from, to); err != nil {
+		return nil, err
+	}
+
+	// 2. Rasio lulus/gagal verifikasi
+	byStatus, err := s.Repo.CountVerificationByStatus(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	stats := domain.VerificationStats{
+		Passed:  byStatus["PASSED"],
+		Failed:  byStatus["FAILED"],
+		Expired: byStatus["EXPIRED"],
+		Pending: byStatus["PENDING"],
+	}
+	stats.Total = stats.Passed + stats.Failed + stats.Expired + stats.Pending
+	if stats.Total > 0 {
+		stats.PassRate = float64(stats.Passed) / float64(stats.Total)
+		stats.FailRate = float64(stats.Failed) / float64(stats.Total)
+	}
+	report.Verification = stats
+
+	// 3. Latency privilege (granted -> used)
+	used, err := s.Repo.GetUsedPrivileges(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report.PrivilegeLatency = privilegeLatency(used)
+
+	// 4. Privilege yang tidak pernah dipakai
+	if report.UnusedPrivileges, err = s.Repo.CountUnusedPrivileges(ctx, from, to, false); err != nil {
+		return nil, err
+	}
+	if report.ExpiredPrivileges, err = s.Repo.CountUnusedPrivileges(ctx, from, to, true); err != nil {
+		return nil, err
+	}
+
+	// 5. Tiket ditutup tanpa verifikasi & pelanggaran kebijakan
+	if report.TicketsClosedWithoutVerification, err = s.Repo.CountClosedWithoutVerification(ctx, from, to); err != nil {
+		return nil, err
+	}
+	if report.PolicyViolations, err = s.Repo.CountPolicyViolations(ctx, from, to); err != nil {
+		return nil, err
+	}
+
+	// 6. Kepuasan User (CSAT) per pseudonym CS & kategori
+	if s.CSATSvc != nil {
+		csat, err := s.CSATSvc.BuildReport(ctx, from, to)
+		if err != nil {
+			return nil, err
+		}
+		report.CSAT = *csat
+	}
+
+	return report, nil
+}
+
+func privilegeLatency(privileges []domain.TemporaryPrivilege) domain.PrivilegeLatencyStats {
+	var stats domain.PrivilegeLatencyStats
+	var total float64
+	for _, p := range privileges {
+		if p.UsedAt == nil {
+			continue
+		}
+		seconds := p.UsedAt.Sub(p.GrantedAt).Seconds()
+		if stats.Samples == 0 || seconds < stats.MinSeconds {
+			stats.MinSeconds = seconds
+		}
+		if seconds > stats.MaxSeconds {
+			stats.MaxSeconds = seconds
+		}
+		total += seconds
+		stats.Samples++
+	}
+	if stats.Samples > 0 {
+		stats.AvgSeconds = total / float64(stats.Samples)
+	}
+	return stats
+}
+
+// ExportAnalyticsCSV mengubah laporan menjadi CSV datar (section, key, metric, value)
+func (s *AuditService) ExportAnalyticsCSV(report *domain.AuditAnalyticsReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"section", "key", "metric", "value"},
+		{"window", "", "from", report.From.UTC().Format(time.RFC3339)},
+		{"window", "", "to", report.To.UTC().Format(time.RFC3339)},
+	}
+	for _, d := range report.DeniedByActor {
+		rows = append(rows, []string{"denied_by_actor", d.ActorHash, d.Action, strconv.FormatInt(d.Count, 10)})
+	}
+
+	v := report.Verification
+	rows = append(rows,
+		[]string{"verification", "", "total", strconv.FormatInt(v.Total, 10)},
+		[]string{"verification", "", "passed", strconv.FormatInt(v.Passed, 10)},
+		[]string{"verification", "", "failed", strconv.FormatInt(v.Failed, 10)},
+		[]string{"verification", "", "expired", strconv.FormatInt(v.Expired, 10)},
+		[]string{"verification", "", "pending", strconv.FormatInt(v.Pending, 10)},
+		[]string{"verification", "", "pass_rate", strconv.FormatFloat(v.PassRate, 'f', 4, 64)},
+		[]string{"verification", "", "fail_rate", strconv.FormatFloat(v.FailRate, 'f', 4, 64)},
+	)
+
+	l := report.PrivilegeLatency
+	rows = append(rows,
+		[]string{"privilege_latency", "", "samples", strconv.Itoa(l.Samples)},
+		[]string{"privilege_latency", "", "avg_seconds", strconv.FormatFloat(l.AvgSeconds, 'f', 2, 64)},
+		[]string{"privilege_latency", "", "min_seconds", strconv.FormatFloat(l.MinSeconds, 'f', 2, 64)},
+		[]string{"privilege_latency", "", "max_seconds", strconv.FormatFloat(l.MaxSeconds, 'f', 2, 64)},
+		[]string{"privileges", "", "unused", strconv.FormatInt(report.UnusedPrivileges, 10)},
+		[]string{"privileges", "", "expired", strconv.FormatInt(report.ExpiredPrivileges, 10)},
+		[]string{"tickets", "", "closed_without_verification", strconv.FormatInt(report.TicketsClosedWithoutVerification, 10)},
+	)
+	for _, p := range report.PolicyViolations {
+		rows = append(rows, []string{"policy_violation", p.Action, p.Result, strconv.FormatInt(p.Count, 10)})
+	}
+
+	csat := report.CSAT
+	rows = append(rows,
+		[]string{"csat", "", "requested", strconv.FormatInt(csat.Requested, 10)},
+		[]string{"csat", "", "responses", strconv.FormatInt(csat.Responses, 10)},
+		[]string{"csat", "", "response_rate", strconv.FormatFloat(csat.ResponseRate, 'f', 4, 64)},
+		[]string{"csat", "", "avg_rating", strconv.FormatFloat(csat.AvgRating, 'f', 2, 64)},
+		[]string{"csat", "", "satisfied_rate", strconv.FormatFloat(csat.SatisfiedRate, 'f', 4, 64)},
+	)
+	for rating := 1; rating <= 5; rating++ {
+		rows = append(rows, []string{"csat_distribution", strconv.Itoa(rating), "count", strconv.FormatInt(csat.Distribution[rating], 10)})
+	}
+	for _, section := range []struct {
+		name   string
+		groups []domain.CSATGroupStats
+	}{{"csat_by_agent", csat.ByAgent}, {"csat_by_category", csat.ByCategory}} {
+		for _, g := range section.groups {
+			rows = append(rows,
+				[]string{section.name, g.Key, "responses", strconv.FormatInt(g.Responses, 10)},
+				[]string{section.name, g.Key, "avg_rating", strconv.FormatFloat(g.AvgRating, 'f', 2, 64)},
+				[]string{section.name, g.Key, "satisfied_rate", strconv.FormatFloat(g.SatisfiedRate, 'f', 4, 64)},
+			)
+		}
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+)
+
+// CannedResponseService: Library jawaban siap pakai (tim & pribadi) + macro
+type CannedResponseService struct {
+	Repo       *repository.CannedResponseRepository
+	AgentRepo  *repository.AgentRepository
+	TicketRepo *repository.TicketRepository
+	ChatSvc    *ChatService
+	TicketSvc  *TicketService
+	VerifSvc   *VerificationService
+	AuditSvc   *AuditService
+}
+
+func NewCannedResponseService(
+	repo *repository.CannedResponseRepository,
+	agentRepo *repository.AgentRepository,
+	ticketRepo *repository.TicketRepository,
+	chatSvc *ChatService,
+	ticketSvc *TicketService,
+	verifSvc *VerificationService,
+	auditSvc *AuditService,
+) *CannedResponseService {
+	return &CannedResponseService{
+		Repo:       repo,
+		AgentRepo:  agentRepo,
+		TicketRepo: ticketRepo,
+		ChatSvc:    chatSvc,
+		TicketSvc:  ticketSvc,
+		VerifSvc:   verifSvc,
+		AuditSvc:   auditSvc,
+	}
+}
+
+// MacroStepResult: Hasil satu langkah macro
+type MacroStepResult struct {
+	Type   string `json:"type"`
+	Status string `json:"status,omitempty"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// MacroResult: Hasil menjalankan canned response / macro di tiket
+type MacroResult struct {
+	ResponseID uint              `json:"response_id"`
+	Message    *domain.Chat      `json:"message,omitempty"`
+	Steps      []MacroStepResult `json:"steps"`
+}
+
+//
+// =======================
+// LIBRARY
+// =======================
+//
+
+// ListForAgent: Library pribadi + tim CS
+func (s *CannedResponseService) ListForAgent(ctx context.Context, csID uint, search string) ([]domain.CannedResponse, error) {
+	profile, err := s.AgentRepo.GetProfile(ctx, csID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.ListForAgent(ctx, csID, profile.TeamID, strings.TrimSpace(search))
+}
+
+// ListTeamResponses (Supervisor): Library tim
+func (s *CannedResponseService) ListTeamResponses(ctx context.Context, teamID *uint) ([]domain.CannedResponse, error) {
+	return s.Repo.ListTeamResponses(ctx, teamID)
+}
+
+// Save membuat (id = 0) atau mengubah canned response.
+// CS hanya mengelola library pribadinya; Supervisor hanya mengelola library tim.
+func (s *CannedResponseService) Save(ctx context.Context, id uint, input domain.CannedResponse, actorID uint, role string) (*domain.CannedResponse, error) {
+	response := &domain.CannedResponse{CreatedBy: actorID}
+	if id != 0 {
+		existing, err := s.manageable(ctx, id, actorID, role)
+		if err != nil {
+			return nil, err
+		}
+		response = existing
+	}
+
+	// 1. Scope ditentukan oleh role, bukan input
+	switch role {
+	case domain.RoleCS:
+		response.Scope, response.OwnerID, response.TeamID = domain.CannedScopePersonal, &actorID, nil
+	case domain.RoleSupervisor:
+		if input.TeamID == nil {
+			return nil, errors.New("team_id is required for team responses")
+		}
+		if _, err := s.AgentRepo.GetTeam(ctx, *input.TeamID); err != nil {
+			return nil, errors.New("team not found")
+		}
+		response.Scope, response.TeamID, response.OwnerID = domain.CannedScopeTeam, input.TeamID, nil
+	default:
+		return nil, errors.New("invalid role")
+	}
+
+	// 2. Isi + validasi placeholder / macro
+	response.Shortcut = strings.TrimSpace(input.Shortcut)
+	response.Title = strings.TrimSpace(input.Title)
+	response.Body = input.Body
+	response.Actions = input.Actions
+	if err := response.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Save(ctx, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Delete menghapus canned response yang boleh dikelola aktor
+func (s *CannedResponseService) Delete(ctx context.Context, id, actorID uint, role string) error {
+	if _, err := s.manageable(ctx, id, actorID, role); err != nil {
+		return err
+	}
+	return s.Repo.Delete(ctx, id)
+}
+
+// GetUsageStats (Supervisor): Pemakaian per response dalam rentang waktu
+func (s *CannedResponseService) GetUsageStats(ctx context.Context, from, to time.Time) ([]domain.CannedResponseStats, error) {
+	return s.Repo.GetUsageStats(ctx, from, to)
+}
+
+// manageable: CS -> response pribadi miliknya, Supervisor -> response tim
+func (s *CannedResponseService) manageable(ctx context.Context, id, actorID uint, role string) (*domain.CannedResponse, error) {
+	response, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, errors.New("canned response not found")
+	}
+	switch {
+	case role == domain.RoleCS && response.Scope == domain.CannedScopePersonal && response.OwnerID != nil && *response.OwnerID == actorID:
+	case role == domain.RoleSupervisor && response.Scope == domain.CannedScopeTeam:
+	default:
+		return nil, errors.New("access denied: you cannot manage this canned response")
+	}
+	return response, nil
+}
+
+//
+// =======================
+// PAKAI DI TIKET
+// =======================
+//
+
+// Preview: Body dengan placeholder terisi data tiket (tidak dihitung sebagai pemakaian)
+func (s *CannedResponseService) Preview(ctx context.Context, ticketID, responseID, csID uint) (string, error) {
+	response, ticket, err := s.usable(ctx, ticketID, responseID, csID)
+	if err != nil {
+		return "", err
+	}
+	return response.Render(ticket), nil
+}
+
+// Apply menjalankan canned response / macro di tiket secara berurutan; berhenti di langkah pertama yang gagal
+func (s *CannedResponseService) Apply(ctx context.Context, ticketID, responseID, csID uint) (*MacroResult, error) {
+	// 1. Akses ke response & tiket
+	response, ticket, err := s.usable(ctx, ticketID, responseID, csID)
+	if err != nil {
+		s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, "CANNED_RESPONSE_APPLY", "DENIED",
+			fmt.Sprintf("response #%d, Reason: %s", responseID, err))
+		return nil, err
+	}
+
+	// 2. Jalankan langkah macro lewat service yang sama dengan aksi manual (otorisasi & audit tetap berlaku)
+	result := &MacroResult{ResponseID: response.ID}
+	for _, step := range response.MacroSteps() {
+		stepResult := MacroStepResult{Type: step.Type, Status: step.Status}
+		switch step.Type {
+		case domain.MacroSendMessage:
+			result.Message, err = s.ChatSvc.SendMessage(ctx, ticketID, csID, domain.RoleCS, response.Render(ticket))
+		case domain.MacroStartVerification:
+			_, err = s.VerifSvc.StartVerification(ctx, ticketID, csID)
+		case domain.MacroSetStatus:
+			_, err = s.TicketSvc.Transition(ctx, ticketID, step.Status, csID, domain.RoleCS, fmt.Sprintf("Macro #%d", response.ID))
+		}
+		stepResult.OK = err == nil
+		if err != nil {
+			stepResult.Error = err.Error()
+		}
+		result.Steps = append(result.Steps, stepResult)
+		if err != nil {
+			break
+		}
+	}
+
+	// 3. Catat pemakaian
+	if err := s.Repo.RecordUsage(ctx, response.ID, ticketID, csID); err != nil {
+		return result, err
+	}
+	outcome := "SUCCESS"
+	if !result.Steps[len(result.Steps)-1].OK {
+		outcome = "FAILED"
+	}
+	s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, "CANNED_RESPONSE_APPLY", outcome,
+		fmt.Sprintf("response #%d (%s): %d/%d step(s) done", response.ID, response.Title, countOK(result.Steps), len(response.MacroSteps())))
+	return result, nil
+}
+
+// usable: Response ada di library CS & CS sedang memegang tiket
+func (s *CannedResponseService) usable(ctx context.Context, ticketID, responseID, csID uint) (*domain.CannedResponse, *domain.Ticket, error) {
+	response, err := s.Repo.Get(ctx, responseID)
+	if err != nil {
+		return nil, nil, errors.New("canned response not found")
+	}
+	switch response.Scope {
+	case domain.CannedScopePersonal:
+		if response.OwnerID == nil || *response.OwnerID != csID {
+			return nil, nil, errors.New("canned response not found")
+		}
+	case domain.CannedScopeTeam:
+		profile, err := s.AgentRepo.GetProfile(ctx, csID)
+		if err != nil || profile.TeamID == nil || response.TeamID == nil || *profile.TeamID != *response.TeamID {
+			return nil, nil, errors.New("canned response not found")
+		}
+	}
+
+	ticket, err := s.TicketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, nil, errors.New("ticket not found")
+	}
+	if assignedCS, err := s.TicketRepo.GetAssignedCS(ctx, ticketID); err != nil || assignedCS != csID {
+		return nil, nil, errors.New("access denied: you are not handling this ticket")
+	}
+	return response, ticket, nil
+}
+
+func countOK(steps []MacroStepResult) int {
+	n := 0
+	for _, step := range steps {
+		if step.OK {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+// CapacityService mengelola batas tiket aktif per agent & per tim.
+// Penegakan batas dilakukan secara atomic di repository (lihat reserveAgentCapacity).
+type CapacityService struct {
+	AgentRepo  *repository.AgentRepository
+	TicketRepo *repository.TicketRepository
+	AuditSvc   *AuditService
+}
+
+func NewCapacityService(agentRepo *repository.AgentRepository, ticketRepo *repository.TicketRepository, auditSvc *AuditService) *CapacityService {
+	return &CapacityService{AgentRepo: agentRepo, TicketRepo: ticketRepo, AuditSvc: auditSvc}
+}
+
+// GetTeams daftar tim beserta batasnya
+func (s *CapacityService) GetTeams(ctx context.Context) ([]domain.Team, error) {
+	return s.AgentRepo.GetTeams(ctx)
+}
+
+// SaveTeam membuat tim baru (id = 0) atau memperbarui batas tim
+func (s *CapacityService) SaveTeam(ctx context.Context, id uint, name string, maxActive int, actorID uint) (*domain.Team, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("team name is required")
+	}
+	if maxActive < 1 {
+		return nil, errors.New("max_active_tickets must be at least 1")
+	}
+
+	team := &domain.Team{}
+	if id != 0 {
+		existing, err := s.AgentRepo.GetTeam(ctx, id)
+		if err != nil {
+			return nil, errors.New("team not found")
+		}
+		team = existing
+	}
+	team.Name = name
+	team.MaxActiveTickets = maxActive
+	if err := s.AgentRepo.SaveTeam(ctx, team); err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogEvent(ctx, actorID, domain.RoleSupervisor, "TEAM_CAPACITY_UPDATE", "SUCCESS",
+		fmt.Sprintf("team=%s max_active_tickets=%d", team.Name, team.MaxActiveTickets))
+	return team, nil
+}
+
+// SetAgentCapacity mengatur batas pribadi agent (0 = ikut tim) dan keanggotaan tim (nil = tanpa tim)
+func (s *CapacityService) SetAgentCapacity(ctx context.Context, csID uint, capacity int, teamID *uint, actorID uint) (*domain.AgentProfile, error) {
+	user, err := s.TicketRepo.GetUserByID(ctx, csID)
+	if err != nil || !user.HasRole(domain.RoleCS) {
+		return nil, errors.New("target is not a CS agent")
+	}
+	if capacity < 0 {
+		return nil, errors.New("capacity cannot be negative")
+	}
+
+	profile, err := s.AgentRepo.GetProfile(ctx, csID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Team = nil
+	if teamID != nil {
+		team, err := s.AgentRepo.GetTeam(ctx, *teamID)
+		if err != nil {
+			return nil, errors.New("team not found")
+		}
+		profile.Team = team
+	}
+	profile.TeamID = teamID
+	profile.Capacity = capacity
+	if err := s.AgentRepo.SaveProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogEvent(ctx, actorID, domain.RoleSupervisor, "AGENT_CAPACITY_UPDATE", "SUCCESS",
+		fmt.Sprintf("%s capacity=%d team_id=%s effective=%d", utils.AnonymizeID(csID), capacity, formatTeamID(teamID), profile.EffectiveCapacity()))
+	return profile, nil
+}
+
+func formatTeamID(teamID *uint) string {
+	if teamID == nil {
+		return "none"
+	}
+	return fmt.Sprint(*teamID)
+}
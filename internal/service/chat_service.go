@@ -1,24 +1,55 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/syukurgit/zta/internal/dlp"
 	"github.com/syukurgit/zta/internal/domain"
 	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/pkg/utils"
 )
 
+// Privilege JIT untuk membuka nilai asli data yang disamarkan DLP
+const (
+	PrivilegeViewRedacted = "VIEW_REDACTED"
+	maxRevealAccess       = 60 * time.Minute
+)
+
+// ChatEditWindow: Batas waktu pengirim boleh mengedit / menarik pesannya
+const ChatEditWindow = 15 * time.Minute
+
+// MaxChatWait: Batas maksimal request long-poll chat ditahan
+const MaxChatWait = 30 * time.Second
+
 type ChatService struct {
 	ChatRepo   *repository.ChatRepository
 	TicketRepo *repository.TicketRepository
+	SLASvc     *SLAService
+	TicketSvc  *TicketService // Untuk transisi PENDING_USER -> IN_PROGRESS saat user membalas
+	AuditSvc   *AuditService
+	Redactor   *dlp.Redactor // DLP: PII & secret disamarkan sebelum disimpan
 }
 
 func NewChatService(
 	chatRepo *repository.ChatRepository,
 	ticketRepo *repository.TicketRepository,
+	slaSvc *SLAService,
+	ticketSvc *TicketService,
+	auditSvc *AuditService,
 ) *ChatService {
 	return &ChatService{
 		ChatRepo:   chatRepo,
 		TicketRepo: ticketRepo,
+		SLASvc:     slaSvc,
+		TicketSvc:  ticketSvc,
+		AuditSvc:   auditSvc,
+		Redactor:   dlp.NewRedactor(),
 	}
 }
 
@@ -28,14 +59,72 @@ func NewChatService(
 // =======================
 //
 func (s *ChatService) SendMessage(
+	ctx context.Context,
+	ticketID uint,
+	senderID uint,
+	role string,
+	message string,
+) (*domain.Chat, error) {
+	return s.SendMessageWithAttachments(ctx, ticketID, senderID, role, message, nil)
+}
+
+// SendMessageWithAttachments: Pesan + lampiran (blob sudah disimpan AttachmentService) dalam satu insert
+func (s *ChatService) SendMessageWithAttachments(
+	ctx context.Context,
 	ticketID uint,
 	senderID uint,
 	role string,
 	message string,
+	attachments []domain.Attachment,
 ) (*domain.Chat, error) {
 
+	// 1-2. Ambil tiket + AUTHORIZATION
+	ticket, err := s.AuthorizeSend(ctx, ticketID, senderID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. DLP: samarkan data sensitif, nilai asli hanya disimpan terenkripsi
+	masked, redactions, err := s.redact(ticketID, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Simpan chat
+	chat := &domain.Chat{
+		TicketID:    ticketID,
+		SenderID:    senderID,
+		SenderRole:  role,
+		Message:     masked,
+		Attachments: attachments,
+		Redactions:  redactions,
+	}
+
+	if err := s.ChatRepo.CreateChat(ctx, chat); err != nil {
+		return nil, err
+	}
+
+	for _, r := range chat.Redactions {
+		s.AuditSvc.LogActivity(ctx, ticketID, senderID, role, "DLP_REDACTION", "SUCCESS",
+			fmt.Sprintf("chat #%d: %s masked (redaction #%d)", chat.ID, r.Kind, r.ID))
+	}
+
+	// 5. SLA: Balasan pertama CS pemegang tiket = first response (CS lain tidak menghentikan timer),
+	// balasan User mengakhiri PENDING_USER (timer jalan lagi)
+	switch {
+	case role == domain.RoleCS && s.isParticipant(ctx, ticket, senderID, role):
+		s.SLASvc.RecordFirstResponse(ctx, ticket, senderID)
+	case role == domain.RoleUser && ticket.Status == domain.TicketPendingUser:
+		s.TicketSvc.Transition(ctx, ticketID, domain.TicketInProgress, senderID, role, "User replied")
+	}
+
+	return chat, nil
+}
+
+// AuthorizeSend: Apakah aktor boleh mengirim pesan / lampiran ke tiket ini
+func (s *ChatService) AuthorizeSend(ctx context.Context, ticketID, senderID uint, role string) (*domain.Ticket, error) {
 	// 1. Ambil tiket
-	ticket, err := s.TicketRepo.GetByID(ticketID)
+	ticket, err := s.TicketRepo.GetByID(ctx, ticketID)
 	if err != nil {
 		return nil, errors.New("ticket not found")
 	}
@@ -51,27 +140,14 @@ func (s *ChatService) SendMessage(
 
 	case domain.RoleCS:
 		// CS boleh chat selama tiket masih aktif
-		if ticket.Status == "CLOSED" || ticket.Status == "LOCKED" {
+		if ticket.Status == domain.TicketClosed || ticket.Status == domain.TicketLocked {
 			return nil, errors.New("cannot chat on closed or locked tickets")
 		}
 
 	default:
 		return nil, errors.New("invalid role")
 	}
-
-	// 3. Simpan chat
-	chat := &domain.Chat{
-		TicketID:   ticketID,
-		SenderID:   senderID,
-		SenderRole: role,
-		Message:    message,
-	}
-
-	if err := s.ChatRepo.CreateChat(chat); err != nil {
-		return nil, err
-	}
-
-	return chat, nil
+	return ticket, nil
 }
 
 //
@@ -80,13 +156,86 @@ func (s *ChatService) SendMessage(
 // =======================
 //
 func (s *ChatService) GetHistory(
+	ctx context.Context,
 	ticketID uint,
 	requestorID uint,
 	role string,
+	q domain.ListQuery,
+) (*domain.Page[domain.Chat], error) {
+
+	// 1-2. Ambil tiket + AUTHORIZATION
+	ticket, err := s.AuthorizeRead(ctx, ticketID, requestorID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Ambil history chat
+	page, err := s.ChatRepo.ListChatHistory(ctx, ticketID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4-5. Unmask pesan SYSTEM & receipt delivered
+	s.prepareForReader(ctx, ticket, page.Items, requestorID, role)
+	return page, nil
+}
+
+// SyncHistory: Sinkronisasi inkremental (pesan dengan ID > after, atau yang dibuat / diedit / ditarik setelah cursor).
+// wait > 0 = long-poll: request ditahan sampai ada pesan baru / perubahan atau wait habis.
+func (s *ChatService) SyncHistory(
+	ctx context.Context,
+	ticketID uint,
+	requestorID uint,
+	role string,
+	since repository.ChatSince,
+	limit int,
+	wait time.Duration,
 ) ([]domain.Chat, error) {
 
+	// 1-2. Ambil tiket + AUTHORIZATION
+	ticket, err := s.AuthorizeRead(ctx, ticketID, requestorID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Ambil / tunggu pesan baru
+	chats, err := s.ChatRepo.WaitForChats(ctx, ticketID, since, limit, min(wait, MaxChatWait))
+	if err != nil {
+		return nil, err
+	}
+
+	// 4-5. Unmask pesan SYSTEM & receipt delivered
+	s.prepareForReader(ctx, ticket, chats, requestorID, role)
+	return chats, nil
+}
+
+// prepareForReader menyesuaikan pesan untuk pembaca & mencatat receipt delivered
+func (s *ChatService) prepareForReader(ctx context.Context, ticket *domain.Ticket, chats []domain.Chat, requestorID uint, role string) {
+	// Link rahasia di pesan SYSTEM hanya dibuka untuk pemilik tiket
+	if role == domain.RoleUser {
+		for i := range chats {
+			unmaskSystemMessage(&chats[i])
+		}
+	}
+
+	// Receipt "delivered" hanya untuk peserta percakapan (bukan Auditor / CS lain)
+	if s.isParticipant(ctx, ticket, requestorID, role) {
+		var chatIDs []uint
+		for _, chat := range chats {
+			if chat.SenderID != requestorID {
+				chatIDs = append(chatIDs, chat.ID)
+			}
+		}
+		if err := s.ChatRepo.MarkDelivered(ctx, requestorID, chatIDs); err != nil {
+			log.Printf("failed to mark chats delivered on ticket %d: %v", ticket.ID, err)
+		}
+	}
+}
+
+// AuthorizeRead: Apakah aktor boleh membaca chat (dan lampirannya) di tiket ini
+func (s *ChatService) AuthorizeRead(ctx context.Context, ticketID, requestorID uint, role string) (*domain.Ticket, error) {
 	// 1. Ambil tiket
-	ticket, err := s.TicketRepo.GetByID(ticketID)
+	ticket, err := s.TicketRepo.GetByID(ctx, ticketID)
 	if err != nil {
 		return nil, errors.New("ticket not found")
 	}
@@ -105,10 +254,263 @@ func (s *ChatService) GetHistory(
 		// (tidak tergantung privilege reset password, dll)
 		// optional: bisa tambahin cek assignment di sini
 
+	case domain.RoleAuditor:
+		// Auditor hanya baca (read-only), setiap bacaan dicatat oleh AuditAccessLogger
+
 	default:
 		return nil, errors.New("invalid role")
 	}
+	return ticket, nil
+}
 
-	// 3. Ambil history chat
-	return s.ChatRepo.GetChatHistory(ticketID)
+//
+// =======================
+// EDIT / RETRACT
+// =======================
+//
+
+// EditMessage: Pengirim mengganti isi pesannya sendiri dalam ChatEditWindow (versi lama disimpan)
+func (s *ChatService) EditMessage(ctx context.Context, ticketID, chatID, actorID uint, role, message string) (*domain.Chat, error) {
+	// 1. Validasi kepemilikan & window
+	chat, err := s.editableChat(ctx, ticketID, chatID, actorID, role)
+	if err != nil {
+		s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, "CHAT_EDITED", "DENIED",
+			fmt.Sprintf("chat #%d, Reason: %s", chatID, err))
+		return nil, err
+	}
+
+	// 2. DLP untuk isi baru
+	masked, redactions, err := s.redact(ticketID, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Simpan revisi + update (atomic)
+	if err := s.ChatRepo.ReviseChat(ctx, chat, chat.Version, "EDIT", masked, redactions, actorID); err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, "CHAT_EDITED", "SUCCESS",
+		fmt.Sprintf("chat #%d now at version %d", chat.ID, chat.Version))
+	return chat, nil
+}
+
+// RetractMessage: Pengirim menarik pesannya; isi dikosongkan, versi lama tetap ada untuk Auditor
+func (s *ChatService) RetractMessage(ctx context.Context, ticketID, chatID, actorID uint, role string) (*domain.Chat, error) {
+	chat, err := s.editableChat(ctx, ticketID, chatID, actorID, role)
+	if err != nil {
+		s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, "CHAT_RETRACTED", "DENIED",
+			fmt.Sprintf("chat #%d, Reason: %s", chatID, err))
+		return nil, err
+	}
+
+	if err := s.ChatRepo.ReviseChat(ctx, chat, chat.Version, "RETRACT", "", nil, actorID); err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, "CHAT_RETRACTED", "SUCCESS",
+		fmt.Sprintf("chat #%d retracted at version %d", chat.ID, chat.Version))
+	return chat, nil
+}
+
+// editableChat: Hanya pengirim (User / CS), pesan belum ditarik, masih dalam window, dan tiket masih boleh dichat
+func (s *ChatService) editableChat(ctx context.Context, ticketID, chatID, actorID uint, role string) (*domain.Chat, error) {
+	if _, err := s.AuthorizeSend(ctx, ticketID, actorID, role); err != nil {
+		return nil, err
+	}
+
+	chat, err := s.ChatRepo.GetChat(ctx, ticketID, chatID)
+	if err != nil {
+		return nil, errors.New("message not found")
+	}
+	if chat.SenderRole == domain.RoleSystem || chat.SenderID != actorID || chat.SenderRole != role {
+		return nil, errors.New("only the sender can change this message")
+	}
+	if chat.RetractedAt != nil {
+		return nil, errors.New("message has been retracted")
+	}
+	if time.Since(chat.CreatedAt) > ChatEditWindow {
+		return nil, fmt.Errorf("messages can only be changed within %d minutes", int(ChatEditWindow.Minutes()))
+	}
+	return chat, nil
+}
+
+// GetRevisions (Auditor): Semua versi lama satu pesan
+func (s *ChatService) GetRevisions(ctx context.Context, ticketID, chatID uint) ([]domain.ChatRevision, error) {
+	if _, err := s.ChatRepo.GetChat(ctx, ticketID, chatID); err != nil {
+		return nil, errors.New("message not found")
+	}
+	return s.ChatRepo.GetRevisions(ctx, ticketID, chatID)
+}
+
+//
+// =======================
+// READ RECEIPTS
+// =======================
+//
+
+// MarkRead: Peserta menandai pesan pihak lain sampai upToID sudah dibaca
+func (s *ChatService) MarkRead(ctx context.Context, ticketID, readerID uint, role string, upToID uint) (int, error) {
+	ticket, err := s.AuthorizeRead(ctx, ticketID, readerID, role)
+	if err != nil {
+		return 0, err
+	}
+	if !s.isParticipant(ctx, ticket, readerID, role) {
+		return 0, errors.New("only ticket participants can mark messages as read")
+	}
+	return s.ChatRepo.MarkRead(ctx, readerID, ticketID, upToID)
+}
+
+// GetUnreadCounts: User -> tiket miliknya, CS -> tiket aktif yang dipegang
+func (s *ChatService) GetUnreadCounts(ctx context.Context, readerID uint, role string) ([]domain.UnreadCount, error) {
+	switch role {
+	case domain.RoleUser:
+		return s.ChatRepo.CountUnread(ctx, readerID, s.ChatRepo.UserTicketIDs(readerID))
+	case domain.RoleCS:
+		return s.ChatRepo.CountUnread(ctx, readerID, s.ChatRepo.CSTicketIDs(readerID))
+	default:
+		return nil, errors.New("invalid role")
+	}
+}
+
+// isParticipant: User pemilik tiket atau CS yang sedang memegang tiket
+func (s *ChatService) isParticipant(ctx context.Context, ticket *domain.Ticket, actorID uint, role string) bool {
+	switch role {
+	case domain.RoleUser:
+		return ticket.UserID == actorID
+	case domain.RoleCS:
+		assignedCS, err := s.TicketRepo.GetAssignedCS(ctx, ticket.ID)
+		return err == nil && assignedCS == actorID
+	default:
+		return false
+	}
+}
+
+// GrantRevealAccess: Supervisor memberi CS pemegang tiket akses JIT untuk membuka data yang disamarkan DLP
+func (s *ChatService) GrantRevealAccess(ctx context.Context, ticketID, csID, supervisorID uint, duration time.Duration, reason string) (*domain.TemporaryPrivilege, error) {
+	assignedCS, err := s.TicketRepo.GetAssignedCS(ctx, ticketID)
+	if err != nil || assignedCS == 0 || assignedCS != csID {
+		s.AuditSvc.LogActivity(ctx, ticketID, supervisorID, domain.RoleSupervisor, "DLP_ACCESS_GRANT", "DENIED",
+			"Reason: target CS does not hold this ticket")
+		return nil, errors.New("target CS does not hold this ticket")
+	}
+	if duration <= 0 || duration > maxRevealAccess {
+		return nil, fmt.Errorf("duration must be between 1 and %d minutes", int(maxRevealAccess.Minutes()))
+	}
+
+	privilege := &domain.TemporaryPrivilege{
+		CSID:      csID,
+		TicketID:  ticketID,
+		Action:    PrivilegeViewRedacted,
+		Token:     utils.GenerateRandomToken(32),
+		GrantedAt: time.Now(),
+		ExpiresAt: time.Now().Add(duration),
+	}
+	if err := s.TicketRepo.CreatePrivilege(ctx, privilege); err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, supervisorID, domain.RoleSupervisor, "DLP_ACCESS_GRANT", "SUCCESS",
+		fmt.Sprintf("%s for %s until %s, reason: %s", PrivilegeViewRedacted, utils.AnonymizeID(csID), privilege.ExpiresAt.Format(time.RFC3339), reason))
+	return privilege, nil
+}
+
+// RevealRedaction: CS membuka nilai asli satu data tersamarkan (wajib privilege VIEW_REDACTED aktif)
+func (s *ChatService) RevealRedaction(ctx context.Context, ticketID, redactionID, csID uint) (string, error) {
+	// 1. Cek privilege JIT
+	if _, err := s.TicketRepo.GetActivePrivilege(ctx, csID, ticketID, PrivilegeViewRedacted); err != nil {
+		s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, "DLP_REVEAL", "DENIED",
+			fmt.Sprintf("Reason: No valid %s privilege (redaction #%d)", PrivilegeViewRedacted, redactionID))
+		return "", errors.New("access denied: no active privilege to view redacted data")
+	}
+
+	// 2. Ambil & dekripsi
+	redaction, err := s.ChatRepo.GetRedaction(ctx, ticketID, redactionID)
+	if err != nil {
+		return "", errors.New("redaction not found")
+	}
+	// Data milik versi lama (pesan diedit / ditarik) hanya disimpan untuk Auditor
+	if redaction.RevisionID != nil {
+		s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, "DLP_REVEAL", "DENIED",
+			fmt.Sprintf("Reason: redaction #%d belongs to a previous message version", redaction.ID))
+		return "", errors.New("redaction not found")
+	}
+
+	// 3. Link rahasia di pesan SYSTEM (reset / verifikasi) hanya untuk User pemilik tiket, tidak bisa di-reveal CS
+	chat, err := s.ChatRepo.GetChat(ctx, ticketID, redaction.ChatID)
+	if err != nil {
+		return "", errors.New("redaction not found")
+	}
+	if chat.SenderRole == domain.RoleSystem {
+		s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, "DLP_REVEAL", "DENIED",
+			fmt.Sprintf("Reason: redaction #%d belongs to a SYSTEM message", redaction.ID))
+		return "", errors.New("access denied: system message secrets cannot be revealed")
+	}
+
+	original, err := utils.DecryptString(redaction.Ciphertext)
+	if err != nil {
+		return "", errors.New("failed to decrypt redacted data")
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, "DLP_REVEAL", "SUCCESS",
+		fmt.Sprintf("redaction #%d (%s) revealed", redaction.ID, redaction.Kind))
+	return original, nil
+}
+
+// redact menjalankan DLP dan mengenkripsi setiap nilai asli
+func (s *ChatService) redact(ticketID uint, message string) (string, []domain.ChatRedaction, error) {
+	masked, matches := s.Redactor.Redact(message)
+	redactions := make([]domain.ChatRedaction, 0, len(matches))
+	for _, m := range matches {
+		ciphertext, err := utils.EncryptString(m.Original)
+		if err != nil {
+			return "", nil, errors.New("failed to protect sensitive data, message not sent")
+		}
+		redactions = append(redactions, domain.ChatRedaction{TicketID: ticketID, Kind: m.Kind, Masked: m.Masked, Ciphertext: ciphertext})
+	}
+	return masked, redactions, nil
+}
+
+// PostSystemEvent: Hook event tiket -> pesan SYSTEM berbahasa sesuai tiket.
+// Link rahasia (verifikasi / reset) disimpan tersamarkan seperti DLP; hanya User pemilik tiket yang melihat aslinya.
+func (s *ChatService) PostSystemEvent(ctx context.Context, event TicketEvent) {
+	ticket, err := s.TicketRepo.GetByID(ctx, event.TicketID)
+	if err != nil {
+		return
+	}
+	template, ok := systemMessage(event.Type, ticket.Language)
+	if !ok {
+		return
+	}
+
+	masked, redactions, err := s.redact(ticket.ID, strings.ReplaceAll(template, "{link}", event.Link))
+	if err != nil {
+		log.Printf("system message %s for ticket %d not sent: %v", event.Type, ticket.ID, err)
+		return
+	}
+	chat := &domain.Chat{
+		TicketID:   ticket.ID,
+		SenderID:   0,
+		SenderRole: domain.RoleSystem,
+		Message:    masked,
+		Redactions: redactions,
+	}
+	if err := s.ChatRepo.CreateChat(ctx, chat); err != nil {
+		log.Printf("system message %s for ticket %d not sent: %v", event.Type, ticket.ID, err)
+	}
+}
+
+// unmaskSystemMessage mengembalikan nilai asli di pesan SYSTEM (urutan redaksi = urutan kemunculan)
+func unmaskSystemMessage(chat *domain.Chat) {
+	if chat.SenderRole != domain.RoleSystem {
+		return
+	}
+	sort.Slice(chat.Redactions, func(i, j int) bool { return chat.Redactions[i].ID < chat.Redactions[j].ID })
+	for _, r := range chat.Redactions {
+		original, err := utils.DecryptString(r.Ciphertext)
+		if err != nil {
+			continue
+		}
+		chat.Message = strings.Replace(chat.Message, r.Masked, original, 1)
+	}
 }
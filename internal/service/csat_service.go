@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+const (
+	// CSATLinkTTL: Masa berlaku link survei sejak tiket ditutup
+	CSATLinkTTL = 7 * 24 * time.Hour
+	// MaxCSATComment: Panjang maksimal komentar (karakter)
+	MaxCSATComment = 1000
+)
+
+var (
+	ErrCSATNotFound = errors.New("survey not found")
+	ErrCSATClosed   = errors.New("survey link has expired or was already used")
+)
+
+type CSATService struct {
+	Repo       *repository.CSATRepository
+	TicketRepo *repository.TicketRepository
+	AuditSvc   *AuditService
+
+	TicketEventHooks // Link survei dikirim ke User sebagai pesan SYSTEM
+}
+
+func NewCSATService(repo *repository.CSATRepository, ticketRepo *repository.TicketRepository, auditSvc *AuditService) *CSATService {
+	return &CSATService{Repo: repo, TicketRepo: ticketRepo, AuditSvc: auditSvc}
+}
+
+// HandleTicketEvent: Hook event tiket. CLOSED -> kirim survei, REOPENED -> survei lama hangus
+// (penilaian selalu untuk penutupan terakhir).
+func (s *CSATService) HandleTicketEvent(ctx context.Context, event TicketEvent) {
+	switch event.Type {
+	case EventTicketClosed:
+		if err := s.RequestSurvey(ctx, event.TicketID); err != nil {
+			log.Printf("csat survey for ticket %d not sent: %v", event.TicketID, err)
+		}
+	case EventTicketReopened:
+		if _, err := s.Repo.ExpirePending(ctx, event.TicketID); err != nil {
+			log.Printf("csat survey for ticket %d not expired: %v", event.TicketID, err)
+		}
+	}
+}
+
+// RequestSurvey membuat survei satu kali untuk tiket yang baru ditutup dan mengirim link-nya ke User
+func (s *CSATService) RequestSurvey(ctx context.Context, ticketID uint) error {
+	ticket, err := s.TicketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+
+	// 1. Tiket tanpa CS (tidak pernah ditangani) tidak disurvei
+	csID, err := s.TicketRepo.GetAssignedCS(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	if csID == 0 {
+		return nil
+	}
+
+	// 2. Hanya satu survei aktif per tiket
+	if _, err := s.Repo.ExpirePending(ctx, ticketID); err != nil {
+		return err
+	}
+	survey := &domain.CSATSurvey{
+		TicketID:  ticketID,
+		UserID:    ticket.UserID,
+		Token:     utils.GenerateRandomToken(64),
+		CSHash:    utils.AnonymizeID(csID),
+		Category:  ticket.Category,
+		Status:    domain.CSATPending,
+		ExpiresAt: time.Now().Add(CSATLinkTTL),
+	}
+	if err := s.Repo.Create(ctx, survey); err != nil {
+		return err
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, 0, domain.RoleSystem, "CSAT_REQUESTED", "SUCCESS",
+		fmt.Sprintf("Survey #%d valid until %s", survey.ID, survey.ExpiresAt.UTC().Format(time.RFC3339)))
+
+	surveyURL := fmt.Sprintf("http://localhost:3000/csat/%s", survey.Token)
+	s.emit(ctx, TicketEvent{TicketID: ticketID, Type: EventCSATRequested, Link: surveyURL})
+	return nil
+}
+
+// GetSurvey dipanggil saat User membuka link survei
+func (s *CSATService) GetSurvey(ctx context.Context, token string) (*domain.CSATSurvey, error) {
+	survey, err := s.Repo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, ErrCSATNotFound
+	}
+	if survey.Status != domain.CSATPending || time.Now().After(survey.ExpiresAt) {
+		return nil, ErrCSATClosed
+	}
+	return survey, nil
+}
+
+// SubmitRating menyimpan penilaian 1-5 + komentar. Link hangus setelah dipakai.
+func (s *CSATService) SubmitRating(ctx context.Context, token string, rating int, comment string) error {
+	// 1. Validasi input
+	if rating < 1 || rating > 5 {
+		return errors.New("rating must be between 1 and 5")
+	}
+	comment = strings.TrimSpace(comment)
+	if utf8.RuneCountInString(comment) > MaxCSATComment {
+		return fmt.Errorf("comment is too long (max %d characters)", MaxCSATComment)
+	}
+
+	survey, err := s.Repo.GetByToken(ctx, token)
+	if err != nil {
+		return ErrCSATNotFound
+	}
+
+	// 2. Simpan (atomic: hanya jika masih PENDING & belum kedaluwarsa)
+	now := time.Now()
+	survey.Status = domain.CSATSubmitted
+	survey.Rating = &rating
+	survey.Comment = comment
+	survey.SubmittedAt = &now
+	ok, err := s.Repo.Submit(ctx, survey)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.AuditSvc.LogActivity(ctx, survey.TicketID, survey.UserID, domain.RoleUser, "CSAT_SUBMITTED", "DENIED",
+			fmt.Sprintf("Survey #%d: link expired or already used", survey.ID))
+		return ErrCSATClosed
+	}
+
+	// 3. Audit (tanpa isi komentar)
+	s.AuditSvc.LogActivity(ctx, survey.TicketID, survey.UserID, domain.RoleUser, "CSAT_SUBMITTED", "SUCCESS",
+		fmt.Sprintf("Survey #%d: rating %d, comment: %t", survey.ID, rating, comment != ""))
+	return nil
+}
+
+// BuildReport menyusun ringkasan CSAT (per agent pseudonym & per kategori) untuk survei yang dikirim di [from, to]
+func (s *CSATService) BuildReport(ctx context.Context, from, to time.Time) (*domain.CSATReport, error) {
+	report := &domain.CSATReport{}
+
+	// 1. Survei terkirim & terisi
+	byStatus, err := s.Repo.CountByStatus(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range byStatus {
+		report.Requested += count
+	}
+	report.Responses = byStatus[domain.CSATSubmitted]
+	if report.Requested > 0 {
+		report.ResponseRate = float64(report.Responses) / float64(report.Requested)
+	}
+
+	// 2. Distribusi rating
+	if report.Distribution, err = s.Repo.RatingDistribution(ctx, from, to); err != nil {
+		return nil, err
+	}
+	var sum, satisfied int64
+	for rating, count := range report.Distribution {
+		sum += int64(rating) * count
+		if rating >= 4 {
+			satisfied += count
+		}
+	}
+	if report.Responses > 0 {
+		report.AvgRating = float64(sum) / float64(report.Responses)
+		report.SatisfiedRate = float64(satisfied) / float64(report.Responses)
+	}
+
+	// 3. Per pseudonym CS & per kategori
+	if report.ByAgent, err = s.groupStats(ctx, from, to, "cs_hash"); err != nil {
+		return nil, err
+	}
+	if report.ByCategory, err = s.groupStats(ctx, from, to, "category"); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *CSATService) groupStats(ctx context.Context, from, to time.Time, column string) ([]domain.CSATGroupStats, error) {
+	groups, err := s.Repo.GroupStats(ctx, from, to, column)
+	for i := range groups {
+		if groups[i].Responses > 0 {
+			groups[i].SatisfiedRate = float64(groups[i].Satisfied) / float64(groups[i].Responses)
+		}
+	}
+	return groups, err
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+)
+
+// Batas panjang catatan internal
+const maxNoteLength = 5000
+
+// ErrNoteAccessDenied: CS hanya boleh membaca / menulis catatan tiket yang sedang dipegangnya
+var ErrNoteAccessDenied = errors.New("access denied: you are not handling this ticket")
+
+// NoteService: Catatan internal tiket untuk CS & Supervisor. User tidak punya jalur baca sama sekali.
+type NoteService struct {
+	Repo       *repository.NoteRepository
+	TicketRepo *repository.TicketRepository
+	AuditSvc   *AuditService
+}
+
+func NewNoteService(repo *repository.NoteRepository, ticketRepo *repository.TicketRepository, auditSvc *AuditService) *NoteService {
+	return &NoteService{Repo: repo, TicketRepo: ticketRepo, AuditSvc: auditSvc}
+}
+
+// AddNote: CS / Supervisor menambahkan catatan internal (opsional menyebut agent lain)
+func (s *NoteService) AddNote(ctx context.Context, ticketID, authorID uint, role, body string, mentionIDs []uint) (*domain.TicketNote, error) {
+	// 1. Hanya staf internal yang boleh menulis
+	if role != domain.RoleCS && role != domain.RoleSupervisor {
+		return nil, errors.New("access denied")
+	}
+	if _, err := s.TicketRepo.GetByID(ctx, ticketID); err != nil {
+		return nil, errors.New("ticket not found")
+	}
+	if err := s.authorizeTicket(ctx, ticketID, authorID, role, "NOTE_ADDED"); err != nil {
+		return nil, err
+	}
+
+	// 2. Validasi isi & mention
+	body, err := validateNoteBody(body)
+	if err != nil {
+		return nil, err
+	}
+	mentionIDs, err = s.validateMentions(ctx, mentionIDs, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Simpan
+	note := &domain.TicketNote{
+		TicketID:   ticketID,
+		AuthorID:   authorID,
+		AuthorRole: role,
+		Body:       body,
+		Revision:   1,
+	}
+	for _, id := range mentionIDs {
+		note.Mentions = append(note.Mentions, domain.TicketNoteMention{MentionedID: id})
+	}
+	if err := s.Repo.CreateNote(ctx, note); err != nil {
+		return nil, err
+	}
+
+	// 4. Audit (isi catatan tidak ditulis ke AuditLog)
+	s.AuditSvc.LogActivity(ctx, ticketID, authorID, role, "NOTE_ADDED", "SUCCESS",
+		fmt.Sprintf("note #%d, mentions: %d", note.ID, len(mentionIDs)))
+	return note, nil
+}
+
+// EditNote: Hanya penulis yang boleh mengedit; isi lama disimpan sebagai revisi
+func (s *NoteService) EditNote(ctx context.Context, noteID, editorID uint, role, body string, mentionIDs []uint) (*domain.TicketNote, error) {
+	note, err := s.Repo.GetNote(ctx, noteID)
+	if err != nil {
+		return nil, errors.New("note not found")
+	}
+	if note.AuthorID != editorID {
+		s.AuditSvc.LogActivity(ctx, note.TicketID, editorID, role, "NOTE_EDITED", "DENIED",
+			fmt.Sprintf("Reason: not the author of note #%d", note.ID))
+		return nil, errors.New("access denied: only the author can edit this note")
+	}
+	if err := s.authorizeTicket(ctx, note.TicketID, editorID, role, "NOTE_EDITED"); err != nil {
+		return nil, err
+	}
+
+	body, err = validateNoteBody(body)
+	if err != nil {
+		return nil, err
+	}
+	mentionIDs, err = s.validateMentions(ctx, mentionIDs, editorID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.Repo.UpdateNote(ctx, noteID, body, mentionIDs, editorID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogActivity(ctx, note.TicketID, editorID, role, "NOTE_EDITED", "SUCCESS",
+		fmt.Sprintf("note #%d -> revision %d", note.ID, updated.Revision))
+	return updated, nil
+}
+
+// GetNotes: Catatan tiket untuk CS pemegang tiket, Supervisor & Auditor
+func (s *NoteService) GetNotes(ctx context.Context, ticketID, requestorID uint, role string) ([]domain.TicketNote, error) {
+	if !canReadNotes(role) {
+		return nil, errors.New("access denied")
+	}
+	if err := s.authorizeTicket(ctx, ticketID, requestorID, role, "NOTE_READ"); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetNotesByTicket(ctx, ticketID)
+}
+
+// GetNoteRevisions: Riwayat edit satu catatan (aturan akses sama dengan GetNotes)
+func (s *NoteService) GetNoteRevisions(ctx context.Context, noteID, requestorID uint, role string) ([]domain.TicketNoteRevision, error) {
+	if !canReadNotes(role) {
+		return nil, errors.New("access denied")
+	}
+	note, err := s.Repo.GetNote(ctx, noteID)
+	if err != nil {
+		return nil, errors.New("note not found")
+	}
+	if err := s.authorizeTicket(ctx, note.TicketID, requestorID, role, "NOTE_READ"); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetRevisions(ctx, noteID)
+}
+
+// GetMentions: Catatan yang menyebut agent ini
+func (s *NoteService) GetMentions(ctx context.Context, agentID uint, q domain.ListQuery) (*domain.Page[domain.TicketNote], error) {
+	return s.Repo.ListMentions(ctx, agentID, q)
+}
+
+// GetTicketTimeline: AuditLog + catatan internal + riwayat edit, diurutkan berdasarkan waktu (untuk Auditor)
+func (s *NoteService) GetTicketTimeline(ctx context.Context, ticketID uint) ([]domain.TimelineEntry, error) {
+	logs, err := s.AuditSvc.Repo.GetLogsByTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.Repo.GetNotesByTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.Repo.GetRevisionsByTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := make([]domain.TimelineEntry, 0, len(logs)+len(notes)+len(revisions))
+	for i := range logs {
+		timeline = append(timeline, domain.TimelineEntry{Type: "AUDIT", Timestamp: logs[i].Timestamp, AuditLog: &logs[i]})
+	}
+	for i := range notes {
+		timeline = append(timeline, domain.TimelineEntry{Type: "NOTE", Timestamp: notes[i].CreatedAt, Note: &notes[i]})
+	}
+	for i := range revisions {
+		timeline = append(timeline, domain.TimelineEntry{Type: "NOTE_EDIT", Timestamp: revisions[i].CreatedAt, Revision: &revisions[i]})
+	}
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Timestamp.Before(timeline[j].Timestamp)
+	})
+	return timeline, nil
+}
+
+// authorizeTicket: CS hanya boleh mengakses catatan tiket yang sedang di-assign kepadanya
+// (Supervisor & Auditor tidak dibatasi assignment)
+func (s *NoteService) authorizeTicket(ctx context.Context, ticketID, actorID uint, role, action string) error {
+	if role != domain.RoleCS {
+		return nil
+	}
+	if assignedCS, err := s.TicketRepo.GetAssignedCS(ctx, ticketID); err != nil || assignedCS != actorID {
+		s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, action, "DENIED", "Reason: CS is not assigned to this ticket")
+		return ErrNoteAccessDenied
+	}
+	return nil
+}
+
+func canReadNotes(role string) bool {
+	return role == domain.RoleCS || role == domain.RoleSupervisor || role == domain.RoleAuditor
+}
+
+func validateNoteBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", errors.New("note body is required")
+	}
+	if len(body) > maxNoteLength {
+		return "", fmt.Errorf("note body exceeds %d characters", maxNoteLength)
+	}
+	return body, nil
+}
+
+// validateMentions: Hanya CS / Supervisor yang boleh disebut; duplikat & diri sendiri dibuang
+func (s *NoteService) validateMentions(ctx context.Context, mentionIDs []uint, authorID uint) ([]uint, error) {
+	seen := make(map[uint]bool)
+	var valid []uint
+	for _, id := range mentionIDs {
+		if seen[id] || id == authorID {
+			continue
+		}
+		seen[id] = true
+
+		user, err := s.TicketRepo.GetUserByID(ctx, id)
+		if err != nil || (!user.HasRole(domain.RoleCS) && !user.HasRole(domain.RoleSupervisor)) {
+			return nil, fmt.Errorf("mention %d is not a CS agent or supervisor", id)
+		}
+		valid = append(valid, id)
+	}
+	return valid, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+)
+
+// PermissionCacheTTL: Perubahan mapping dari instance lain terlihat paling lambat setelah rentang ini
+const PermissionCacheTTL = time.Minute
+
+var (
+	ErrUnknownPermission  = errors.New("unknown permission")
+	ErrUnknownRole        = errors.New("unknown role")
+	ErrOwnRoleMapping     = errors.New("admins cannot change the permissions of a role they hold")
+	ErrPermissionConflict = errors.New("permissions cannot be granted together")
+)
+
+// PermissionService: Resolusi role -> permission efektif dari tabel role_permissions (di-cache in-memory)
+type PermissionService struct {
+	Repo     *repository.PermissionRepository
+	AuditSvc *AuditService
+	TTL      time.Duration
+
+	mu       sync.RWMutex
+	mapping  map[string][]string
+	loadedAt time.Time
+}
+
+func NewPermissionService(repo *repository.PermissionRepository, auditSvc *AuditService) *PermissionService {
+	return &PermissionService{Repo: repo, AuditSvc: auditSvc, TTL: PermissionCacheTTL}
+}
+
+// Resolve menggabungkan permission semua role (hasil terurut, tanpa duplikat)
+func (s *PermissionService) Resolve(ctx context.Context, roles []string) ([]string, error) {
+	mapping, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var perms []string
+	for _, role := range roles {
+		for _, p := range mapping[role] {
+			if !slices.Contains(perms, p) {
+				perms = append(perms, p)
+			}
+		}
+	}
+	sort.Strings(perms)
+	return perms, nil
+}
+
+// Mapping: Salinan mapping role -> permission yang berlaku saat ini
+func (s *PermissionService) Mapping(ctx context.Context) (map[string][]string, error) {
+	mapping, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]string, len(mapping))
+	for role, perms := range mapping {
+		result[role] = slices.Clone(perms)
+	}
+	return result, nil
+}
+
+// SetRolePermissions mengganti permission satu role (ADMIN). ADMIN tidak boleh mengubah role yang dipegangnya sendiri
+// agar tidak bisa menaikkan hak aksesnya lewat mapping (mis. menambah audit:read ke ADMIN).
+func (s *PermissionService) SetRolePermissions(ctx context.Context, adminID uint, adminRoles []string, role string, permissions []string) ([]string, error) {
+	if !slices.Contains(domain.LoginRoles, role) {
+		return nil, ErrUnknownRole
+	}
+	if slices.Contains(adminRoles, role) {
+		s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_UPDATE_ROLE_PERMISSIONS", "DENIED",
+			fmt.Sprintf("own role %s", role))
+		return nil, ErrOwnRoleMapping
+	}
+
+	var perms []string
+	for _, p := range permissions {
+		if !slices.Contains(domain.Permissions, p) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, p)
+		}
+		if !slices.Contains(perms, p) {
+			perms = append(perms, p)
+		}
+	}
+	sort.Strings(perms)
+
+	if err := s.checkSeparation(ctx, role, perms); err != nil {
+		s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_UPDATE_ROLE_PERMISSIONS", "DENIED",
+			fmt.Sprintf("%s: %v", role, err))
+		return nil, err
+	}
+
+	// 1. Tabel masih kosong (memakai default) -> tulis default dulu agar role lain tidak kehilangan permission
+	if err := s.materializeDefaults(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.ReplaceRolePermissions(ctx, role, perms); err != nil {
+		return nil, err
+	}
+	s.Invalidate()
+
+	s.AuditSvc.LogEvent(ctx, adminID, domain.RoleAdmin, "ADMIN_UPDATE_ROLE_PERMISSIONS", "SUCCESS",
+		fmt.Sprintf("%s: %v", role, perms))
+	return perms, nil
+}
+
+// checkSeparation: USER hanya boleh UserPermissions; pasangan IncompatiblePermissions tidak boleh ada di role ini,
+// juga tidak di gabungan role ini dengan role staff lain yang boleh dipegang bersamaan oleh satu akun.
+func (s *PermissionService) checkSeparation(ctx context.Context, role string, perms []string) error {
+	if role == domain.RoleUser {
+		for _, p := range perms {
+			if !slices.Contains(domain.UserPermissions, p) {
+				return fmt.Errorf("%w: %s cannot be granted to %s", ErrPermissionConflict, p, domain.RoleUser)
+			}
+		}
+		return nil
+	}
+	if err := conflictingPermissions(perms); err != nil {
+		return err
+	}
+
+	mapping, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range domain.StaffRoles {
+		if other == role || slices.Contains(domain.IncompatibleRoles, [2]string{role, other}) ||
+			slices.Contains(domain.IncompatibleRoles, [2]string{other, role}) {
+			continue
+		}
+		if err := conflictingPermissions(append(slices.Clone(perms), mapping[other]...)); err != nil {
+			return fmt.Errorf("%w (combined with %s)", err, other)
+		}
+	}
+	return nil
+}
+
+func conflictingPermissions(perms []string) error {
+	for _, pair := range domain.IncompatiblePermissions {
+		if slices.Contains(perms, pair[0]) && slices.Contains(perms, pair[1]) {
+			return fmt.Errorf("%w: %s + %s", ErrPermissionConflict, pair[0], pair[1])
+		}
+	}
+	return nil
+}
+
+// Invalidate mengosongkan cache (dipanggil setelah mapping berubah)
+func (s *PermissionService) Invalidate() {
+	s.mu.Lock()
+	s.mapping = nil
+	s.mu.Unlock()
+}
+
+// load mengambil mapping dari cache / DB. Tabel kosong (belum di-seed) -> DefaultRolePermissions.
+func (s *PermissionService) load(ctx context.Context) (map[string][]string, error) {
+	s.mu.RLock()
+	mapping, fresh := s.mapping, time.Since(s.loadedAt) < s.TTL
+	s.mu.RUnlock()
+	if mapping != nil && fresh {
+		return mapping, nil
+	}
+
+	rows, err := s.Repo.ListRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		mapping = domain.DefaultRolePermissions
+	} else {
+		mapping = make(map[string][]string)
+		for _, row := range rows {
+			mapping[row.Role] = append(mapping[row.Role], row.Permission)
+		}
+	}
+
+	s.mu.Lock()
+	s.mapping, s.loadedAt = mapping, time.Now()
+	s.mu.Unlock()
+	return mapping, nil
+}
+
+func (s *PermissionService) materializeDefaults(ctx context.Context) error {
+	rows, err := s.Repo.ListRolePermissions(ctx)
+	if err != nil || len(rows) > 0 {
+		return err
+	}
+	for role, perms := range domain.DefaultRolePermissions {
+		if err := s.Repo.ReplaceRolePermissions(ctx, role, perms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
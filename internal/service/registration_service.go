@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/mail"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// EmailTokenTTL: Masa berlaku link konfirmasi email
+	EmailTokenTTL = 24 * time.Hour
+	// MaxEmailTokensPerHour: Batas kirim ulang link konfirmasi per akun
+	MaxEmailTokensPerHour = 3
+
+	// Risk score awal akun self-service (akun seed / staff mulai dari 0-10; >= 80 memblokir verifikasi)
+	RegistrationBaseRisk      = 30
+	RegistrationGeneratedRisk = 15 // Bagian lokal email mirip hasil generator (>= 5 digit)
+	EmailConfirmedRiskCredit  = 10 // Dikurangi setelah email terbukti dimiliki
+)
+
+var ErrDisposableEmail = errors.New("disposable email addresses are not allowed")
+
+// disposableDomains: Domain email sekali pakai yang umum. Tambahan lewat DISPOSABLE_EMAIL_DOMAINS (dipisah koma).
+var disposableDomains = map[string]bool{
+	"mailinator.com": true, "guerrillamail.com": true, "10minutemail.com": true, "tempmail.com": true,
+	"temp-mail.org": true, "yopmail.com": true, "trashmail.com": true, "sharklasers.com": true,
+	"getnada.com": true, "dispostable.com": true, "maildrop.cc": true, "throwawaymail.com": true,
+	"fakeinbox.com": true, "mailnesia.com": true, "mintemail.com": true, "emailondeck.com": true,
+}
+
+type RegistrationService struct {
+	Repo     *repository.UserRepository
+	AuditSvc *AuditService
+	Mailer   mail.Sender
+
+	blockedDomains map[string]bool
+}
+
+func NewRegistrationService(repo *repository.UserRepository, auditSvc *AuditService, mailer mail.Sender) *RegistrationService {
+	blocked := make(map[string]bool, len(disposableDomains))
+	for d := range disposableDomains {
+		blocked[d] = true
+	}
+	for _, d := range strings.Split(os.Getenv("DISPOSABLE_EMAIL_DOMAINS"), ",") {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			blocked[d] = true
+		}
+	}
+	return &RegistrationService{Repo: repo, AuditSvc: auditSvc, Mailer: mailer, blockedDomains: blocked}
+}
+
+// Register membuat akun USER berstatus PENDING_VERIFICATION dan mengirim link konfirmasi.
+// Email yang sudah terdaftar TIDAK menghasilkan error (mencegah enumerasi akun): pemiliknya
+// menerima email pemberitahuan / link baru, response ke client tetap sama.
+func (s *RegistrationService) Register(ctx context.Context, email, password, displayName string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	displayName, err := NormalizeDisplayName(displayName)
+	if err != nil {
+		return err
+	}
+
+	// 1. Kebijakan: domain sekali pakai & kekuatan password
+	if s.isDisposable(email) {
+		s.AuditSvc.LogEvent(ctx, 0, domain.RoleUser, "REGISTER", "DENIED", "Disposable email domain")
+		return ErrDisposableEmail
+	}
+	if err := utils.CheckPasswordPolicy(password, email); err != nil {
+		return err
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// 2. Email sudah terdaftar
+	existing, err := s.Repo.GetByEmail(ctx, email)
+	if err == nil {
+		return s.handleExisting(ctx, existing, hash, displayName)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// 3. Buat akun (belum bisa login sampai email dikonfirmasi)
+	user := &domain.User{
+		Email:        email,
+		DisplayName:  displayName,
+		PasswordHash: hash,
+		Role:         domain.RoleUser,
+		RiskScore:    initialRiskScore(email),
+		Status:       domain.UserPendingVerification,
+	}
+	if err := s.Repo.Create(ctx, user); err != nil {
+		// Registrasi paralel dengan email sama: unique index menolak, perlakukan sebagai sudah terdaftar
+		if existing, lookupErr := s.Repo.GetByEmail(ctx, email); lookupErr == nil {
+			return s.handleExisting(ctx, existing, hash, displayName)
+		}
+		return err
+	}
+
+	s.AuditSvc.LogEvent(ctx, user.ID, domain.RoleUser, "REGISTER", "SUCCESS",
+		fmt.Sprintf("Self-service registration, initial risk score %d", user.RiskScore))
+	return s.sendConfirmation(ctx, user)
+}
+
+// ResendConfirmation mengirim ulang link konfirmasi (selalu sukses dari sisi client)
+func (s *RegistrationService) ResendConfirmation(ctx context.Context, email string) error {
+	user, err := s.Repo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	// Akun staff undangan (PENDING_VERIFICATION) diaktifkan lewat AcceptInvite, bukan link konfirmasi
+	if err != nil || user.Status != domain.UserPendingVerification || user.Role != domain.RoleUser {
+		return nil
+	}
+	return s.sendConfirmation(ctx, user)
+}
+
+// ConfirmEmail mengaktifkan akun dari link konfirmasi (token sekali pakai)
+func (s *RegistrationService) ConfirmEmail(ctx context.Context, token string) (*domain.User, error) {
+	user, err := s.Repo.ConfirmEmail(ctx, token, EmailConfirmedRiskCredit)
+	if err != nil {
+		return nil, err
+	}
+	s.AuditSvc.LogEvent(ctx, user.ID, domain.RoleUser, "EMAIL_CONFIRMED", "SUCCESS",
+		fmt.Sprintf("Account activated, risk score %d", user.RiskScore))
+	return user, nil
+}
+
+// AcceptInvite: Staff undangan ADMIN menetapkan password sendiri lalu akun aktif (token sekali pakai)
+func (s *RegistrationService) AcceptInvite(ctx context.Context, token, password string) (*domain.User, error) {
+	invited, err := s.Repo.GetInviteUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.CheckPasswordPolicy(password, invited.Email); err != nil {
+		return nil, err
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.Repo.AcceptInvite(ctx, token, hash)
+	if err != nil {
+		return nil, err
+	}
+	s.AuditSvc.LogEvent(ctx, user.ID, user.Role, "STAFF_INVITE_ACCEPTED", "SUCCESS", "Password set, account activated")
+	return user, nil
+}
+
+// handleExisting: Email sudah terdaftar. Akun yang belum dikonfirmasi mengikuti pendaftaran terbaru: password diganti
+// & link lama dihanguskan, sehingga pendaftar sebelumnya (mis. penyerang yang mendaftarkan email korban lebih dulu)
+// tidak bisa memakai password-nya setelah pemilik email mengkonfirmasi.
+func (s *RegistrationService) handleExisting(ctx context.Context, user *domain.User, passwordHash, displayName string) error {
+	if user.Status == domain.UserPendingVerification && user.Role == domain.RoleUser {
+		limited, err := s.rateLimited(ctx, user)
+		if err != nil || limited {
+			return err
+		}
+		if err := s.Repo.ResetPendingRegistration(ctx, user.ID, passwordHash, displayName); errors.Is(err, repository.ErrTokenInvalid) {
+			return nil // Dikonfirmasi bersamaan: perlakukan seperti akun aktif (response tetap sama)
+		} else if err != nil {
+			return err
+		}
+		s.AuditSvc.LogEvent(ctx, user.ID, domain.RoleUser, "REGISTER", "SUCCESS",
+			"Re-registration of unconfirmed account: password replaced, previous links revoked")
+		return s.sendConfirmation(ctx, user)
+	}
+	s.AuditSvc.LogEvent(ctx, user.ID, user.Role, "REGISTER", "DENIED", "Email already registered")
+	s.send(ctx, user.Email, "Percobaan pendaftaran akun",
+		"Seseorang mencoba mendaftar dengan alamat email ini, tetapi akun Anda sudah ada.\n"+
+			"Jika itu Anda, silakan login atau gunakan fitur reset password. Jika bukan, abaikan email ini.")
+	return nil
+}
+
+// sendConfirmation membuat token baru & mengirim link (dibatasi MaxEmailTokensPerHour)
+func (s *RegistrationService) sendConfirmation(ctx context.Context, user *domain.User) error {
+	limited, err := s.rateLimited(ctx, user)
+	if err != nil || limited {
+		return err
+	}
+
+	token := &domain.EmailVerificationToken{
+		UserID:    user.ID,
+		Token:     utils.GenerateRandomToken(64),
+		Purpose:   domain.TokenConfirmEmail,
+		ExpiresAt: time.Now().Add(EmailTokenTTL),
+	}
+	if err := s.Repo.CreateEmailToken(ctx, token); err != nil {
+		return err
+	}
+
+	confirmURL := fmt.Sprintf("http://localhost:3000/register/confirm/%s", token.Token)
+	s.send(ctx, user.Email, "Konfirmasi email akun Anda",
+		"Klik link berikut untuk mengaktifkan akun Anda (berlaku 24 jam):\n"+confirmURL+"\n\n"+
+			"Setelah login, Anda akan diminta mengisi jawaban verifikasi pribadi.")
+	s.AuditSvc.LogEvent(ctx, user.ID, domain.RoleUser, "EMAIL_CONFIRMATION_SENT", "SUCCESS",
+		fmt.Sprintf("Token #%d valid until %s", token.ID, token.ExpiresAt.UTC().Format(time.RFC3339)))
+	return nil
+}
+
+// rateLimited: true (dan diaudit) jika link konfirmasi sudah dikirim MaxEmailTokensPerHour kali dalam 1 jam terakhir
+func (s *RegistrationService) rateLimited(ctx context.Context, user *domain.User) (bool, error) {
+	count, err := s.Repo.CountEmailTokensSince(ctx, user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return false, err
+	}
+	if count >= MaxEmailTokensPerHour {
+		s.AuditSvc.LogEvent(ctx, user.ID, domain.RoleUser, "EMAIL_CONFIRMATION_SENT", "DENIED", "Rate limit exceeded")
+		return true, nil
+	}
+	return false, nil
+}
+
+// send: Gagal kirim tidak menggagalkan request (User bisa minta kirim ulang)
+func (s *RegistrationService) send(ctx context.Context, to, subject, body string) {
+	if err := s.Mailer.Send(ctx, to, subject, body); err != nil {
+		log.Printf("registration mail not sent: %v", err)
+	}
+}
+
+// isDisposable: Domain (atau subdomain dari) domain sekali pakai
+func (s *RegistrationService) isDisposable(email string) bool {
+	_, host, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	for {
+		if s.blockedDomains[host] {
+			return true
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return false
+		}
+		host = parent
+	}
+}
+
+// initialRiskScore: Akun baru belum punya riwayat, jadi mulai dari skor dasar + sinyal sederhana
+func initialRiskScore(email string) int {
+	score := RegistrationBaseRisk
+	local, _, _ := strings.Cut(email, "@")
+	digits := 0
+	for _, r := range local {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits >= 5 {
+		score += RegistrationGeneratedRisk
+	}
+	return score
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+// Mode routing tiket
+const (
+	RoutingManual      = "MANUAL"       // CS claim sendiri dari antrian (default)
+	RoutingRoundRobin  = "ROUND_ROBIN"  // Agent yang paling lama tidak menerima tiket
+	RoutingLeastLoaded = "LEAST_LOADED" // Agent dengan tiket aktif paling sedikit
+)
+
+type RoutingService struct {
+	AgentRepo  *repository.AgentRepository
+	TicketRepo *repository.TicketRepository
+	AuditSvc   *AuditService
+	Mode       string
+
+	backlog chan struct{} // Sinyal route backlog (buffer 1: banyak pemicu digabung jadi satu putaran)
+
+	TicketEventHooks
+}
+
+func NewRoutingService(agentRepo *repository.AgentRepository, ticketRepo *repository.TicketRepository, auditSvc *AuditService, mode string) *RoutingService {
+	switch mode {
+	case RoutingRoundRobin, RoutingLeastLoaded:
+	default:
+		mode = RoutingManual
+	}
+	return &RoutingService{AgentRepo: agentRepo, TicketRepo: ticketRepo, AuditSvc: auditSvc, Mode: mode, backlog: make(chan struct{}, 1)}
+}
+
+// Enabled: true jika auto-assignment aktif
+func (s *RoutingService) Enabled() bool {
+	return s != nil && s.Mode != RoutingManual
+}
+
+// RouteTicket memilih agent untuk tiket OPEN. Mengembalikan 0 jika tidak ada agent yang cocok
+// (tiket tetap di antrian dan dicoba lagi saat ada agent AVAILABLE / kapasitas kosong).
+func (s *RoutingService) RouteTicket(ctx context.Context, ticket *domain.Ticket) (uint, error) {
+	if !s.Enabled() || ticket.Status != domain.TicketOpen {
+		return 0, nil
+	}
+
+	// 1. Kandidat: AVAILABLE + skill kategori + bahasa
+	profiles, err := s.AgentRepo.GetAvailableProfiles(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var candidates []domain.AgentProfile
+	for _, p := range profiles {
+		if matchesList(p.Skills, ticket.Category) && matchesList(p.Languages, ticket.Language) {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	// 2. Buang agent yang kapasitasnya penuh
+	ids := make([]uint, len(candidates))
+	for i, p := range candidates {
+		ids[i] = p.UserID
+	}
+	loads, err := s.AgentRepo.CountActiveByAgents(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	// 3. Pilih sesuai mode
+	var chosen *domain.AgentProfile
+	for i := range candidates {
+		p := &candidates[i]
+		if loads[p.UserID] >= int64(p.EffectiveCapacity()) {
+			continue
+		}
+		if chosen == nil || s.better(p, chosen, loads) {
+			chosen = p
+		}
+	}
+	if chosen == nil {
+		return 0, nil
+	}
+
+	// 4. Assign (atomic: gagal jika tiket sudah diambil)
+	if err := s.TicketRepo.AutoAssignTicket(ctx, ticket.ID, chosen.UserID); err != nil {
+		return 0, err
+	}
+	s.AgentRepo.TouchLastAssigned(ctx, chosen.UserID, time.Now())
+
+	s.AuditSvc.LogActivity(ctx, ticket.ID, 0, domain.RoleSystem, "AUTO_ROUTE", "SUCCESS",
+		fmt.Sprintf("%s -> %s (category=%s, language=%s)", s.Mode, utils.AnonymizeID(chosen.UserID), ticket.Category, ticket.Language))
+	s.emit(ctx, TicketEvent{TicketID: ticket.ID, Type: EventTicketAssigned})
+	return chosen.UserID, nil
+}
+
+// RouteOpenTickets mencoba me-route backlog antrian (dipanggil saat agent AVAILABLE / kapasitas kosong)
+func (s *RoutingService) RouteOpenTickets(ctx context.Context) (int, error) {
+	if !s.Enabled() {
+		return 0, nil
+	}
+	tickets, err := s.TicketRepo.GetOpenTickets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	routed := 0
+	for i := range tickets {
+		csID, err := s.RouteTicket(ctx, &tickets[i])
+		if err != nil {
+			continue
+		}
+		if csID != 0 {
+			routed++
+		}
+	}
+	return routed, nil
+}
+
+// TriggerBacklog meminta worker me-route backlog antrian tanpa menahan request (tidak pernah blocking;
+// pemicu saat putaran masih berjalan digabung menjadi satu putaran berikutnya)
+func (s *RoutingService) TriggerBacklog() {
+	if !s.Enabled() {
+		return
+	}
+	select {
+	case s.backlog <- struct{}{}:
+	default:
+	}
+}
+
+// StartBacklogWorker menjalankan RouteOpenTickets saat dipicu (TriggerBacklog) dan berkala sebagai jaring pengaman,
+// sampai ctx dibatalkan (jalankan sebagai goroutine). Hanya satu putaran berjalan dalam satu waktu.
+func (s *RoutingService) StartBacklogWorker(ctx context.Context, interval time.Duration) {
+	if !s.Enabled() {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.backlog:
+		case <-ticker.C:
+		}
+		if _, err := s.RouteOpenTickets(ctx); err != nil {
+			log.Printf("Routing backlog error: %v", err)
+		}
+	}
+}
+
+// SetPresence: CS mengubah status AVAILABLE / AWAY / OFFLINE
+func (s *RoutingService) SetPresence(ctx context.Context, csID uint, presence string) (*domain.AgentProfile, error) {
+	switch presence {
+	case domain.PresenceAvailable, domain.PresenceAway, domain.PresenceOffline:
+	default:
+		return nil, errors.New("invalid presence status")
+	}
+
+	profile, err := s.AgentRepo.GetProfile(ctx, csID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Presence = presence
+	if err := s.AgentRepo.SaveProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	if presence == domain.PresenceAvailable {
+		s.TriggerBacklog()
+	}
+	return profile, nil
+}
+
+// GetProfile profil agent (CS melihat dirinya sendiri)
+func (s *RoutingService) GetProfile(ctx context.Context, csID uint) (*domain.AgentProfile, error) {
+	return s.AgentRepo.GetProfile(ctx, csID)
+}
+
+// GetProfiles semua profil agent (Supervisor)
+func (s *RoutingService) GetProfiles(ctx context.Context) ([]domain.AgentProfile, error) {
+	return s.AgentRepo.GetProfiles(ctx)
+}
+
+// UpdateProfile: Supervisor mengatur skill & bahasa agent (kapasitas diatur lewat CapacityService)
+func (s *RoutingService) UpdateProfile(ctx context.Context, csID uint, skills, languages []string, supervisorID uint) (*domain.AgentProfile, error) {
+	user, err := s.TicketRepo.GetUserByID(ctx, csID)
+	if err != nil || !user.HasRole(domain.RoleCS) {
+		return nil, errors.New("target is not a CS agent")
+	}
+
+	profile, err := s.AgentRepo.GetProfile(ctx, csID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Skills = normalizeList(skills, strings.ToUpper)
+	profile.Languages = normalizeList(languages, strings.ToLower)
+	if err := s.AgentRepo.SaveProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogEvent(ctx, supervisorID, domain.RoleSupervisor, "AGENT_PROFILE_UPDATE", "SUCCESS",
+		fmt.Sprintf("%s skills=%s languages=%s", utils.AnonymizeID(csID), profile.Skills, profile.Languages))
+	return profile, nil
+}
+
+// better: apakah kandidat a lebih baik dari b sesuai mode routing
+func (s *RoutingService) better(a, b *domain.AgentProfile, loads map[uint]int64) bool {
+	if s.Mode == RoutingLeastLoaded && loads[a.UserID] != loads[b.UserID] {
+		return loads[a.UserID] < loads[b.UserID]
+	}
+	// Round-robin (dan tie-breaker least-loaded): yang paling lama tidak menerima tiket
+	if a.LastAssignedAt == nil || b.LastAssignedAt == nil {
+		return a.LastAssignedAt == nil && b.LastAssignedAt != nil
+	}
+	return a.LastAssignedAt.Before(*b.LastAssignedAt)
+}
+
+// matchesList: nilai ada di list dipisah koma ("*" = cocok semua)
+func matchesList(list, value string) bool {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeList(items []string, transform func(string) string) string {
+	var cleaned []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			cleaned = append(cleaned, transform(item))
+		}
+	}
+	return strings.Join(cleaned, ",")
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/envelope"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/internal/storage"
+)
+
+// ShredService: Crypto-shredding data tiket (hapus data key -> chat, catatan internal & revisinya tidak terbaca lagi,
+// termasuk di arsip). Lampiran tidak dienkripsi per tiket, jadi metadata & blob-nya dihapus langsung.
+type ShredService struct {
+	Keyring        *envelope.Keyring
+	TicketRepo     *repository.TicketRepository
+	ChatRepo       *repository.ChatRepository
+	AttachmentRepo *repository.AttachmentRepository
+	Store          storage.BlobStore
+	AuditSvc       *AuditService
+}
+
+func NewShredService(keyring *envelope.Keyring, ticketRepo *repository.TicketRepository, chatRepo *repository.ChatRepository,
+	attachmentRepo *repository.AttachmentRepository, store storage.BlobStore, auditSvc *AuditService) *ShredService {
+	return &ShredService{Keyring: keyring, TicketRepo: ticketRepo, ChatRepo: chatRepo, AttachmentRepo: attachmentRepo, Store: store, AuditSvc: auditSvc}
+}
+
+// ShredTicket: Hanya tiket CLOSED tanpa legal hold. Tidak bisa dibatalkan.
+func (s *ShredService) ShredTicket(ctx context.Context, ticketID, actorID uint, reason string) error {
+	// 1. Validasi tiket
+	ticket, err := s.TicketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return errors.New("ticket not found")
+	}
+	if ticket.Status != domain.TicketClosed || ticket.LegalHold {
+		s.AuditSvc.LogActivity(ctx, ticketID, actorID, domain.RoleSupervisor, "CRYPTO_SHRED", "DENIED",
+			"Reason: ticket must be CLOSED and not under legal hold")
+		return errors.New("only closed tickets without legal hold can be shredded")
+	}
+
+	// 2. Hapus data key tiket
+	if err := s.Keyring.Shred(ctx, envelope.ScopeTicket, ticketID); err != nil {
+		return err
+	}
+
+	// 3. Nilai asli DLP dienkripsi dengan kunci sistem, jadi dihapus langsung
+	removed, err := s.ChatRepo.DeleteRedactions(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+
+	// 4. Lampiran: metadata (listing & signed URL) dihapus, blob dihapus jika tidak dipakai tiket lain
+	attachments, orphaned, err := s.AttachmentRepo.DeleteByTicket(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	for _, key := range orphaned {
+		if err := s.Store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, actorID, domain.RoleSupervisor, "CRYPTO_SHRED", "SUCCESS",
+		fmt.Sprintf("ticket data key destroyed, %d redaction(s) removed, %d attachment(s) / %d blob(s) deleted, reason: %s",
+			removed, attachments, len(orphaned), reason))
+	return nil
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/oidc"
+	"github.com/syukurgit/zta/internal/repository"
+	"gorm.io/gorm"
+)
+
+// SSOStateTTL: Batas waktu antara redirect ke IdP dan callback
+const SSOStateTTL = 10 * time.Minute
+
+var (
+	ErrSSODenied = errors.New("this SSO account is not allowed to sign in")
+	ErrSSOFailed = errors.New("SSO login failed")
+)
+
+// GroupRole: Satu baris mapping grup IdP -> role staff (OIDC_GROUP_ROLES)
+type GroupRole struct {
+	Group string
+	Role  string
+}
+
+// ParseGroupRoles membaca "grup=ROLE,grup=ROLE". Urutan = prioritas role utama jika user ada di beberapa grup.
+func ParseGroupRoles(spec string) ([]GroupRole, error) {
+	var mapping []GroupRole
+	for _, item := range strings.Split(spec, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(item, "=")
+		group, role = strings.TrimSpace(group), strings.ToUpper(strings.TrimSpace(role))
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid group mapping %q (expected group=ROLE)", item)
+		}
+		if !slices.Contains(domain.StaffRoles, role) {
+			return nil, fmt.Errorf("group %s: %w", group, ErrInvalidRole)
+		}
+		mapping = append(mapping, GroupRole{Group: group, Role: role})
+	}
+	return mapping, nil
+}
+
+// SSOLogin: Hasil login SSO
+type SSOLogin struct {
+	User    *domain.User
+	Session *SessionToken
+}
+
+// SSOService: Login staff lewat OpenID Connect (authorization code + PKCE), mapping grup -> role
+// dan provisioning just-in-time. Role akun SSO selalu mengikuti grup IdP saat login.
+type SSOService struct {
+	Client     *oidc.Client
+	Repo       *repository.SSORepository
+	Users      *repository.UserRepository
+	Sessions   *repository.SessionRepository
+	Accounts   *AccountService
+	AuditSvc   *AuditService
+	GroupRoles []GroupRole
+}
+
+func NewSSOService(client *oidc.Client, repo *repository.SSORepository, users *repository.UserRepository, sessions *repository.SessionRepository,
+	accounts *AccountService, auditSvc *AuditService, groupRoles []GroupRole) *SSOService {
+	return &SSOService{Client: client, Repo: repo, Users: users, Sessions: sessions, Accounts: accounts, AuditSvc: auditSvc, GroupRoles: groupRoles}
+}
+
+// Begin menyimpan state + nonce + code_verifier lalu mengembalikan URL authorize IdP beserta state
+// (state juga diikat ke browser lewat cookie oleh handler, cegah login CSRF)
+func (s *SSOService) Begin(ctx context.Context, loginHint string) (string, string, error) {
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.Repo.SaveState(ctx, &domain.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(SSOStateTTL),
+	}); err != nil {
+		return "", "", err
+	}
+	redirectURL, err := s.Client.AuthCodeURL(ctx, state, nonce, challenge, loginHint)
+	return redirectURL, state, err
+}
+
+// Complete: Callback IdP -> verifikasi code & ID token, cari / tautkan / buat akun, sinkron role, buat sesi
+func (s *SSOService) Complete(ctx context.Context, code, state, ip, userAgent string) (*SSOLogin, error) {
+	// 1. State sekali pakai -> nonce & code_verifier milik login ini
+	loginState, err := s.Repo.ConsumeState(ctx, state)
+	if errors.Is(err, repository.ErrLoginStateInvalid) {
+		return nil, fmt.Errorf("%w: %v", ErrSSOFailed, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Tukar code + verifikasi ID token
+	identity, err := s.Client.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		s.AuditSvc.LogEvent(ctx, 0, domain.RoleSystem, "LOGIN_SSO", "FAILED", truncate(err.Error(), 255))
+		return nil, fmt.Errorf("%w: %v", ErrSSOFailed, err)
+	}
+	subject := fmt.Sprintf("sub=%s", identity.Subject)
+
+	// 3. Grup IdP -> role staff (tanpa grup yang di-mapping = tidak punya akses)
+	roles, err := s.mapRoles(identity.Groups)
+	if err != nil {
+		s.AuditSvc.LogEvent(ctx, 0, domain.RoleSystem, "LOGIN_SSO", "DENIED", fmt.Sprintf("%s: %v", subject, err))
+		return nil, ErrSSODenied
+	}
+
+	// 4. Akun lokal: identitas tertaut / tautkan akun staff lama / provisioning baru
+	user, err := s.resolveUser(ctx, identity, roles)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != domain.UserActive {
+		s.AuditSvc.LogEvent(ctx, user.ID, user.Role, "LOGIN_SSO", "DENIED", fmt.Sprintf("account status %s", user.Status))
+		return nil, ErrSSODenied
+	}
+
+	// 5. Role mengikuti IdP (perubahan role oleh ADMIN ditimpa saat login SSO berikutnya)
+	if err := s.syncRoles(ctx, user, roles); err != nil {
+		return nil, err
+	}
+
+	// 6. Sesi (MFA ditangani IdP)
+	idpMFA := slices.Contains(identity.AMR, "mfa")
+	session, err := s.Accounts.StartSSOSession(ctx, user, idpMFA, ip, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	s.AuditSvc.LogEvent(ctx, user.ID, user.Role, "LOGIN_SSO", "SUCCESS",
+		fmt.Sprintf("%s, roles %v, idp_mfa=%t", subject, user.AllRoles(), idpMFA))
+	return &SSOLogin{User: user, Session: session}, nil
+}
+
+// mapRoles: Role dari grup (urutan GroupRoles), divalidasi seperti perubahan role oleh ADMIN
+func (s *SSOService) mapRoles(groups []string) ([]string, error) {
+	var roles []string
+	for _, m := range s.GroupRoles {
+		if slices.Contains(groups, m.Group) {
+			roles = append(roles, m.Role)
+		}
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("no mapped group in %v", groups)
+	}
+	return validateStaffRoles(roles)
+}
+
+func (s *SSOService) resolveUser(ctx context.Context, identity *oidc.Identity, roles []string) (*domain.User, error) {
+	subject := fmt.Sprintf("sub=%s", identity.Subject)
+
+	// 1. Sudah pernah login SSO
+	link, err := s.Repo.GetIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		user, err := s.Users.GetByID(ctx, link.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Repo.TouchIdentity(ctx, link.ID, time.Now()); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" {
+		s.AuditSvc.LogEvent(ctx, 0, domain.RoleSystem, "LOGIN_SSO", "DENIED", subject+": ID token has no email")
+		return nil, ErrSSODenied
+	}
+	now := time.Now()
+	newLink := &domain.UserIdentity{Issuer: identity.Issuer, Subject: identity.Subject, LastLoginAt: &now}
+
+	// 2. Email sudah terdaftar: hanya akun staff lokal, dengan email terverifikasi di IdP (cegah pengambilalihan akun)
+	existing, err := s.Users.GetByEmail(ctx, email)
+	if err == nil {
+		switch {
+		case !slices.Contains(domain.StaffRoles, existing.Role):
+			s.AuditSvc.LogEvent(ctx, existing.ID, existing.Role, "SSO_ACCOUNT_LINKED", "DENIED", subject+": not a staff account")
+			return nil, ErrSSODenied
+		case !identity.EmailVerified:
+			s.AuditSvc.LogEvent(ctx, existing.ID, existing.Role, "SSO_ACCOUNT_LINKED", "DENIED", subject+": email not verified by IdP")
+			return nil, ErrSSODenied
+		case existing.AuthProvider == domain.AuthOIDC:
+			s.AuditSvc.LogEvent(ctx, existing.ID, existing.Role, "SSO_ACCOUNT_LINKED", "DENIED", subject+": already linked to another identity")
+			return nil, ErrSSODenied
+		}
+		// Akun dengan TOTP lokal tidak boleh turun jadi satu faktor: tautan hanya jika IdP menyatakan MFA
+		mfaEnabled, err := s.Accounts.GetMFAStatus(ctx, existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		if mfaEnabled && !slices.Contains(identity.AMR, "mfa") {
+			s.AuditSvc.LogEvent(ctx, existing.ID, existing.Role, "SSO_ACCOUNT_LINKED", "DENIED", subject+": local MFA enrolled but IdP did not assert mfa")
+			return nil, ErrSSODenied
+		}
+		if err := s.Repo.LinkIdentity(ctx, existing.ID, newLink); err != nil {
+			return nil, err
+		}
+		// Password lokal tidak berlaku lagi -> sesi hasil login password dicabut
+		revoked, err := s.Sessions.RevokeAllForUser(ctx, existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		existing.AuthProvider, existing.PasswordHash = domain.AuthOIDC, ""
+		s.AuditSvc.LogEvent(ctx, existing.ID, existing.Role, "SSO_ACCOUNT_LINKED", "SUCCESS",
+			fmt.Sprintf("%s, local password disabled, %d sessions revoked", subject, revoked))
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// 3. Provisioning just-in-time (tanpa password lokal)
+	user := &domain.User{
+		Email:        email,
+		Role:         roles[0],
+		Status:       domain.UserActive,
+		AuthProvider: domain.AuthOIDC,
+	}
+	if identity.EmailVerified {
+		user.EmailVerifiedAt = &now
+	}
+	for _, role := range roles[1:] {
+		user.ExtraRoles = append(user.ExtraRoles, domain.UserRole{Role: role})
+	}
+	if err := s.Repo.CreateSSOUser(ctx, user, newLink); err != nil {
+		return nil, err
+	}
+	s.AuditSvc.LogEvent(ctx, user.ID, user.Role, "SSO_USER_PROVISIONED", "SUCCESS", fmt.Sprintf("%s, roles %v", subject, roles))
+	return user, nil
+}
+
+// syncRoles menyamakan role akun dengan hasil mapping grup; sesi lama dicabut jika berubah
+func (s *SSOService) syncRoles(ctx context.Context, user *domain.User, roles []string) error {
+	from := user.AllRoles()
+	if slices.Equal(from, roles) {
+		return nil
+	}
+	if err := s.Users.SetRoles(ctx, user.ID, roles[0], roles[1:]); err != nil {
+		return err
+	}
+	revoked, err := s.Sessions.RevokeAllForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	user.Role, user.ExtraRoles = roles[0], nil
+	for _, role := range roles[1:] {
+		user.ExtraRoles = append(user.ExtraRoles, domain.UserRole{UserID: user.ID, Role: role})
+	}
+	s.AuditSvc.LogEvent(ctx, user.ID, user.Role, "SSO_ROLES_SYNCED", "SUCCESS",
+		fmt.Sprintf("%v -> %v, %d sessions revoked", from, roles, revoked))
+	return nil
+}
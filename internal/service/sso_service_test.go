@@ -0,0 +1,294 @@
+package service_test
+
+// Integration test SSOService.Complete terhadap IdP tiruan (mockidp) dan MySQL sungguhan.
+// Jalankan dengan database KHUSUS test (tabel di-migrate & diisi data uji):
+//
+//	ZTA_TEST_DSN="user:pass@tcp(127.0.0.1:3306)/zta_test?charset=utf8mb4&parseTime=True&loc=Local" go test ./internal/service -run SSO
+//
+// Tanpa ZTA_TEST_DSN test di-skip.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/syukurgit/zta/config"
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/envelope"
+	"github.com/syukurgit/zta/internal/kms"
+	"github.com/syukurgit/zta/internal/oidc"
+	"github.com/syukurgit/zta/internal/oidc/mockidp"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/internal/service"
+	"github.com/syukurgit/zta/pkg/utils"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	testClientID     = "zta"
+	testClientSecret = "zta-secret"
+	testRedirectURL  = "http://localhost:3000/auth/callback"
+)
+
+type ssoFixture struct {
+	db    *gorm.DB
+	idp   *mockidp.Server
+	svc   *service.SSOService
+	users *repository.UserRepository
+	run   string // Suffix unik per run agar data uji tidak bentrok di database yang sama
+}
+
+func setupSSO(t *testing.T) *ssoFixture {
+	t.Helper()
+	dsn := os.Getenv("ZTA_TEST_DSN")
+	if dsn == "" {
+		t.Skip("ZTA_TEST_DSN not set: skipping SSO integration test")
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect test database: %v", err)
+	}
+
+	// KEK lokal harus sama antar run (data key yang sudah tersimpan dibungkus dengan KEK ini)
+	keyFile := os.Getenv("KMS_KEY_FILE")
+	if keyFile == "" {
+		keyFile = filepath.Join(os.TempDir(), "zta-test-master.keys")
+	}
+	provider, err := kms.NewLocalFileKeyProvider(keyFile)
+	if err != nil {
+		t.Fatalf("load test KEK: %v", err)
+	}
+	config.DB = db
+	config.Keyring = envelope.NewKeyring(db, provider)
+	envelope.Register(config.Keyring)
+	config.MigrateDB()
+
+	idp, err := mockidp.Start(testClientID, testClientSecret)
+	if err != nil {
+		t.Fatalf("start mock IdP: %v", err)
+	}
+	t.Cleanup(idp.Close)
+
+	auditSvc := service.NewAuditService(repository.NewAuditRepository(db))
+	users := repository.NewUserRepository(db)
+	sessions := repository.NewSessionRepository(db)
+	permissions := service.NewPermissionService(repository.NewPermissionRepository(db), auditSvc)
+	accounts := service.NewAccountService(users, sessions, permissions, auditSvc)
+	groupRoles, err := service.ParseGroupRoles("cs-agents=CS,auditors=AUDITOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := oidc.NewClient(oidc.Config{Issuer: idp.Issuer(), ClientID: testClientID, ClientSecret: testClientSecret, RedirectURL: testRedirectURL})
+	return &ssoFixture{
+		db:    db,
+		idp:   idp,
+		svc:   service.NewSSOService(client, repository.NewSSORepository(db), users, sessions, accounts, auditSvc, groupRoles),
+		users: users,
+		run:   fmt.Sprint(time.Now().UnixNano()),
+	}
+}
+
+// authorize menjalankan Begin lalu "browser" ke endpoint authorize IdP; hasilnya code & state dari redirect
+func (f *ssoFixture) authorize(t *testing.T, loginHint string) (code, state string) {
+	t.Helper()
+	authURL, state, err := f.svc.Begin(context.Background(), loginHint)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+
+	browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := browser.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: expected redirect, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if got := location.Query().Get("state"); got != state {
+		t.Fatalf("authorize: state %q, want %q", got, state)
+	}
+	if location.Query().Get("code") == "" {
+		t.Fatalf("authorize: no code (%s)", location.Query().Get("error_description"))
+	}
+	return location.Query().Get("code"), state
+}
+
+// tamperState mengubah state login tersimpan (mensimulasikan code / token milik login lain)
+func (f *ssoFixture) tamperState(t *testing.T, state, column, value string) {
+	t.Helper()
+	if err := f.db.Model(&domain.OIDCLoginState{}).Where("state = ?", state).Update(column, value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *ssoFixture) user(subject, email string, groups []string, amr ...string) mockidp.User {
+	u := mockidp.User{Subject: subject + "-" + f.run, Email: email, EmailVerified: true, Groups: groups, AMR: amr}
+	f.idp.AddUser(u)
+	return u
+}
+
+func (f *ssoFixture) email(local string) string {
+	return fmt.Sprintf("%s.%s@company.com", local, f.run)
+}
+
+func TestSSOCompleteWithMockIdP(t *testing.T) {
+	f := setupSSO(t)
+	ctx := context.Background()
+
+	t.Run("provisions a mapped user", func(t *testing.T) {
+		u := f.user("new-cs", f.email("new.cs"), []string{"cs-agents"})
+		code, state := f.authorize(t, u.Email)
+
+		login, err := f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test")
+		if err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		if login.User.Role != domain.RoleCS || login.User.AuthProvider != domain.AuthOIDC || login.Session.Token == "" {
+			t.Fatalf("unexpected login: role %s, provider %s", login.User.Role, login.User.AuthProvider)
+		}
+	})
+
+	t.Run("PKCE mismatch", func(t *testing.T) {
+		u := f.user("pkce", f.email("pkce"), []string{"cs-agents"})
+		code, state := f.authorize(t, u.Email)
+		verifier, _, err := oidc.NewPKCE()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.tamperState(t, state, "code_verifier", verifier)
+
+		if _, err := f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test"); !errors.Is(err, service.ErrSSOFailed) {
+			t.Fatalf("Complete: got %v, want ErrSSOFailed", err)
+		}
+		if _, err := f.users.GetByEmail(ctx, u.Email); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("user must not be provisioned, lookup error: %v", err)
+		}
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		u := f.user("nonce", f.email("nonce"), []string{"cs-agents"})
+		code, state := f.authorize(t, u.Email)
+		nonce, err := oidc.RandomString(32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.tamperState(t, state, "nonce", nonce)
+
+		_, err = f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test")
+		if !errors.Is(err, service.ErrSSOFailed) {
+			t.Fatalf("Complete: got %v, want ErrSSOFailed", err)
+		}
+	})
+
+	t.Run("state is single use", func(t *testing.T) {
+		u := f.user("replay", f.email("replay"), []string{"cs-agents"})
+		code, state := f.authorize(t, u.Email)
+		if _, err := f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test"); err != nil {
+			t.Fatalf("first Complete: %v", err)
+		}
+		if _, err := f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test"); !errors.Is(err, service.ErrSSOFailed) {
+			t.Fatalf("replayed Complete: got %v, want ErrSSOFailed", err)
+		}
+	})
+
+	t.Run("unmapped group", func(t *testing.T) {
+		u := f.user("guest", f.email("guest"), []string{"partners"})
+		code, state := f.authorize(t, u.Email)
+
+		if _, err := f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test"); !errors.Is(err, service.ErrSSODenied) {
+			t.Fatalf("Complete: got %v, want ErrSSODenied", err)
+		}
+		if _, err := f.users.GetByEmail(ctx, u.Email); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("user must not be provisioned, lookup error: %v", err)
+		}
+	})
+
+	t.Run("links an existing local staff account", func(t *testing.T) {
+		local := f.createLocalStaff(t, f.email("local.cs"), false)
+		u := f.user("local-cs", local.Email, []string{"cs-agents"})
+		code, state := f.authorize(t, u.Email)
+
+		login, err := f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test")
+		if err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		if login.User.ID != local.ID {
+			t.Fatalf("linked user %d, want existing %d", login.User.ID, local.ID)
+		}
+		stored, err := f.users.GetByID(ctx, local.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.AuthProvider != domain.AuthOIDC || stored.PasswordHash != "" {
+			t.Fatalf("local password must be disabled: provider %s, hash set %t", stored.AuthProvider, stored.PasswordHash != "")
+		}
+	})
+
+	t.Run("refuses to link a TOTP account without IdP MFA", func(t *testing.T) {
+		local := f.createLocalStaff(t, f.email("local.mfa"), true)
+		u := f.user("local-mfa", local.Email, []string{"cs-agents"}, "pwd")
+		code, state := f.authorize(t, u.Email)
+
+		if _, err := f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test"); !errors.Is(err, service.ErrSSODenied) {
+			t.Fatalf("Complete: got %v, want ErrSSODenied", err)
+		}
+		stored, err := f.users.GetByID(ctx, local.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.AuthProvider != domain.AuthLocal || stored.PasswordHash == "" {
+			t.Fatalf("account must stay local: provider %s", stored.AuthProvider)
+		}
+
+		// IdP yang menyatakan MFA boleh menautkan akun yang sama
+		f.user("local-mfa", local.Email, []string{"cs-agents"}, "pwd", "mfa")
+		code, state = f.authorize(t, u.Email)
+		login, err := f.svc.Complete(ctx, code, state, "127.0.0.1", "go-test")
+		if err != nil {
+			t.Fatalf("Complete with IdP MFA: %v", err)
+		}
+		if login.User.ID != local.ID {
+			t.Fatalf("linked user %d, want existing %d", login.User.ID, local.ID)
+		}
+	})
+}
+
+// createLocalStaff membuat akun CS lokal (password), opsional dengan TOTP aktif
+func (f *ssoFixture) createLocalStaff(t *testing.T, email string, withMFA bool) *domain.User {
+	t.Helper()
+	hash, err := utils.HashPassword("Kopi-Tubruk#88-" + f.run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	user := &domain.User{
+		Email:           email,
+		PasswordHash:    hash,
+		Role:            domain.RoleCS,
+		Status:          domain.UserActive,
+		AuthProvider:    domain.AuthLocal,
+		EmailVerifiedAt: &now,
+	}
+	ctx := context.Background()
+	if err := f.users.Create(ctx, user); err != nil {
+		t.Fatalf("create local staff: %v", err)
+	}
+	if withMFA {
+		if err := f.users.SaveMFA(ctx, &domain.UserMFA{UserID: user.ID, Secret: "JBSWY3DPEHPK3PXP", ActivatedAt: &now}); err != nil {
+			t.Fatalf("enroll MFA: %v", err)
+		}
+	}
+	return user
+}
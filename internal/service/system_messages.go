@@ -0,0 +1,65 @@
+package service
+
+// systemMessages: Template pesan SYSTEM per event & bahasa tiket. {link} diganti URL rahasia event.
+var systemMessages = map[string]map[string]string{
+	EventTicketAssigned: {
+		"id": "Tiket Anda sedang ditangani oleh agen kami.",
+		"en": "Your ticket is now being handled by one of our agents.",
+	},
+	EventVerificationStarted: {
+		"id": "Untuk melanjutkan, silakan verifikasi identitas Anda melalui link berikut: {link}",
+		"en": "To continue, please verify your identity using this link: {link}",
+	},
+	EventVerificationPassed: {
+		"id": "Verifikasi identitas berhasil.",
+		"en": "Identity verification passed.",
+	},
+	EventVerificationFailed: {
+		"id": "Verifikasi identitas gagal. Tiket dikunci demi keamanan akun Anda.",
+		"en": "Identity verification failed. The ticket has been locked to protect your account.",
+	},
+	EventResetLinkIssued: {
+		"id": "Link reset password Anda (berlaku 10 menit, jangan dibagikan ke siapa pun): {link}",
+		"en": "Your password reset link (valid for 10 minutes, do not share it with anyone): {link}",
+	},
+	EventTicketPendingUser: {
+		"id": "Agen menunggu balasan Anda.",
+		"en": "The agent is waiting for your reply.",
+	},
+	EventTicketTransferred: {
+		"id": "Tiket Anda dialihkan ke agen lain.",
+		"en": "Your ticket has been transferred to another agent.",
+	},
+	EventTicketReopened: {
+		"id": "Tiket dibuka kembali.",
+		"en": "The ticket has been reopened.",
+	},
+	EventTicketClosed: {
+		"id": "Tiket telah ditutup. Terima kasih telah menghubungi kami.",
+		"en": "The ticket has been closed. Thank you for contacting us.",
+	},
+	EventCSATRequested: {
+		"id": "Bagaimana layanan kami? Beri penilaian 1-5 melalui link berikut (sekali pakai, berlaku 7 hari): {link}",
+		"en": "How did we do? Rate your experience from 1 to 5 using this link (single use, valid for 7 days): {link}",
+	},
+	EventTicketLocked: {
+		"id": "Tiket dikunci oleh sistem keamanan. Silakan hubungi kami melalui tiket baru jika perlu.",
+		"en": "The ticket has been locked by our security system. Please open a new ticket if needed.",
+	},
+}
+
+// defaultMessageLanguage: Dipakai jika bahasa tiket tidak punya template
+const defaultMessageLanguage = "id"
+
+// systemMessage memilih template sesuai bahasa (fallback ke bahasa default)
+func systemMessage(event, language string) (string, bool) {
+	templates, ok := systemMessages[event]
+	if !ok {
+		return "", false
+	}
+	if msg, ok := templates[language]; ok {
+		return msg, true
+	}
+	msg, ok := templates[defaultMessageLanguage]
+	return msg, ok
+}
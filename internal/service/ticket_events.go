@@ -0,0 +1,41 @@
+package service
+
+import "context"
+
+// Event tiket yang relevan untuk User (dikirim sebagai pesan SYSTEM di chat)
+const (
+	EventTicketAssigned      = "TICKET_ASSIGNED"
+	EventVerificationStarted = "VERIFICATION_STARTED" // Link = URL verifikasi
+	EventVerificationPassed  = "VERIFICATION_PASSED"
+	EventVerificationFailed  = "VERIFICATION_FAILED"
+	EventResetLinkIssued     = "RESET_LINK_ISSUED" // Link = URL reset password
+	EventTicketPendingUser   = "TICKET_PENDING_USER"
+	EventTicketClosed        = "TICKET_CLOSED"
+	EventTicketReopened      = "TICKET_REOPENED"
+	EventTicketLocked        = "TICKET_LOCKED"
+	EventTicketTransferred   = "TICKET_TRANSFERRED"
+	EventCSATRequested       = "CSAT_REQUESTED" // Link = URL survei kepuasan
+)
+
+// TicketEvent: Kejadian penting pada tiket. Link (jika ada) bersifat rahasia untuk pemilik tiket.
+type TicketEvent struct {
+	TicketID uint
+	Type     string
+	Link     string
+}
+
+// TicketEventHooks: Daftar hook yang dipanggil setiap event (pola sama dengan AuditService.OnLog)
+type TicketEventHooks struct {
+	hooks []func(context.Context, TicketEvent)
+}
+
+// OnEvent mendaftarkan hook event tiket
+func (h *TicketEventHooks) OnEvent(hook func(context.Context, TicketEvent)) {
+	h.hooks = append(h.hooks, hook)
+}
+
+func (h *TicketEventHooks) emit(ctx context.Context, event TicketEvent) {
+	for _, hook := range h.hooks {
+		hook(ctx, event)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -14,55 +15,230 @@ import (
 type TicketService struct {
 	Repo     *repository.TicketRepository
 	AuditSvc *AuditService // Injeksi Audit Service
+	SLASvc   *SLAService
+	Router   *RoutingService // Opsional: auto-assignment (nil / MANUAL = CS claim sendiri)
+
+	// VerificationCarryOver: Jika true, sesi verifikasi PENDING/PASSED tetap berlaku saat tiket ditransfer
+	// (privilege JIT CS lama tetap dicabut). Default false (Zero Trust): CS baru wajib memicu verifikasi ulang.
+	VerificationCarryOver bool
+
+	TicketEventHooks // Pesan SYSTEM di chat, dll.
 }
 
-// NewTicketService: Constructor diperbarui menerima AuditService
-func NewTicketService(repo *repository.TicketRepository, auditSvc *AuditService) *TicketService {
-	return &TicketService{Repo: repo, AuditSvc: auditSvc}
+// NewTicketService: Constructor diperbarui menerima AuditService & SLAService
+func NewTicketService(repo *repository.TicketRepository, auditSvc *AuditService, slaSvc *SLAService) *TicketService {
+	return &TicketService{Repo: repo, AuditSvc: auditSvc, SLASvc: slaSvc}
 }
 
-// CreateTicket: User membuat tiket baru
-func (s *TicketService) CreateTicket(userID uint, subject string) (*domain.Ticket, error) {
+// CreateTicket: User membuat tiket baru (deadline SLA dihitung dari kategori & prioritas)
+func (s *TicketService) CreateTicket(ctx context.Context, userID uint, subject, category, priority, language string) (*domain.Ticket, error) {
+	if language == "" {
+		language = "id"
+	}
+	if category == "" {
+		category = "GENERAL"
+	}
+	if priority == "" {
+		priority = domain.PriorityMedium
+	}
+	if !ValidPriority(priority) {
+		return nil, errors.New("invalid priority")
+	}
+
 	ticket := &domain.Ticket{
-		UserID:  userID,
-		Subject: subject,
-		Status:  "OPEN",
+		UserID:   userID,
+		Subject:  subject,
+		Status:   domain.TicketOpen,
+		Category: category,
+		Priority: priority,
+		Language: language,
+	}
+	if err := s.SLASvc.ApplyDeadlines(ctx, ticket, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Create(ctx, ticket); err != nil {
+		return nil, err
 	}
-	err := s.Repo.Create(ticket)
-	return ticket, err
+
+	// Auto-routing: jika tidak ada agent yang cocok, tiket tetap di antrian OPEN
+	if s.Router.Enabled() {
+		if csID, err := s.Router.RouteTicket(ctx, ticket); err == nil && csID != 0 {
+			ticket.Status = domain.TicketInProgress
+		}
+	}
+	return ticket, nil
 }
 
-// GetOpenQueue: Mengambil tiket yang belum diambil CS
-func (s *TicketService) GetOpenQueue() ([]domain.Ticket, error) {
-	return s.Repo.GetOpenTickets()
+// Transition: Satu-satunya jalan untuk mengubah status tiket (state machine di domain/ticket_state.go).
+// Setiap transisi (berhasil maupun ditolak) dicatat di AuditLog sebagai TICKET_TRANSITION.
+func (s *TicketService) Transition(ctx context.Context, ticketID uint, to string, actorID uint, role, reason string) (*domain.Ticket, error) {
+	ticket, err := s.Repo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, errors.New("ticket not found")
+	}
+
+	assignedCS, err := s.Repo.GetAssignedCS(ctx, ticketID)
+	if err != nil {
+		return nil, errors.New("system error: failed to check assignment")
+	}
+
+	from := ticket.Status
+	tc := domain.TransitionContext{ActorID: actorID, Role: role, AssignedCSID: assignedCS, Now: time.Now()}
+	if err := ticket.CanTransition(to, tc); err != nil {
+		s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, "TICKET_TRANSITION", "DENIED",
+			fmt.Sprintf("%s -> %s: %v", from, to, err))
+		return nil, err
+	}
+
+	if err := s.Repo.TransitionStatus(ctx, ticketID, from, to); err != nil {
+		return nil, err
+	}
+	ticket.Status = to
+
+	// Efek samping transisi
+	switch {
+	case to == domain.TicketPendingUser:
+		s.SLASvc.PauseForUser(ctx, ticketID, actorID, role)
+	case from == domain.TicketPendingUser:
+		s.SLASvc.ResumeFromUser(ctx, ticketID, actorID, role)
+	}
+	if to == domain.TicketClosed || to == domain.TicketLocked {
+		// Zero Trust: akses JIT yang belum dipakai langsung dicabut
+		s.Repo.RevokePrivileges(ctx, ticketID)
+		// Kapasitas agent berkurang -> route backlog antrian di background (bukan di jalur request)
+		s.Router.TriggerBacklog()
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, "TICKET_TRANSITION", "SUCCESS",
+		fmt.Sprintf("%s -> %s: %s", from, to, reason))
+
+	if event, ok := transitionEvents[to]; ok {
+		s.emit(ctx, TicketEvent{TicketID: ticketID, Type: event})
+	}
+	return ticket, nil
 }
 
-// ClaimTicket: CS mengambil tiket dari antrian
-func (s *TicketService) ClaimTicket(csID, ticketID uint) error {
-	// 1. POLICY CHECK: Max 1 Active Ticket per CS
-	activeCount, err := s.Repo.CountActiveTicketsByCS(csID)
+// MarkPendingUser: CS menandai tiket menunggu balasan user (SLA dijeda)
+func (s *TicketService) MarkPendingUser(ctx context.Context, ticketID, csID uint) error {
+	_, err := s.Transition(ctx, ticketID, domain.TicketPendingUser, csID, domain.RoleCS, "Waiting on user")
+	return err
+}
+
+// ReopenTicket: User membuka lagi tiket CLOSED (dalam ReopenWindow)
+func (s *TicketService) ReopenTicket(ctx context.Context, ticketID, userID uint) error {
+	_, err := s.Transition(ctx, ticketID, domain.TicketReopened, userID, domain.RoleUser, "Reopened by user")
+	return err
+}
+
+// ResumeTicket: CS melanjutkan tiket REOPENED / PENDING_USER
+func (s *TicketService) ResumeTicket(ctx context.Context, ticketID, csID uint) error {
+	_, err := s.Transition(ctx, ticketID, domain.TicketInProgress, csID, domain.RoleCS, "Resumed by CS")
+	return err
+}
+
+// TransferTicket: Memindahkan tiket aktif ke CS lain.
+// role CS  -> hand-off oleh pemegang tiket saat ini (Kind TRANSFER)
+// role SUPERVISOR -> reassign tiket siapa pun (Kind REASSIGN)
+func (s *TicketService) TransferTicket(ctx context.Context, ticketID, toCSID, actorID uint, role, note string) error {
+	deny := func(reason string) error {
+		s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, "TRANSFER_TICKET", "DENIED", "Reason: "+reason)
+		return errors.New("transfer denied: " + reason)
+	}
+
+	// 1. Tiket harus sedang aktif
+	ticket, err := s.Repo.GetByID(ctx, ticketID)
 	if err != nil {
-		return errors.New("system error: failed to check active tickets")
+		return errors.New("ticket not found")
+	}
+	if !ticket.IsActive() {
+		return deny(fmt.Sprintf("ticket is %s", ticket.Status))
 	}
 
-	if activeCount > 0 {
+	// 2. Siapa pemegang saat ini & apakah aktor berhak
+	fromCSID, err := s.Repo.GetAssignedCS(ctx, ticketID)
+	if err != nil || fromCSID == 0 {
+		return deny("ticket is not assigned")
+	}
+	kind := "REASSIGN"
+	if role == domain.RoleCS {
+		if fromCSID != actorID {
+			return deny("only the current CS can hand off this ticket")
+		}
+		kind = "TRANSFER"
+	}
+	if toCSID == fromCSID {
+		return deny("target CS already holds this ticket")
+	}
+
+	// 3. Target harus akun CS
+	target, err := s.Repo.GetUserByID(ctx, toCSID)
+	if err != nil || !target.HasRole(domain.RoleCS) {
+		return deny("target is not a CS agent")
+	}
+
+	// 4. Pindahkan (kapasitas target dicek atomic; privilege CS lama dicabut / dipindah sesuai policy)
+	history := &domain.TicketAssignmentHistory{
+		TicketID:       ticketID,
+		FromCSID:       fromCSID,
+		ToCSID:         toCSID,
+		Kind:           kind,
+		AssignedBy:     actorID,
+		AssignedByRole: role,
+		Note:           note,
+	}
+	if err := s.Repo.TransferAssignment(ctx, history, s.VerificationCarryOver); err != nil {
+		if errors.Is(err, domain.ErrActiveLimitReached) {
+			return deny("target CS " + err.Error())
+		}
+		return err
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, actorID, role, "TRANSFER_TICKET", "SUCCESS",
+		fmt.Sprintf("%s %s -> %s, verification carry-over: %t, note: %s",
+			kind, utils.AnonymizeID(fromCSID), utils.AnonymizeID(toCSID), s.VerificationCarryOver, note))
+	s.emit(ctx, TicketEvent{TicketID: ticketID, Type: EventTicketTransferred})
+	return nil
+}
+
+// GetAssignmentHistory: Riwayat pemegang tiket
+func (s *TicketService) GetAssignmentHistory(ctx context.Context, ticketID uint) ([]domain.TicketAssignmentHistory, error) {
+	return s.Repo.GetAssignmentHistory(ctx, ticketID)
+}
+
+// GetOpenQueue: Mengambil tiket yang belum diambil CS (per halaman)
+func (s *TicketService) GetOpenQueue(ctx context.Context, q domain.ListQuery) (*domain.Page[domain.Ticket], error) {
+	return s.Repo.ListOpenTickets(ctx, q)
+}
+
+// ClaimTicket: CS mengambil tiket dari antrian
+func (s *TicketService) ClaimTicket(ctx context.Context, csID, ticketID uint) error {
+	// 0. Mode auto-routing: tiket dibagikan sistem, CS tidak boleh memilih sendiri
+	if s.Router.Enabled() {
+		s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, "CLAIM_TICKET", "DENIED",
+			fmt.Sprintf("Reason: Manual claim disabled (routing mode %s)", s.Router.Mode))
+		return errors.New("policy violation: tickets are assigned automatically in this routing mode")
+	}
+
+	// 1. Claim + POLICY CHECK kapasitas (atomic di repository: profil CS dikunci selama transaksi)
+	err := s.Repo.AssignTicketToCS(ctx, ticketID, csID)
+	if errors.Is(err, domain.ErrActiveLimitReached) {
 		// LOG: Policy Violation
 		s.AuditSvc.LogActivity(
+			ctx,
 			ticketID, // TicketID
 			csID,     // ActorID
 			"CS",     // Role
 			"CLAIM_TICKET",
 			"DENIED",
-			"Reason: Active Limit Reached (Max 1)",
+			"Reason: "+err.Error(),
 		)
-		return errors.New("policy violation: you have an active ticket. Please finish or close it first.")
+		return errors.New("policy violation: you have reached your active ticket limit. Please finish or close a ticket first.")
 	}
-
-	// 2. Lanjutkan proses Claim
-	err = s.Repo.AssignTicketToCS(ticketID, csID)
 	if err == nil {
 		// LOG: Success Claim
 		s.AuditSvc.LogActivity(
+			ctx,
 			ticketID,
 			csID,
 			"CS",
@@ -70,19 +246,49 @@ func (s *TicketService) ClaimTicket(csID, ticketID uint) error {
 			"SUCCESS",
 			"CS claimed the ticket",
 		)
+		s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, "TICKET_TRANSITION", "SUCCESS",
+			fmt.Sprintf("%s -> %s: claimed", domain.TicketOpen, domain.TicketInProgress))
+		s.emit(ctx, TicketEvent{TicketID: ticketID, Type: EventTicketAssigned})
 	}
 	return err
 }
 
+// ErrTicketNotActive: Aksi verifikasi / reset password hanya untuk tiket IN_PROGRESS yang dipegang CS peminta
+var ErrTicketNotActive = errors.New("access denied: ticket must be IN_PROGRESS and assigned to you")
+
+// RequireActiveAssignment memastikan tiket berstatus IN_PROGRESS dan di-assign ke csID; penolakan dicatat dengan action
+func (s *TicketService) RequireActiveAssignment(ctx context.Context, ticketID, csID uint, action string) error {
+	ticket, err := s.Repo.GetByID(ctx, ticketID)
+	if err != nil {
+		return errors.New("ticket not found")
+	}
+	assignedCS, err := s.Repo.GetAssignedCS(ctx, ticketID)
+	if err != nil {
+		return errors.New("system error: failed to check assignment")
+	}
+	if ticket.Status != domain.TicketInProgress || assignedCS == 0 || assignedCS != csID {
+		s.AuditSvc.LogActivity(ctx, ticketID, csID, domain.RoleCS, action, "DENIED",
+			fmt.Sprintf("Reason: ticket %s, assigned CS %d", ticket.Status, assignedCS))
+		return ErrTicketNotActive
+	}
+	return nil
+}
+
 // ExecuteResetPassword: CS membuat LINK reset password (bukan mereset password langsung)
-func (s *TicketService) ExecuteResetPassword(csID, ticketID uint) (string, error) {
+func (s *TicketService) ExecuteResetPassword(ctx context.Context, csID, ticketID uint) (string, error) {
+	// 0. Hanya CS yang sedang menangani tiket IN_PROGRESS
+	if err := s.RequireActiveAssignment(ctx, ticketID, csID, "GENERATE_RESET_LINK"); err != nil {
+		return "", err
+	}
+
 	// 1. Cek Privilege 'SEND_RESET_LINK' (Diberikan oleh VerificationService jika lulus)
 	var privilege domain.TemporaryPrivilege
-	err := s.Repo.DB.Where("cs_id = ? AND ticket_id = ? AND action = ? AND expires_at > ? AND is_used = ?",
+	err := s.Repo.DB.WithContext(ctx).Where("cs_id = ? AND ticket_id = ? AND action = ? AND expires_at > ? AND is_used = ?",
 		csID, ticketID, "SEND_RESET_LINK", time.Now(), false).First(&privilege).Error
 
 	if err != nil {
 		s.AuditSvc.LogActivity(
+			ctx,
 			ticketID,
 			csID,
 			"CS",
@@ -106,15 +312,16 @@ func (s *TicketService) ExecuteResetPassword(csID, ticketID uint) (string, error
 		ExpiresAt: time.Now().Add(10 * time.Minute),
 	}
 	
-	if err := s.Repo.DB.Create(userPriv).Error; err != nil {
+	if err := s.Repo.DB.WithContext(ctx).Create(userPriv).Error; err != nil {
 		return "", errors.New("failed to generate user token")
 	}
 
 	// 3. Hanguskan Privilege CS (One-time Use)
-	s.Repo.DB.Model(&privilege).Update("is_used", true)
+	s.Repo.MarkPrivilegeUsed(ctx, privilege.ID)
 
 	// LOG: Success
 	s.AuditSvc.LogActivity(
+		ctx,
 		ticketID,
 		csID,
 		"CS",
@@ -123,30 +330,21 @@ func (s *TicketService) ExecuteResetPassword(csID, ticketID uint) (string, error
 		"Reset link generated for user",
 	)
 
-	// 4. Kembalikan Link untuk dikirim via Chat
-	return fmt.Sprintf("http://localhost:3000/reset-password/%s", userResetToken), nil
+	// 4. Link dikirim langsung ke User sebagai pesan SYSTEM (CS tidak perlu menyalin)
+	resetURL := fmt.Sprintf("http://localhost:3000/reset-password/%s", userResetToken)
+	s.emit(ctx, TicketEvent{TicketID: ticketID, Type: EventResetLinkIssued, Link: resetURL})
+	return resetURL, nil
 }
 
 // CloseTicket: Menutup tiket dan mencabut akses
-func (s *TicketService) CloseTicket(ticketID uint, requestorID uint, role string) error {
-	// 1. Ambil data tiket
-	ticket, err := s.Repo.GetByID(ticketID)
-	if err != nil {
-		return errors.New("ticket not found")
-	}
-
-	// 2. Cek Otoritas
-	if role == domain.RoleUser && ticket.UserID != requestorID {
-		return errors.New("unauthorized: you don't own this ticket")
-	}
-	
-	// (Opsional) Jika CS, bisa tambahkan cek apakah dia yang handle tiket ini
-
-	// 3. Update Status
-	err = s.Repo.UpdateStatus(ticketID, "CLOSED")
+func (s *TicketService) CloseTicket(ctx context.Context, ticketID uint, requestorID uint, role string) error {
+	// 1. Cek Otoritas & Update Status lewat state machine
+	// (tiket OPEN tidak bisa langsung ditutup, tiket CLOSED tidak bisa ditutup ulang)
+	_, err := s.Transition(ctx, ticketID, domain.TicketClosed, requestorID, role, "Ticket closed manually")
 	if err == nil {
 		// LOG: Audit Trail
 		s.AuditSvc.LogActivity(
+			ctx,
 			ticketID,
 			requestorID,
 			role,
@@ -158,53 +356,33 @@ func (s *TicketService) CloseTicket(ticketID uint, requestorID uint, role string
 	return err
 }
 
-// GetUserTickets: Mengambil semua tiket milik user tertentu
-func (s *TicketService) GetUserTickets(userID uint) ([]domain.Ticket, error) {
-	var tickets []domain.Ticket
-	err := s.Repo.DB.Preload("User").Where("user_id = ?", userID).Order("created_at desc").Find(&tickets).Error
-	return tickets, err
+// GetUserTickets: Mengambil tiket milik user tertentu (per halaman)
+func (s *TicketService) GetUserTickets(ctx context.Context, userID uint, q domain.ListQuery) (*domain.Page[domain.Ticket], error) {
+	return s.Repo.ListUserTickets(ctx, userID, q)
 }
 
-// GetCSActiveTickets: Mengambil tiket yang sedang dikerjakan CS tertentu (IN_PROGRESS)
-func (s *TicketService) GetCSActiveTickets(csID uint) ([]domain.Ticket, error) {
-	var tickets []domain.Ticket
-	
-	// Gunakan JOIN ke tabel ticket_assignments
-	err := s.Repo.DB.Preload("User").
-		Joins("JOIN ticket_assignments ON ticket_assignments.ticket_id = tickets.id").
-		Where("ticket_assignments.cs_id = ? AND tickets.status = ?", csID, "IN_PROGRESS").
-		Order("tickets.updated_at desc").
-		Find(&tickets).Error
-		
-	return tickets, err
+// GetCSActiveTickets: Mengambil tiket yang sedang dikerjakan CS tertentu (IN_PROGRESS / PENDING_USER / REOPENED)
+func (s *TicketService) GetCSActiveTickets(ctx context.Context, csID uint, q domain.ListQuery) (*domain.Page[domain.Ticket], error) {
+	return s.Repo.ListCSTickets(ctx, csID, domain.ActiveTicketStatuses, q)
 }
 
 // GetCSHistory: Mengambil tiket yang SUDAH diselesaikan (CLOSED) oleh CS tertentu
-func (s *TicketService) GetCSHistory(csID uint) ([]domain.Ticket, error) {
-	var tickets []domain.Ticket
-	
-	// Ambil tiket yang statusnya CLOSED dan pernah di-assign ke CS ini
-	err := s.Repo.DB.Preload("User").
-		Joins("JOIN ticket_assignments ON ticket_assignments.ticket_id = tickets.id").
-		Where("ticket_assignments.cs_id = ? AND tickets.status = ?", csID, "CLOSED").
-		Order("tickets.updated_at desc").
-		Find(&tickets).Error
-		
-	return tickets, err
+func (s *TicketService) GetCSHistory(ctx context.Context, csID uint, q domain.ListQuery) (*domain.Page[domain.Ticket], error) {
+	return s.Repo.ListCSTickets(ctx, csID, []string{domain.TicketClosed}, q)
 }
 
 
 // internal/service/ticket_service.go
 
-func (s *TicketService) ProcessUserResetPassword(token, newPassword string) error {
+func (s *TicketService) ProcessUserResetPassword(ctx context.Context, token, newPassword string) error {
 	// 1. Validasi Token
-	priv, err := s.Repo.GetPrivilegeByToken(token)
+	priv, err := s.Repo.GetPrivilegeByToken(ctx, token)
 	if err != nil {
 		return errors.New("invalid or expired token")
 	}
 
 	// 2. Ambil Ticket untuk tahu User-nya siapa
-	ticket, err := s.Repo.GetByID(priv.TicketID)
+	ticket, err := s.Repo.GetByID(ctx, priv.TicketID)
 	if err != nil {
 		return errors.New("ticket context not found")
 	}
@@ -213,24 +391,33 @@ func (s *TicketService) ProcessUserResetPassword(token, newPassword string) erro
 	hashedPwd, _ := utils.HashPassword(newPassword)
 
 	// 4. Eksekusi Update (Transaction)
-	err = s.Repo.DB.Transaction(func(tx *gorm.DB) error {
+	err = s.Repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Update Password User
 		if err := tx.Model(&domain.User{}).Where("id = ?", ticket.UserID).Update("password_hash", hashedPwd).Error; err != nil {
 			return err
 		}
 		// Tandai Token Hangus
-		if err := tx.Model(&domain.TemporaryPrivilege{}).Where("id = ?", priv.ID).Update("is_used", true).Error; err != nil {
+		if err := tx.Model(&domain.TemporaryPrivilege{}).Where("id = ?", priv.ID).
+			Updates(map[string]interface{}{"is_used": true, "used_at": time.Now()}).Error; err != nil {
 			return err
 		}
 		return nil
 	})
 
 	if err != nil {
-		s.AuditSvc.LogActivity(ticket.ID, ticket.UserID, "USER", "SET_NEW_PASSWORD", "FAILED", "Database error during update")
+		s.AuditSvc.LogActivity(ctx, ticket.ID, ticket.UserID, "USER", "SET_NEW_PASSWORD", "FAILED", "Database error during update")
 		return err
 	}
 
 	// 5. Log Sukses
-	s.AuditSvc.LogActivity(ticket.ID, ticket.UserID, "USER", "SET_NEW_PASSWORD", "SUCCESS", "User successfully reset their password")
+	s.AuditSvc.LogActivity(ctx, ticket.ID, ticket.UserID, "USER", "SET_NEW_PASSWORD", "SUCCESS", "User successfully reset their password")
 	return nil
-}
\ No newline at end of file
+}
+
+// transitionEvents: Status tujuan yang perlu diberitahukan ke User
+var transitionEvents = map[string]string{
+	domain.TicketPendingUser: EventTicketPendingUser,
+	domain.TicketClosed:      EventTicketClosed,
+	domain.TicketReopened:    EventTicketReopened,
+	domain.TicketLocked:      EventTicketLocked,
+}
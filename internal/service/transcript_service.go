@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/internal/repository"
+	"github.com/syukurgit/zta/internal/transcript"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+// TranscriptService: Ekspor rekam jejak tiket (HTML + PDF + manifest bertanda tangan) untuk sengketa
+type TranscriptService struct {
+	TicketRepo     *repository.TicketRepository
+	ChatRepo       *repository.ChatRepository
+	AuditRepo      *repository.AuditRepository
+	VerifRepo      *repository.VerificationRepository
+	TranscriptRepo *repository.TranscriptRepository
+	AuditSvc       *AuditService
+	Signer         *transcript.Signer
+}
+
+func NewTranscriptService(
+	ticketRepo *repository.TicketRepository,
+	chatRepo *repository.ChatRepository,
+	auditRepo *repository.AuditRepository,
+	verifRepo *repository.VerificationRepository,
+	transcriptRepo *repository.TranscriptRepository,
+	auditSvc *AuditService,
+	signer *transcript.Signer,
+) *TranscriptService {
+	return &TranscriptService{
+		TicketRepo:     ticketRepo,
+		ChatRepo:       chatRepo,
+		AuditRepo:      auditRepo,
+		VerifRepo:      verifRepo,
+		TranscriptRepo: transcriptRepo,
+		AuditSvc:       auditSvc,
+		Signer:         signer,
+	}
+}
+
+// TranscriptVerification: Hasil pemeriksaan bundle yang diunggah
+type TranscriptVerification struct {
+	Valid    bool                 `json:"valid"`
+	Recorded bool                 `json:"recorded"` // Manifest persis sama dengan catatan ekspor di DB
+	Manifest *transcript.Manifest `json:"manifest,omitempty"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// Export menyusun bundle transcript satu tiket dan mencatat ekspornya
+func (s *TranscriptService) Export(ctx context.Context, ticketID, auditorID uint) (*transcript.BundleResult, error) {
+	// 1. Kumpulkan data
+	ticket, err := s.TicketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, errors.New("ticket not found")
+	}
+	chats, err := s.ChatRepo.GetChatHistory(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.ChatRepo.GetRevisionsByTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.AuditRepo.GetLogsByTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.VerifRepo.GetSessionsByTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Render + tanda tangan
+	doc := transcript.Document{
+		Ticket:        *ticket,
+		Chats:         chats,
+		Revisions:     revisions,
+		Events:        events,
+		Verifications: sessions,
+		GeneratedAt:   time.Now(),
+		GeneratedBy:   utils.AnonymizeID(auditorID),
+	}
+	result, err := transcript.Bundle(doc, uuid.New().String(), s.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Catat ekspor (bukti bahwa bundle ini memang dikeluarkan sistem)
+	manifestSum := sha256.Sum256(result.ManifestJSON)
+	if err := s.TranscriptRepo.CreateExport(ctx, &domain.TranscriptExport{
+		ExportID:       result.Manifest.ExportID,
+		TicketID:       ticketID,
+		ManifestSHA256: hex.EncodeToString(manifestSum[:]),
+		Signature:      result.Signature,
+		KeyID:          result.Manifest.KeyID,
+		ExportedBy:     doc.GeneratedBy,
+	}); err != nil {
+		return nil, err
+	}
+
+	s.AuditSvc.LogActivity(ctx, ticketID, auditorID, domain.RoleAuditor, "TRANSCRIPT_EXPORT", "SUCCESS",
+		fmt.Sprintf("export %s: %d chat(s), %d revision(s), %d event(s), %d verification session(s)",
+			result.Manifest.ExportID, len(chats), len(revisions), len(events), len(sessions)))
+	return result, nil
+}
+
+// Verify memeriksa tanda tangan & hash bundle, lalu mencocokkan dengan catatan ekspor
+func (s *TranscriptService) Verify(ctx context.Context, bundle []byte, auditorID uint) *TranscriptVerification {
+	manifest, manifestJSON, err := transcript.Verify(bundle, s.Signer.PublicKey())
+	if err != nil {
+		var ticketID uint
+		if manifest != nil {
+			ticketID = manifest.TicketID
+		}
+		s.AuditSvc.LogActivity(ctx, ticketID, auditorID, domain.RoleAuditor, "TRANSCRIPT_VERIFY", "FAILED", err.Error())
+		return &TranscriptVerification{Manifest: manifest, Error: err.Error()}
+	}
+
+	result := &TranscriptVerification{Valid: true, Manifest: manifest}
+	manifestSum := sha256.Sum256(manifestJSON)
+	if export, err := s.TranscriptRepo.GetExport(ctx, manifest.ExportID); err == nil {
+		result.Recorded = export.ManifestSHA256 == hex.EncodeToString(manifestSum[:]) && export.TicketID == manifest.TicketID
+	}
+
+	s.AuditSvc.LogActivity(ctx, manifest.TicketID, auditorID, domain.RoleAuditor, "TRANSCRIPT_VERIFY", "SUCCESS",
+		fmt.Sprintf("export %s valid, recorded: %t", manifest.ExportID, result.Recorded))
+	return result
+}
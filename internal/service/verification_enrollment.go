@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+// VerificationCategories: Setiap sesi verifikasi memilih 1 pertanyaan per kategori,
+// jadi enrollment wajib mencakup semuanya
+var VerificationCategories = []string{"STATIC", "HISTORY", "USAGE"}
+
+// ErrReauthRequired: Mengganti jawaban yang sudah di-enroll wajib menyertakan password saat ini
+var ErrReauthRequired = errors.New("current password is required to change enrolled verification answers")
+
+// OnboardingQuestion: Pertanyaan bank soal tanpa kunci jawaban
+type OnboardingQuestion struct {
+	ID       uint   `json:"id"`
+	Category string `json:"category"`
+	Question string `json:"question"`
+	Enrolled bool   `json:"enrolled"`
+}
+
+// OnboardingStatus: Langkah onboarding User yang belum selesai
+type OnboardingStatus struct {
+	VerificationAnswersEnrolled bool                 `json:"verification_answers_enrolled"`
+	RequiredCategories          []string             `json:"required_categories"`
+	Questions                   []OnboardingQuestion `json:"questions"`
+}
+
+// normalizeAnswer: Jawaban pribadi dibandingkan tanpa beda huruf besar/kecil & spasi berlebih
+func normalizeAnswer(answer string) string {
+	return strings.ToLower(strings.Join(strings.Fields(answer), " "))
+}
+
+// GetOnboarding: Status enrollment jawaban verifikasi + daftar pertanyaan yang bisa dipilih
+func (s *VerificationService) GetOnboarding(ctx context.Context, userID uint) (*OnboardingStatus, error) {
+	questions, err := s.Repo.ListQuestionBank(ctx)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := s.Repo.GetUserAnswers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &OnboardingStatus{VerificationAnswersEnrolled: len(answers) > 0, RequiredCategories: VerificationCategories}
+	for _, q := range questions {
+		_, enrolled := answers[q.ID]
+		status.Questions = append(status.Questions, OnboardingQuestion{ID: q.ID, Category: q.Category, Question: q.QuestionText, Enrolled: enrolled})
+	}
+	return status, nil
+}
+
+// EnrollAnswers menyimpan jawaban pribadi User (minimal 1 per kategori). Enroll pertama cukup dengan sesi;
+// enroll ulang (mengganti set lama) wajib re-auth dengan password saat ini, karena jawaban ini yang dipakai
+// CS untuk memverifikasi pemilik akun.
+func (s *VerificationService) EnrollAnswers(ctx context.Context, userID uint, answers map[uint]string, currentPassword string) error {
+	existing, err := s.Repo.GetUserAnswers(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		if err := s.reauthenticate(ctx, userID, currentPassword); err != nil {
+			return err
+		}
+	}
+
+	questions, err := s.Repo.ListQuestionBank(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]domain.VerificationQuestion, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+
+	// 1. Validasi: pertanyaan ada, jawaban tidak kosong, semua kategori terwakili
+	covered := map[string]bool{}
+	normalized := make(map[uint]string, len(answers))
+	for questionID, answer := range answers {
+		q, ok := byID[questionID]
+		if !ok {
+			return fmt.Errorf("unknown question %d", questionID)
+		}
+		answer = normalizeAnswer(answer)
+		if utf8.RuneCountInString(answer) < 2 || len(answer) > utils.MaxPasswordBytes {
+			return fmt.Errorf("answer for question %d must be 2-%d characters", questionID, utils.MaxPasswordBytes)
+		}
+		covered[q.Category] = true
+		normalized[questionID] = answer
+	}
+	for _, category := range VerificationCategories {
+		if !covered[category] {
+			return errors.New("answer at least one question in each category: " + strings.Join(VerificationCategories, ", "))
+		}
+	}
+
+	// 2. Hash (bcrypt, sama seperti kunci jawaban bank soal)
+	records := make([]domain.UserVerificationAnswer, 0, len(normalized))
+	for questionID, answer := range normalized {
+		hash, err := utils.HashPassword(answer)
+		if err != nil {
+			return err
+		}
+		records = append(records, domain.UserVerificationAnswer{UserID: userID, QuestionID: questionID, AnswerHash: hash})
+	}
+
+	// 3. Simpan (hash saja) & audit tanpa isi jawaban
+	if err := s.Repo.ReplaceUserAnswers(ctx, userID, records); err != nil {
+		return err
+	}
+	action := "VERIFICATION_ANSWERS_ENROLLED"
+	if len(existing) > 0 {
+		action = "VERIFICATION_ANSWERS_CHANGED"
+	}
+	s.AuditSvc.LogEvent(ctx, userID, domain.RoleUser, action, "SUCCESS",
+		fmt.Sprintf("%d personal answers enrolled", len(records)))
+	return nil
+}
+
+// reauthenticate: Password saat ini wajib cocok sebelum jawaban lama diganti (token sesi saja tidak cukup)
+func (s *VerificationService) reauthenticate(ctx context.Context, userID uint, currentPassword string) error {
+	user, err := s.TicketSvc.Repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if currentPassword == "" || user.PasswordHash == "" || !utils.CheckPasswordHash(currentPassword, user.PasswordHash) {
+		s.AuditSvc.LogEvent(ctx, userID, domain.RoleUser, "VERIFICATION_ANSWERS_CHANGED", "DENIED", "re-authentication failed")
+		return ErrReauthRequired
+	}
+	return nil
+}
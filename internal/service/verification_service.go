@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -11,20 +12,31 @@ import (
 	"github.com/syukurgit/zta/pkg/utils"
 )
 
+// ErrVerificationClosed: Tiket sudah tidak ditangani CS yang memulai sesi verifikasi
+var ErrVerificationClosed = errors.New("verification session is no longer valid for this ticket")
+
 type VerificationService struct {
-	Repo     *repository.VerificationRepository
-	AuditSvc *AuditService // Injeksi Audit Service
+	Repo      *repository.VerificationRepository
+	AuditSvc  *AuditService  // Injeksi Audit Service
+	TicketSvc *TicketService // Untuk auto-lock tiket saat verifikasi FAILED
+
+	TicketEventHooks // Link verifikasi & hasilnya dikirim ke User sebagai pesan SYSTEM
 }
 
-// Constructor diperbarui menerima AuditService
-func NewVerificationService(repo *repository.VerificationRepository, auditSvc *AuditService) *VerificationService {
-	return &VerificationService{Repo: repo, AuditSvc: auditSvc}
+// Constructor diperbarui menerima AuditService & TicketService
+func NewVerificationService(repo *repository.VerificationRepository, auditSvc *AuditService, ticketSvc *TicketService) *VerificationService {
+	return &VerificationService{Repo: repo, AuditSvc: auditSvc, TicketSvc: ticketSvc}
 }
 
 // StartVerification: Memulai sesi dan mengirim link
-func (s *VerificationService) StartVerification(ticketID uint, csID uint) (string, error) {
+func (s *VerificationService) StartVerification(ctx context.Context, ticketID uint, csID uint) (string, error) {
+	// 0. Hanya CS yang sedang menangani tiket IN_PROGRESS
+	if err := s.TicketSvc.RequireActiveAssignment(ctx, ticketID, csID, "START_VERIFICATION"); err != nil {
+		return "", err
+	}
+
 	// 1. Ambil Data User Target
-	user, err := s.Repo.GetUserByTicket(ticketID)
+	user, err := s.Repo.GetUserByTicket(ctx, ticketID)
 	if err != nil {
 		return "", errors.New("ticket or user not found")
 	}
@@ -32,6 +44,7 @@ func (s *VerificationService) StartVerification(ticketID uint, csID uint) (strin
 	// 2. POLICY CHECK: Risk Score
 	if user.RiskScore >= 80 {
 		s.AuditSvc.LogActivity(
+			ctx,
 			ticketID, // TicketID (Updated Signature)
 			csID,
 			"CS",
@@ -43,9 +56,10 @@ func (s *VerificationService) StartVerification(ticketID uint, csID uint) (strin
 	}
 
 	// 3. POLICY CHECK: Rate Limit
-	count, _ := s.Repo.CountRecentSessions(user.ID)
+	count, _ := s.Repo.CountRecentSessions(ctx, user.ID)
 	if count >= 200 {
 		s.AuditSvc.LogActivity(
+			ctx,
 			ticketID,
 			csID,
 			"CS",
@@ -60,7 +74,7 @@ func (s *VerificationService) StartVerification(ticketID uint, csID uint) (strin
 	sessionID := uuid.New().String()
 
 	// 5. Pilih Pertanyaan
-	questions, err := s.Repo.GetSecureQuestionSet()
+	questions, err := s.Repo.GetSecureQuestionSet(ctx, user.ID)
 	if err != nil {
 		return "", errors.New("system error: failed to generate question set")
 	}
@@ -70,18 +84,20 @@ func (s *VerificationService) StartVerification(ticketID uint, csID uint) (strin
 		ID:           sessionID,
 		TicketID:     ticketID,
 		UserID:       user.ID,
+		CSID:         csID,
 		Status:       "PENDING",
 		AttemptCount: 0,
 		ExpiresAt:    time.Now().Add(15 * time.Minute),
 	}
 
 	// 7. Simpan ke DB
-	if err := s.Repo.CreateSession(session, questions); err != nil {
+	if err := s.Repo.CreateSession(ctx, session, questions); err != nil {
 		return "", err
 	}
 
 	// 8. Audit Log
 	s.AuditSvc.LogActivity(
+		ctx,
 		ticketID,
 		csID,
 		"CS",
@@ -95,13 +111,14 @@ func (s *VerificationService) StartVerification(ticketID uint, csID uint) (strin
 		"http://localhost:3000/verify/%s",
 		sessionID,
 	)
+	s.emit(ctx, TicketEvent{TicketID: ticketID, Type: EventVerificationStarted, Link: verificationURL})
 
 	return verificationURL, nil
 }
 
 // GetVerificationQuestions dipanggil saat User membuka link
-func (s *VerificationService) GetVerificationQuestions(sessionID string) ([]domain.VerificationQuestion, error) {
-	session, err := s.Repo.GetSessionByID(sessionID)
+func (s *VerificationService) GetVerificationQuestions(ctx context.Context, sessionID string) ([]domain.VerificationQuestion, error) {
+	session, err := s.Repo.GetSessionByID(ctx, sessionID)
 	if err != nil {
 		return nil, errors.New("invalid session")
 	}
@@ -112,13 +129,13 @@ func (s *VerificationService) GetVerificationQuestions(sessionID string) ([]doma
 	}
 
 	// Ambil pertanyaan
-	return s.Repo.GetQuestionsBySession(sessionID)
+	return s.Repo.GetQuestionsBySession(ctx, sessionID)
 }
 
 // SubmitAnswers dipanggil saat User mengirim jawaban (LOGIC 3 STRIKES)
-func (s *VerificationService) SubmitAnswers(sessionID string, answers map[uint]string) (bool, error) {
+func (s *VerificationService) SubmitAnswers(ctx context.Context, sessionID string, answers map[uint]string) (bool, error) {
 	// 1. Ambil Session
-	session, err := s.Repo.GetSessionByID(sessionID)
+	session, err := s.Repo.GetSessionByID(ctx, sessionID)
 	if err != nil {
 		return false, errors.New("invalid session")
 	}
@@ -127,8 +144,18 @@ func (s *VerificationService) SubmitAnswers(sessionID string, answers map[uint]s
 		return false, errors.New("sesi sudah tidak aktif")
 	}
 
-	// 2. Ambil Kunci Jawaban
-	questions, _ := s.Repo.GetQuestionsBySession(sessionID)
+	// Tiket harus masih IN_PROGRESS & dipegang CS yang memulai sesi (bukan setelah close / lock / transfer)
+	if err := s.TicketSvc.RequireActiveAssignment(ctx, session.TicketID, session.CSID, "VERIFICATION_ATTEMPT"); err != nil {
+		s.Repo.UpdateSessionResult(ctx, sessionID, "EXPIRED", 0)
+		return false, ErrVerificationClosed
+	}
+
+	// 2. Ambil Kunci Jawaban (jawaban pribadi hasil onboarding menggantikan kunci default bank soal)
+	questions, _ := s.Repo.GetQuestionsBySession(ctx, sessionID)
+	personal, err := s.Repo.GetUserAnswers(ctx, session.UserID)
+	if err != nil {
+		return false, errors.New("system error: failed to load answers")
+	}
 	allCorrect := true
 
 	// 3. Periksa Jawaban
@@ -138,7 +165,11 @@ func (s *VerificationService) SubmitAnswers(sessionID string, answers map[uint]s
 			allCorrect = false
 			break
 		}
-		if !utils.CheckPasswordHash(userAnswer, q.AnswerHash) {
+		answerHash := q.AnswerHash
+		if enrolled, ok := personal[q.ID]; ok {
+			answerHash, userAnswer = enrolled.AnswerHash, normalizeAnswer(userAnswer)
+		}
+		if !utils.CheckPasswordHash(userAnswer, answerHash) {
 			allCorrect = false
 			break
 		}
@@ -162,13 +193,14 @@ func (s *VerificationService) SubmitAnswers(sessionID string, answers map[uint]s
 
 		// Update DB: AttemptCount & Status
 		// Menggunakan map untuk update spesifik agar tidak menimpa field lain
-		s.Repo.DB.Model(&session).Updates(map[string]interface{}{
+		s.Repo.DB.WithContext(ctx).Model(&session).Updates(map[string]interface{}{
 			"attempt_count": session.AttemptCount,
 			"status":        newStatus,
 		})
 
 		// Log Aktivitas Gagal
 		s.AuditSvc.LogActivity(
+			ctx,
 			session.TicketID,
 			session.UserID,
 			"USER",
@@ -177,18 +209,19 @@ func (s *VerificationService) SubmitAnswers(sessionID string, answers map[uint]s
 			fmt.Sprintf("Session: %s, Attempt: %d, Result: %s", sessionID, session.AttemptCount, newStatus),
 		)
 
-		return false, errors.New(msg)
-	}
+		// 3 strikes: tiket dikunci otomatis oleh sistem
+		if newStatus == "FAILED" {
+			s.emit(ctx, TicketEvent{TicketID: session.TicketID, Type: EventVerificationFailed})
+			s.TicketSvc.Transition(ctx, session.TicketID, domain.TicketLocked, 0, domain.RoleSystem,
+				fmt.Sprintf("Verification session %s FAILED", sessionID))
+		}
 
-	// 5. JIKA BERHASIL (SUCCESS): Berikan Privilege 'SEND_RESET_LINK' ke CS
-	csID, err := s.Repo.GetCSByTicket(session.TicketID)
-	if err != nil {
-		return true, errors.New("system error: ticket is not assigned to any CS")
+		return false, errors.New(msg)
 	}
 
-	// Buat Privilege untuk CS agar bisa klik tombol "Send Reset Link"
+	// 5. JIKA BERHASIL (SUCCESS): Berikan Privilege 'SEND_RESET_LINK' ke CS yang memulai sesi (sudah dicek masih memegang tiket)
 	privilege := &domain.TemporaryPrivilege{
-		CSID:      csID,
+		CSID:      session.CSID,
 		TicketID:  session.TicketID,
 		Action:    "SEND_RESET_LINK",
 		Token:     utils.GenerateRandomToken(32),
@@ -196,15 +229,16 @@ func (s *VerificationService) SubmitAnswers(sessionID string, answers map[uint]s
 		ExpiresAt: time.Now().Add(5 * time.Minute),
 	}
 
-	if err := s.Repo.SavePrivilege(privilege); err != nil {
+	if err := s.Repo.SavePrivilege(ctx, privilege); err != nil {
 		return true, err
 	}
 
 	// Tandai sesi lulus
-	s.Repo.UpdateSessionResult(sessionID, "PASSED", 0)
+	s.Repo.UpdateSessionResult(ctx, sessionID, "PASSED", 0)
 
 	// LOG: Verification Passed
 	s.AuditSvc.LogActivity(
+		ctx,
 		session.TicketID,
 		session.UserID,
 		"USER",
@@ -212,6 +246,7 @@ func (s *VerificationService) SubmitAnswers(sessionID string, answers map[uint]s
 		"PASSED",
 		"User berhasil menjawab pertanyaan. Akses dibuka untuk CS.",
 	)
+	s.emit(ctx, TicketEvent{TicketID: session.TicketID, Type: EventVerificationPassed})
 
 	return true, nil
 }
\ No newline at end of file
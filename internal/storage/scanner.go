@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+var ErrMalwareDetected = errors.New("malware detected")
+
+// MalwareScanner: Hook pemindai file sebelum disimpan (ClamAV, layanan eksternal, dll)
+type MalwareScanner interface {
+	Scan(ctx context.Context, fileName string, content []byte) error
+}
+
+// eicarSignature: File uji standar antivirus (bukan malware sungguhan)
+var eicarSignature = []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+// StubScanner: Scanner lokal untuk development, hanya menolak file uji EICAR
+type StubScanner struct{}
+
+func (StubScanner) Scan(ctx context.Context, fileName string, content []byte) error {
+	if bytes.Contains(content, eicarSignature) {
+		return ErrMalwareDetected
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package transcript
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Nama file di dalam bundle
+const (
+	FileHTML      = "transcript.html"
+	FilePDF       = "transcript.pdf"
+	FileManifest  = "manifest.json"
+	FileSignature = "manifest.sig"
+)
+
+var ErrInvalidBundle = errors.New("invalid transcript bundle")
+
+// ManifestFile: Hash setiap file isi bundle
+type ManifestFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+}
+
+// Manifest: Ditandatangani Ed25519; membuktikan isi bundle tidak berubah sejak diekspor
+type Manifest struct {
+	Version     int            `json:"version"`
+	ExportID    string         `json:"export_id"`
+	TicketID    uint           `json:"ticket_id"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	GeneratedBy string         `json:"generated_by"`
+	KeyID       string         `json:"key_id"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// Signer: Kunci Ed25519 untuk menandatangani manifest
+type Signer struct {
+	PrivateKey ed25519.PrivateKey
+}
+
+// NewSigner menurunkan kunci Ed25519 secara deterministik dari secret (kunci sama setiap restart)
+func NewSigner(secret string) *Signer {
+	seed := sha256.Sum256([]byte("zta-transcript-signing|" + secret))
+	return &Signer{PrivateKey: ed25519.NewKeyFromSeed(seed[:])}
+}
+
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.PrivateKey.Public().(ed25519.PublicKey)
+}
+
+// KeyID: Sidik jari public key (16 hex pertama sha256)
+func (s *Signer) KeyID() string {
+	sum := sha256.Sum256(s.PublicKey())
+	return hex.EncodeToString(sum[:8])
+}
+
+// BundleResult: Hasil ekspor. ManifestJSON = bytes persis yang ditandatangani.
+type BundleResult struct {
+	Zip          []byte
+	Manifest     *Manifest
+	ManifestJSON []byte
+	Signature    string // base64
+}
+
+// Bundle merender HTML + PDF, menyusun & menandatangani manifest, lalu membungkus semuanya dalam zip
+func Bundle(doc Document, exportID string, signer *Signer) (*BundleResult, error) {
+	// 1. Render isi
+	html, err := RenderHTML(doc)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{FileHTML: html, FilePDF: RenderPDF(doc)}
+
+	// 2. Manifest + tanda tangan
+	manifest := &Manifest{
+		Version:     1,
+		ExportID:    exportID,
+		TicketID:    doc.Ticket.ID,
+		GeneratedAt: doc.GeneratedAt.UTC(),
+		GeneratedBy: doc.GeneratedBy,
+		KeyID:       signer.KeyID(),
+	}
+	for _, name := range []string{FileHTML, FilePDF} {
+		sum := sha256.Sum256(files[name])
+		manifest.Files = append(manifest.Files, ManifestFile{Name: name, SHA256: hex.EncodeToString(sum[:]), Size: len(files[name])})
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(signer.PrivateKey, manifestJSON))
+
+	// 3. Zip
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{{FileHTML, html}, {FilePDF, files[FilePDF]}, {FileManifest, manifestJSON}, {FileSignature, []byte(signature)}} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: manifest.GeneratedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(entry.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &BundleResult{Zip: buf.Bytes(), Manifest: manifest, ManifestJSON: manifestJSON, Signature: signature}, nil
+}
+
+// Verify memeriksa tanda tangan manifest dan hash setiap file di bundle.
+// Mengembalikan manifest & bytes manifest (untuk dicocokkan dengan catatan ekspor di DB).
+func Verify(bundle []byte, publicKey ed25519.PublicKey) (*Manifest, []byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		return nil, nil, ErrInvalidBundle
+	}
+
+	// 1. Baca semua file (nama duplikat = bundle dimanipulasi)
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		if _, dup := files[f.Name]; dup {
+			return nil, nil, fmt.Errorf("%w: duplicate file %s", ErrInvalidBundle, f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, ErrInvalidBundle
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, 64<<20))
+		rc.Close()
+		if err != nil {
+			return nil, nil, ErrInvalidBundle
+		}
+		files[f.Name] = data
+	}
+
+	// 2. Tanda tangan manifest
+	manifestJSON, signature := files[FileManifest], files[FileSignature]
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if manifestJSON == nil || err != nil || !ed25519.Verify(publicKey, manifestJSON, sig) {
+		return nil, nil, errors.New("manifest signature is invalid")
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, nil, ErrInvalidBundle
+	}
+
+	// 3. Hash setiap file harus cocok, dan tidak boleh ada file tambahan
+	expected := map[string]bool{FileManifest: true, FileSignature: true}
+	for _, mf := range manifest.Files {
+		expected[mf.Name] = true
+		sum := sha256.Sum256(files[mf.Name])
+		if files[mf.Name] == nil || hex.EncodeToString(sum[:]) != mf.SHA256 {
+			return &manifest, manifestJSON, fmt.Errorf("file %s does not match manifest", mf.Name)
+		}
+	}
+	var extra []string
+	for name := range files {
+		if !expected[name] {
+			extra = append(extra, name)
+		}
+	}
+	if len(extra) > 0 {
+		sort.Strings(extra)
+		return &manifest, manifestJSON, fmt.Errorf("unexpected files in bundle: %v", extra)
+	}
+	return &manifest, manifestJSON, nil
+}
@@ -0,0 +1,134 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Ukuran halaman A4 (point) & tata letak teks
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 50
+	pdfFontSize   = 9
+	pdfLeading    = 12
+	pdfWrapChars  = 100 // Perkiraan lebar Courier 9pt di area cetak
+)
+
+// pdfLine: Satu baris teks; Bold = judul bagian
+type pdfLine struct {
+	Text string
+	Bold bool
+}
+
+// renderPDF menulis PDF teks sederhana (Courier, A4, multi halaman) tanpa dependency eksternal
+func renderPDF(lines []pdfLine) []byte {
+	// 1. Bungkus baris panjang & bagi per halaman
+	perPage := (pdfPageHeight - 2*pdfMargin) / pdfLeading
+	var pages [][]pdfLine
+	var current []pdfLine
+	for _, line := range lines {
+		for _, wrapped := range wrapText(line.Text, pdfWrapChars) {
+			if len(current) == perPage {
+				pages = append(pages, current)
+				current = nil
+			}
+			current = append(current, pdfLine{Text: pdfSafe(wrapped), Bold: line.Bold})
+		}
+	}
+	pages = append(pages, current)
+
+	// 2. Object: 1 catalog, 2 pages, 3-4 font, lalu (page, content) per halaman
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT %d TL %d %d Td\n", pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			font := "F1"
+			if line.Bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "/%s %d Tf (%s) Tj T*\n", font, pdfFontSize, line.Text)
+		}
+		fmt.Fprintf(&content, "ET\nBT /F1 8 Tf %d %d Td (Page %d / %d) Tj ET", pdfPageWidth-pdfMargin-60, pdfMargin/2, i+1, len(pages))
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	// 3. Tulis body + tabel xref
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// pdfSafe: Escape karakter khusus string PDF; karakter di luar ASCII cetak diganti '?'
+func pdfSafe(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// wrapText memecah teks per baris (newline asli dipertahankan), utamakan potong di spasi
+func wrapText(s string, width int) []string {
+	var out []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		runes := []rune(paragraph)
+		for len(runes) > width {
+			cut := width
+			if space := lastSpace(runes[:width]); space > width/2 {
+				cut = space
+			}
+			out = append(out, string(runes[:cut]))
+			runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+		}
+		out = append(out, string(runes))
+	}
+	return out
+}
+
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == ' ' {
+			return i
+		}
+	}
+	return -1
+}
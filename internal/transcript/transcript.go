@@ -0,0 +1,202 @@
+// Package transcript menyusun rekam jejak lengkap satu tiket (metadata, chat + versi lama + lampiran, event audit, verifikasi)
+// menjadi HTML + PDF dalam satu bundle zip dengan manifest bertanda tangan Ed25519.
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/syukurgit/zta/internal/domain"
+	"github.com/syukurgit/zta/pkg/utils"
+)
+
+const timeLayout = "2006-01-02 15:04:05 MST"
+
+// Document: Semua data yang masuk ke transcript
+type Document struct {
+	Ticket        domain.Ticket
+	Chats         []domain.Chat
+	Revisions     []domain.ChatRevision // Versi lama pesan yang diedit / ditarik (beserta lampirannya)
+	Events        []domain.AuditLog
+	Verifications []domain.VerificationSession
+	GeneratedAt   time.Time
+	GeneratedBy   string // Pseudonym auditor
+}
+
+// senderLabel: Identitas CS tetap dipseudonimkan seperti di AuditLog
+func senderLabel(chat domain.Chat) string {
+	if chat.SenderRole == domain.RoleCS {
+		return "CS " + utils.AnonymizeID(chat.SenderID)[:12]
+	}
+	return chat.SenderRole
+}
+
+// chatText: Isi pesan + penanda edit / tarik
+func chatText(chat domain.Chat) string {
+	switch {
+	case chat.RetractedAt != nil:
+		return fmt.Sprintf("[message retracted at %s]", chat.RetractedAt.UTC().Format(timeLayout))
+	case chat.EditedAt != nil:
+		return fmt.Sprintf("%s  [edited %s, version %d]", chat.Message, chat.EditedAt.UTC().Format(timeLayout), chat.Version)
+	}
+	return chat.Message
+}
+
+// attachmentText: Metadata lampiran (isi file tidak ikut diekspor; SHA-256 untuk mencocokkan blob)
+func attachmentText(a domain.Attachment) string {
+	return fmt.Sprintf("%s (%s, %d bytes, sha256 %s)", a.FileName, a.MimeType, a.Size, a.SHA256)
+}
+
+func formatTime(t time.Time) string { return t.UTC().Format(timeLayout) }
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return formatTime(*t)
+}
+
+func short(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"time":       formatTime,
+	"optTime":    formatOptionalTime,
+	"sender":     senderLabel,
+	"chatText":   chatText,
+	"short":      short,
+	"attachment": attachmentText,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Ticket #{{.Ticket.ID}} transcript</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; margin: 32px; color: #222; }
+h1 { font-size: 20px; } h2 { font-size: 16px; margin-top: 28px; border-bottom: 1px solid #ccc; }
+table { border-collapse: collapse; width: 100%; } td, th { border: 1px solid #ddd; padding: 4px 6px; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; } .muted { color: #777; } .msg { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Ticket #{{.Ticket.ID}} transcript</h1>
+<p class="muted">Generated {{time .GeneratedAt}} by {{short .GeneratedBy}}</p>
+
+<h2>Ticket</h2>
+<table>
+<tr><th>Subject</th><td>{{.Ticket.Subject}}</td></tr>
+<tr><th>Status</th><td>{{.Ticket.Status}}</td></tr>
+<tr><th>Category / Priority</th><td>{{.Ticket.Category}} / {{.Ticket.Priority}}</td></tr>
+<tr><th>Language</th><td>{{.Ticket.Language}}</td></tr>
+<tr><th>User ID</th><td>{{.Ticket.UserID}}</td></tr>
+<tr><th>Created</th><td>{{time .Ticket.CreatedAt}}</td></tr>
+<tr><th>Closed</th><td>{{optTime .Ticket.ClosedAt}}</td></tr>
+<tr><th>SLA breached</th><td>first response: {{.Ticket.FirstResponseBreached}}, resolution: {{.Ticket.ResolutionBreached}}</td></tr>
+<tr><th>Legal hold</th><td>{{.Ticket.LegalHold}}</td></tr>
+</table>
+
+<h2>Verification</h2>
+{{if .Verifications}}<table>
+<tr><th>Session</th><th>Status</th><th>Attempts</th><th>Created</th><th>Expires</th></tr>
+{{range .Verifications}}<tr><td>{{short .ID}}</td><td>{{.Status}}</td><td>{{.AttemptCount}}</td><td>{{time .CreatedAt}}</td><td>{{time .ExpiresAt}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No verification sessions.</p>{{end}}
+
+<h2>Chat</h2>
+{{if .Chats}}<table>
+<tr><th>#</th><th>Time</th><th>Sender</th><th>Message</th><th>Attachments</th></tr>
+{{range .Chats}}<tr><td>{{.ID}}</td><td>{{time .CreatedAt}}</td><td>{{sender .}}</td><td class="msg">{{chatText .}}</td><td>{{range .Attachments}}<div>{{attachment .}}</div>{{else}}<span class="muted">-</span>{{end}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No messages.</p>{{end}}
+
+<h2>Message revisions</h2>
+{{if .Revisions}}<table>
+<tr><th>Message #</th><th>Version</th><th>Replaced</th><th>Previous message</th><th>Attachments</th></tr>
+{{range .Revisions}}<tr><td>{{.ChatID}}</td><td>{{.Version}}</td><td>{{.Action}} {{time .CreatedAt}}</td><td class="msg">{{.Message}}</td><td>{{range .Attachments}}<div>{{attachment .}}</div>{{else}}<span class="muted">-</span>{{end}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No edited or retracted messages.</p>{{end}}
+
+<h2>Audit events</h2>
+{{if .Events}}<table>
+<tr><th>Time</th><th>Actor</th><th>Action</th><th>Result</th><th>Context</th></tr>
+{{range .Events}}<tr><td>{{time .Timestamp}}</td><td>{{.ActorRole}} {{short .ActorHash}}</td><td>{{.Action}}</td><td>{{.Result}}</td><td class="msg">{{.Context}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No audit events.</p>{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML: Transcript HTML mandiri (CSS inline, tanpa resource eksternal)
+func RenderHTML(doc Document) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPDF: Isi yang sama dengan HTML dalam PDF teks
+func RenderPDF(doc Document) []byte {
+	t := doc.Ticket
+	lines := []pdfLine{
+		{Text: fmt.Sprintf("Ticket #%d transcript", t.ID), Bold: true},
+		{Text: fmt.Sprintf("Generated %s by %s", formatTime(doc.GeneratedAt), short(doc.GeneratedBy))},
+		{},
+		{Text: "TICKET", Bold: true},
+		{Text: "Subject:   " + t.Subject},
+		{Text: "Status:    " + t.Status},
+		{Text: fmt.Sprintf("Category:  %s / %s, language %s", t.Category, t.Priority, t.Language)},
+		{Text: fmt.Sprintf("User ID:   %d", t.UserID)},
+		{Text: "Created:   " + formatTime(t.CreatedAt)},
+		{Text: "Closed:    " + formatOptionalTime(t.ClosedAt)},
+		{Text: fmt.Sprintf("SLA:       first response breached %t, resolution breached %t", t.FirstResponseBreached, t.ResolutionBreached)},
+		{Text: fmt.Sprintf("Legal hold: %t", t.LegalHold)},
+		{},
+		{Text: "VERIFICATION", Bold: true},
+	}
+	if len(doc.Verifications) == 0 {
+		lines = append(lines, pdfLine{Text: "No verification sessions."})
+	}
+	for _, v := range doc.Verifications {
+		lines = append(lines, pdfLine{Text: fmt.Sprintf("%s  %-8s attempts %d, created %s", short(v.ID), v.Status, v.AttemptCount, formatTime(v.CreatedAt))})
+	}
+
+	lines = append(lines, pdfLine{}, pdfLine{Text: "CHAT", Bold: true})
+	if len(doc.Chats) == 0 {
+		lines = append(lines, pdfLine{Text: "No messages."})
+	}
+	for _, chat := range doc.Chats {
+		lines = append(lines, pdfLine{Text: fmt.Sprintf("#%d %s  %s:", chat.ID, formatTime(chat.CreatedAt), senderLabel(chat))})
+		lines = append(lines, pdfLine{Text: "    " + chatText(chat)})
+		lines = appendAttachmentLines(lines, chat.Attachments)
+	}
+
+	lines = append(lines, pdfLine{}, pdfLine{Text: "MESSAGE REVISIONS", Bold: true})
+	if len(doc.Revisions) == 0 {
+		lines = append(lines, pdfLine{Text: "No edited or retracted messages."})
+	}
+	for _, rev := range doc.Revisions {
+		lines = append(lines, pdfLine{Text: fmt.Sprintf("#%d version %d, %s %s:", rev.ChatID, rev.Version, rev.Action, formatTime(rev.CreatedAt))})
+		lines = append(lines, pdfLine{Text: "    " + rev.Message})
+		lines = appendAttachmentLines(lines, rev.Attachments)
+	}
+
+	lines = append(lines, pdfLine{}, pdfLine{Text: "AUDIT EVENTS", Bold: true})
+	if len(doc.Events) == 0 {
+		lines = append(lines, pdfLine{Text: "No audit events."})
+	}
+	for _, e := range doc.Events {
+		lines = append(lines, pdfLine{Text: fmt.Sprintf("%s  %s %s  %s %s  %s",
+			formatTime(e.Timestamp), e.ActorRole, short(e.ActorHash), e.Action, e.Result, e.Context)})
+	}
+	return renderPDF(lines)
+}
+
+func appendAttachmentLines(lines []pdfLine, attachments []domain.Attachment) []pdfLine {
+	for _, a := range attachments {
+		lines = append(lines, pdfLine{Text: "    [attachment] " + attachmentText(a)})
+	}
+	return lines
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+// blindIndexKey: Kunci HMAC blind index (BLIND_INDEX_KEY, fallback DATA_ENCRYPTION_KEY / SYSTEM_SECRET_KEY).
+// Mengganti kunci ini membuat semua index lama tidak cocok lagi.
+func blindIndexKey() []byte {
+	secret := os.Getenv("BLIND_INDEX_KEY")
+	if secret == "" {
+		secret = os.Getenv("DATA_ENCRYPTION_KEY")
+	}
+	if secret == "" {
+		secret = os.Getenv("SYSTEM_SECRET_KEY")
+	}
+	key := sha256.Sum256([]byte("zta-blind-index|" + secret))
+	return key[:]
+}
+
+// BlindIndex: HMAC deterministik dari nilai ternormalisasi, untuk lookup kolom terenkripsi (mis. email saat login)
+func BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, blindIndexKey())
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
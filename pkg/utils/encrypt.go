@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+)
+
+// dataKey: Kunci AES-256 untuk data sensitif di DB (DATA_ENCRYPTION_KEY, fallback SYSTEM_SECRET_KEY)
+func dataKey() []byte {
+	secret := os.Getenv("DATA_ENCRYPTION_KEY")
+	if secret == "" {
+		secret = os.Getenv("SYSTEM_SECRET_KEY")
+	}
+	key := sha256.Sum256([]byte("zta-data-key|" + secret))
+	return key[:]
+}
+
+// EncryptString mengenkripsi plaintext dengan AES-256-GCM (nonce acak di depan ciphertext, base64)
+func EncryptString(plaintext string) (string, error) {
+	sealed, err := SealGCM(dataKey(), []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString kebalikan dari EncryptString
+func DecryptString(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := OpenGCM(dataKey(), sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// SealGCM mengenkripsi dengan AES-GCM memakai key apa pun (nonce acak di depan ciphertext)
+func SealGCM(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// OpenGCM kebalikan dari SealGCM
+func OpenGCM(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
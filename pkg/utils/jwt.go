@@ -13,16 +13,22 @@ var privateKey = []byte(os.Getenv("SYSTEM_SECRET_KEY"))
 
 // JWTClaims mendefinisikan isi dari token kita
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID      uint     `json:"user_id"`
+	Role        string   `json:"role"`  // Role utama
+	Roles       []string `json:"roles"` // Semua role akun
+	Permissions []string `json:"perms"` // Permission efektif saat login (server tetap mengecek ulang lewat sesi)
+	SessionID   string   `json:"sid"`   // UserSession.ID: token ikut mati saat sesi dicabut
 	jwt.RegisteredClaims
 }
 
-// GenerateToken membuat token baru yang berlaku selama durasi tertentu (ttl)
-func GenerateToken(userID uint, role string, ttl time.Duration) (string, error) {
+// GenerateToken membuat token baru untuk satu sesi yang berlaku selama durasi tertentu (ttl)
+func GenerateToken(userID uint, role string, roles, permissions []string, sessionID string, ttl time.Duration) (string, error) {
 	claims := JWTClaims{
-		UserID: userID,
-		Role:   role,
+		UserID:      userID,
+		Role:        role,
+		Roles:       roles,
+		Permissions: permissions,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)), // Kapan kadaluarsa
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
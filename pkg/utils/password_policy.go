@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	MinPasswordLength = 12
+	MaxPasswordBytes  = 72 // Batas input bcrypt
+)
+
+// commonPasswords: Password populer yang tetap lolos aturan panjang / variasi karakter
+var commonPasswords = map[string]bool{
+	"password1234": true, "password123!": true, "passw0rd1234": true, "qwerty123456": true,
+	"123456789012": true, "iloveyou1234": true, "welcome12345": true, "admin1234567": true,
+	"p@ssw0rd1234": true, "p@ssword1234": true, "bismillah123": true, "indonesia123": true,
+}
+
+// CheckPasswordPolicy memeriksa kekuatan password: minimal 12 karakter, minimal 3 dari 4 jenis karakter
+// (huruf kecil, huruf besar, angka, simbol), bukan password populer, dan tidak memuat bagian lokal email.
+func CheckPasswordPolicy(password, email string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordBytes {
+		return fmt.Errorf("password must be at most %d bytes", MaxPasswordBytes)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < 3 {
+		return errors.New("password must mix at least 3 of: lowercase, uppercase, digits, symbols")
+	}
+
+	lowered := strings.ToLower(password)
+	if commonPasswords[lowered] {
+		return errors.New("password is too common")
+	}
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok && len(local) >= 3 && strings.Contains(lowered, local) {
+		return errors.New("password must not contain your email address")
+	}
+	return nil
+}
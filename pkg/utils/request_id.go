@@ -0,0 +1,19 @@
+package utils
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID menyimpan Request ID ke context agar bisa dibaca sampai layer repository
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext mengambil Request ID (string kosong jika tidak ada, mis. dari cmd/)
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
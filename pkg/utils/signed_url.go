@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Label derivasi kunci signed URL (domain separation dari kunci JWT)
+const signedURLKeyLabel = "attachment-url"
+
+// signedURLKey: Kunci khusus signed URL, diturunkan dari SYSTEM_SECRET_KEY (HMAC dengan label) agar
+// tanda tangan URL tidak pernah dibuat dengan kunci yang sama seperti JWT
+func signedURLKey() []byte {
+	h := hmac.New(sha256.New, []byte(os.Getenv("SYSTEM_SECRET_KEY")))
+	h.Write([]byte(signedURLKeyLabel))
+	return h.Sum(nil)
+}
+
+// SignParams membuat tanda tangan HMAC untuk URL berumur pendek (mis. download lampiran).
+// parts mengikat URL ke resource & aktor; expiresAt ikut ditandatangani.
+func SignParams(expiresAt time.Time, parts ...string) string {
+	h := hmac.New(sha256.New, signedURLKey())
+	h.Write([]byte(strings.Join(append(parts, strconv.FormatInt(expiresAt.Unix(), 10)), "|")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifySignedParams memeriksa tanda tangan (constant-time) dan masa berlaku
+func VerifySignedParams(signature string, expiresUnix int64, parts ...string) error {
+	expiresAt := time.Unix(expiresUnix, 0)
+	if time.Now().After(expiresAt) {
+		return errors.New("link expired")
+	}
+	expected := SignParams(expiresAt, parts...)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}